@@ -0,0 +1,201 @@
+// Command admin manages accounts from the command line, e.g. to bootstrap the first owner:
+//
+//	go run ./cmd/admin create-user -email owner@example.com -phone 6281234567890 -address "Jakarta" -role owner
+//	go run ./cmd/admin set-role -email staff@example.com -role staff
+//
+// create-user asks for the password without echoing it, or reads it from ADMIN_PASSWORD or from
+// the first line of stdin when stdin is not a terminal, so it never shows up in the shell history or ps.
+// It reads the same DB_* environment variables (or .env file) as the API. Changes are written to the
+// audit log as actions of the system.
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/joho/godotenv"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/term"
+	"gorm.io/gorm"
+
+	"jakarta-luxury-rent-car/database"
+	"jakarta-luxury-rent-car/models"
+	"jakarta-luxury-rent-car/services"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: admin create-user -email EMAIL -phone PHONE -address ADDRESS [-role owner]")
+	fmt.Fprintln(os.Stderr, "       admin set-role -email EMAIL -role ROLE")
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("Error loading .env file")
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "create-user":
+		err = createUser(os.Args[2:])
+	case "set-role":
+		err = setRole(os.Args[2:])
+	default:
+		usage()
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// createUser creates an account with any role, it is how the first owner or admin is created
+func createUser(args []string) error {
+	flags := flag.NewFlagSet("create-user", flag.ExitOnError)
+	email := flags.String("email", "", "email of the account")
+	phone := flags.String("phone", "", "phone number, e.g. 6281234567890")
+	address := flags.String("address", "", "address")
+	role := flags.String("role", services.RoleOwner, "role: user, owner, staff, driver or admin")
+	flags.Parse(args)
+
+	if *email == "" || *phone == "" || *address == "" {
+		usage()
+	}
+	if !services.IsRole(*role) {
+		return fmt.Errorf("%w: %q", services.ErrUnknownRole, *role)
+	}
+	policy, err := services.LoadPasswordPolicy()
+	if err != nil {
+		return err
+	}
+	password, err := readPassword()
+	if err != nil {
+		return err
+	}
+	if err := policy.Validate(password, *email); err != nil {
+		return err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	database.InitDB()
+
+	var existingUser models.User
+	if err := database.DB.Where("email = ?", *email).First(&existingUser).Error; err == nil {
+		return services.ErrUserAlreadyExists
+	}
+
+	user := models.User{
+		Email:       *email,
+		Password:    string(hashedPassword),
+		PhoneNumber: *phone,
+		Address:     *address,
+		Role:        *role,
+	}
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+
+		return services.RecordAudit(tx, services.AuditActor{Role: services.ActorSystem}, services.AuditEntry{
+			Action:     services.AuditUserCreate,
+			EntityType: services.AuditEntityUser,
+			EntityID:   fmt.Sprint(user.UserID),
+			After:      map[string]interface{}{"email": user.Email, "role": user.Role, "source": "admin cli"},
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+
+	fmt.Printf("Created %s %s with user ID %d\n", user.Role, user.Email, user.UserID)
+	return nil
+}
+
+// readPassword takes the password of a new account from ADMIN_PASSWORD, from a prompt that does
+// not echo it (asked twice), or from the first line of stdin when it is piped in
+func readPassword() (string, error) {
+	if password := os.Getenv("ADMIN_PASSWORD"); password != "" {
+		return password, nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", fmt.Errorf("failed to read password from stdin: %w", err)
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+
+	fmt.Fprint(os.Stderr, "Password: ")
+	password, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+	fmt.Fprint(os.Stderr, "Repeat password: ")
+	repeated, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+	if string(password) != string(repeated) {
+		return "", errors.New("passwords do not match")
+	}
+	return string(password), nil
+}
+
+// setRole changes the role of an existing account
+func setRole(args []string) error {
+	flags := flag.NewFlagSet("set-role", flag.ExitOnError)
+	email := flags.String("email", "", "email of the account")
+	role := flags.String("role", "", "role: user, owner, staff, driver or admin")
+	flags.Parse(args)
+
+	if *email == "" || *role == "" {
+		usage()
+	}
+	if !services.IsRole(*role) {
+		return fmt.Errorf("%w: %q", services.ErrUnknownRole, *role)
+	}
+
+	database.InitDB()
+
+	var user models.User
+	if err := database.DB.Where("email = ?", *email).First(&user).Error; err != nil {
+		return fmt.Errorf("user %s not found: %w", *email, err)
+	}
+
+	from := user.Role
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Update("role", *role).Error; err != nil {
+			return err
+		}
+
+		return services.RecordAudit(tx, services.AuditActor{Role: services.ActorSystem}, services.AuditEntry{
+			Action:     services.AuditUserRoleChange,
+			EntityType: services.AuditEntityUser,
+			EntityID:   fmt.Sprint(user.UserID),
+			Before:     map[string]interface{}{"role": from},
+			After:      map[string]interface{}{"role": user.Role, "source": "admin cli"},
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update role: %w", err)
+	}
+
+	fmt.Printf("%s is now %s\n", *email, *role)
+	return nil
+}
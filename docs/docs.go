@@ -15,9 +15,14 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/cars": {
-            "get": {
-                "description": "Get available luxury cars",
+        "/admin/invitations": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Email a one-time link to create a staff, driver, owner or admin account. The link is also returned so it can be shared another way.",
                 "consumes": [
                     "application/json"
                 ],
@@ -25,67 +30,67 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Public"
+                    "Role Admin"
+                ],
+                "summary": "Invite a staff member",
+                "parameters": [
+                    {
+                        "description": "Email and role of the invited user",
+                        "name": "invitationReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.InvitationRequest"
+                        }
+                    }
                 ],
-                "summary": "Get available luxury cars",
                 "responses": {
-                    "200": {
-                        "description": "List of available luxury cars",
+                    "201": {
+                        "description": "Invitation, its link and whether the email was sent",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Car"
-                            }
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
-                    "500": {
-                        "description": "Error retrieving cars from database",
+                    "400": {
+                        "description": "Invalid request format or validation error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
-                    }
-                }
-            }
-        },
-        "/drivers": {
-            "get": {
-                "description": "Get drivers",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Public"
-                ],
-                "summary": "Get drivers",
-                "responses": {
-                    "200": {
-                        "description": "List of available drivers",
+                    },
+                    "403": {
+                        "description": "Permission denied",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Driver"
-                            }
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "User already exists with this email",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "500": {
-                        "description": "Error get drivers",
+                        "description": "Failed to create invitation",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "additionalProperties": true
                         }
                     }
                 }
             }
         },
-        "/login": {
-            "post": {
-                "description": "Login user and return JWT token",
+        "/admin/users": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List accounts, optionally filtered by role, email and suspension",
                 "consumes": [
                     "application/json"
                 ],
@@ -93,43 +98,72 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Register and Login"
+                    "Role Admin"
                 ],
-                "summary": "User login",
+                "summary": "List users",
                 "parameters": [
                     {
-                        "description": "User login request body",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.LoginRequest"
-                        }
+                        "enum": [
+                            "user",
+                            "owner",
+                            "staff",
+                            "driver",
+                            "admin"
+                        ],
+                        "type": "string",
+                        "description": "Only users with this role",
+                        "name": "role",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only users whose email contains this text",
+                        "name": "email",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only suspended (true) or active (false) users",
+                        "name": "suspended",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number, starting at 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Users per page (default 20, max 100)",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Page of users",
                         "schema": {
-                            "$ref": "#/definitions/handlers.UserResponse"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "400": {
-                        "description": "Invalid input",
+                        "description": "Invalid filter, page or limit",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "401": {
-                        "description": "Invalid email or password",
+                    "403": {
+                        "description": "Permission denied",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "500": {
-                        "description": "Failed to generate token",
+                        "description": "Failed to fetch users",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -138,14 +172,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/owner/approve-booking": {
+        "/admin/users/{id}/reactivate": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Approve or reject a car booking",
+                "description": "Lift the suspension and any failed login lockout of another user",
                 "consumes": [
                     "application/json"
                 ],
@@ -153,51 +187,48 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Role Owner"
+                    "Role Admin"
                 ],
-                "summary": "Approve or reject a car booking",
+                "summary": "Re-enable a user",
                 "parameters": [
                     {
-                        "description": "Approval request body containing rental ID and action (approve/reject)",
-                        "name": "approvalReq",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.ApprovalRequest"
-                        }
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Success message indicating the booking has been approved or rejected",
+                        "description": "Re-enabled user",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/handlers.AdminUserResponse"
                         }
                     },
                     "400": {
-                        "description": "Invalid request format, validation error, car out of stock, or invalid action",
+                        "description": "Invalid user ID",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "403": {
-                        "description": "Permission denied. Only owners can approve or reject bookings.",
+                        "description": "Permission denied",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "404": {
-                        "description": "User, car, or rental history not found",
+                        "description": "User not found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "500": {
-                        "description": "Failed to update car stock, rental history, or send notifications",
+                        "description": "Failed to re-enable user",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -206,44 +237,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/packages": {
-            "get": {
-                "description": "Retrieve a list of all available event packages",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Public"
-                ],
-                "summary": "Get available event packages",
-                "responses": {
-                    "200": {
-                        "description": "List of available event packages",
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.EventPackage"
-                            }
-                        }
-                    },
-                    "500": {
-                        "description": "Error get event packages",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
+        "/admin/users/{id}/role": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
                     }
-                }
-            }
-        },
-        "/register": {
-            "post": {
-                "description": "Register new user",
+                ],
+                "description": "Change the role of another user. The user has to log in again for the new role to apply.",
                 "consumes": [
                     "application/json"
                 ],
@@ -251,43 +252,57 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Register and Login"
+                    "Role Admin"
                 ],
-                "summary": "Register new user",
+                "summary": "Change the role of a user",
                 "parameters": [
                     {
-                        "description": "User registration request body",
-                        "name": "request",
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New role",
+                        "name": "roleReq",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.RegisterRequest"
+                            "$ref": "#/definitions/handlers.UserRoleRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "Updated user",
                         "schema": {
-                            "$ref": "#/definitions/handlers.UserResponse"
+                            "$ref": "#/definitions/handlers.AdminUserResponse"
                         }
                     },
                     "400": {
-                        "description": "Invalid input",
+                        "description": "Invalid request format or validation error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "409": {
-                        "description": "User already exists with this email",
+                    "403": {
+                        "description": "Permission denied",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "500": {
-                        "description": "Failed to hash password or register user",
+                        "description": "Failed to update role",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -296,14 +311,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/booking": {
+        "/admin/users/{id}/suspend": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Book a car",
+                "description": "Suspend another user. Suspended users are logged out, cannot log in and their tokens are rejected.",
                 "consumes": [
                     "application/json"
                 ],
@@ -311,66 +326,59 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Role User"
+                    "Role Admin"
                 ],
-                "summary": "Book a car",
+                "summary": "Suspend a user",
                 "parameters": [
                     {
-                        "description": "Booking request body containing car ID and other booking details",
-                        "name": "bookingReq",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.BookingRequest"
-                        }
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Success message and details of the car booking",
+                        "description": "Suspended user",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.AdminUserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid user ID",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "400": {
-                        "description": "Invalid request format, validation error, or insufficient stock",
+                    "403": {
+                        "description": "Permission denied",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "additionalProperties": true
                         }
                     },
                     "404": {
-                        "description": "Car, driver, or package not found",
+                        "description": "User not found",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "additionalProperties": true
                         }
                     },
                     "500": {
-                        "description": "Failed to create rental history, send notifications, or process booking",
+                        "description": "Failed to suspend user",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "additionalProperties": true
                         }
                     }
                 }
             }
         },
-        "/users/call-assistance": {
-            "post": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Call assistance request",
+        "/cars": {
+            "get": {
+                "description": "Get luxury cars that have at least one free unit between rental_date and return_date (defaults to the next 24 hours)",
                 "consumes": [
                     "application/json"
                 ],
@@ -378,44 +386,42 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Role User"
+                    "Public"
                 ],
-                "summary": "Call assistance request",
+                "summary": "Get available luxury cars",
                 "parameters": [
                     {
-                        "description": "Call assistance request body containing rental ID, location, and description",
-                        "name": "assistanceReq",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.CallAssistanceRequest"
-                        }
+                        "type": "string",
+                        "description": "Start of the rental window (YYYY-MM-DD or RFC3339)",
+                        "name": "rental_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End of the rental window (YYYY-MM-DD or RFC3339)",
+                        "name": "return_date",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Success message and details of the call assistance request",
+                        "description": "List of available luxury cars",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/handlers.CarListing"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Invalid request format or validation error",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    },
-                    "404": {
-                        "description": "User, rental history, or car not found",
+                        "description": "Invalid rental window",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "500": {
-                        "description": "Failed to create call assistance record or send WhatsApp notification",
+                        "description": "Error retrieving cars from database",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -424,14 +430,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/get-deposit": {
+        "/drivers": {
             "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "RGet user deposit amount",
+                "description": "Get drivers",
                 "consumes": [
                     "application/json"
                 ],
@@ -439,19 +440,92 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Role User"
+                    "Public"
                 ],
-                "summary": "Get user deposit amount",
+                "summary": "Get drivers",
                 "responses": {
                     "200": {
-                        "description": "User's email and deposit amount",
+                        "description": "List of available drivers",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Driver"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Error get drivers",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/invitations/accept": {
+            "post": {
+                "description": "Create the account an invitation was sent for. Each invitation link works once.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Register and Login"
+                ],
+                "summary": "Accept an invitation",
+                "parameters": [
+                    {
+                        "description": "Invitation token and account details",
+                        "name": "acceptReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.AcceptInvitationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format, validation error, or password does not meet the password policy",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "404": {
-                        "description": "User not found",
+                        "description": "Invitation not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Invitation already used or user already exists",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "410": {
+                        "description": "Invitation has expired",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to accept invitation",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -460,14 +534,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/get-membership": {
-            "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Get user membership details",
+        "/login": {
+            "post": {
+                "description": "Login user and return a short-lived JWT access token and a refresh token",
                 "consumes": [
                     "application/json"
                 ],
@@ -475,19 +544,64 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Role User"
+                    "Register and Login"
+                ],
+                "summary": "User login",
+                "parameters": [
+                    {
+                        "description": "User login request body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.LoginRequest"
+                        }
+                    }
                 ],
-                "summary": "Get user membership details",
                 "responses": {
                     "200": {
-                        "description": "Membership details including membership ID, user ID, email, and discount level",
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UserResponse"
+                        }
+                    },
+                    "202": {
+                        "description": "Two-factor login: challenge token for /login/otp",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "404": {
-                        "description": "User not found or no membership found",
+                    "400": {
+                        "description": "Invalid input",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid email or password, or account temporarily locked",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Account suspended",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "429": {
+                        "description": "Too many login attempts, or a code was sent less than a minute ago",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to generate token",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -496,14 +610,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/making-payment": {
+        "/login/otp": {
             "post": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "MakingPayment updates status from \"Book\" to \"Paid\"",
+                "description": "Finish the login of an account with two-factor login by sending the code it received over WhatsApp",
                 "consumes": [
                     "application/json"
                 ],
@@ -511,68 +620,66 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Role User"
+                    "Register and Login"
                 ],
-                "summary": "MakingPayment updates status from \"Book\" to \"Paid\"",
+                "summary": "Login second factor",
                 "parameters": [
                     {
-                        "description": "Payment request body containing rental ID and payment details",
-                        "name": "paymentReq",
+                        "description": "Challenge token from /login and the code",
+                        "name": "otpReq",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.PaymentRequest"
+                            "$ref": "#/definitions/handlers.LoginOTPRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Rental status updated to 'Paid' successfully",
+                        "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/handlers.UserResponse"
                         }
                     },
                     "400": {
-                        "description": "Invalid request body or rental status is not 'Book'",
+                        "description": "Invalid request format, validation error, or invalid or expired code",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "additionalProperties": true
                         }
                     },
-                    "404": {
-                        "description": "Rental history or user not found",
+                    "403": {
+                        "description": "Account suspended",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "additionalProperties": true
+                        }
+                    },
+                    "429": {
+                        "description": "Too many wrong codes",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "500": {
-                        "description": "Failed to update rental status or deposit amount",
+                        "description": "Failed to generate token",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "additionalProperties": true
                         }
                     }
                 }
             }
         },
-        "/users/register-membership": {
+        "/logout": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Register a new membership",
+                "description": "Revoke the current session: its refresh tokens and the access tokens issued with them",
                 "consumes": [
                     "application/json"
                 ],
@@ -580,33 +687,55 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Role User"
+                    "Register and Login"
                 ],
-                "summary": "Register a new membership",
+                "summary": "Logout",
                 "responses": {
                     "200": {
-                        "description": "Details of the newly registered membership including membership ID, user ID, email, and discount level",
+                        "description": "Logged out",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
-                    "400": {
-                        "description": "User already has a membership registered",
+                    "500": {
+                        "description": "Failed to log out",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
-                    },
-                    "404": {
-                        "description": "User not found",
+                    }
+                }
+            }
+        },
+        "/logout-all": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Revoke every session of the current user, including the one making the request",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Register and Login"
+                ],
+                "summary": "Logout from all devices",
+                "responses": {
+                    "200": {
+                        "description": "Logged out from all devices",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "500": {
-                        "description": "Failed to register membership",
+                        "description": "Failed to log out",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -615,14 +744,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/users/topup": {
+        "/owner/approve-booking": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Top up user deposit amount",
+                "description": "Approve or reject a car booking. Rejecting a paid booking refunds it in full.",
                 "consumes": [
                     "application/json"
                 ],
@@ -630,46 +759,58 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Role User"
+                    "Role Owner"
                 ],
-                "summary": "Top up user deposit amount",
+                "summary": "Approve or reject a car booking",
                 "parameters": [
                     {
-                        "description": "Top-up request body",
-                        "name": "topUpReq",
+                        "description": "Approval request body containing rental ID and action (approve/reject)",
+                        "name": "approvalReq",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/handlers.TopUpRequest"
+                            "$ref": "#/definitions/handlers.ApprovalRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Updated email and deposit amount",
+                        "description": "Success message indicating the booking has been approved or rejected",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "400": {
-                        "description": "Invalid request format",
+                        "description": "Invalid request format, validation error, car not available for the rental dates, or invalid action",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Permission denied",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "404": {
-                        "description": "User not found",
+                        "description": "User, car, or rental history not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Rental status does not allow this action, e.g. approving an unpaid booking",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
                     },
                     "500": {
-                        "description": "Failed to update deposit amount",
+                        "description": "Failed to update rental history or send notifications",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -677,22 +818,2639 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "handlers.ApprovalRequest": {
-            "type": "object",
-            "required": [
-                "action",
-                "rental_id"
-            ],
-            "properties": {
-                "action": {
-                    "type": "string",
-                    "enum": [
-                        "approve",
-                        "reject"
-                    ]
+        },
+        "/owner/audit": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List privileged and money-moving actions, newest first, optionally filtered by actor, action, entity and time",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Role Owner"
+                ],
+                "summary": "List the audit log",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Only actions of this user",
+                        "name": "actor_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only this action, e.g. booking.approve or user.role_change",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only actions on this kind of entity, e.g. rental, user or deposit_top_up",
+                        "name": "entity_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only actions on this entity, combine with entity_type",
+                        "name": "entity_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only actions at or after this date (YYYY-MM-DD) or time (RFC3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only actions before this date (YYYY-MM-DD, exclusive) or time (RFC3339)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number, starting at 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Entries per page (default 50, max 200)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Page of audit log entries",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid filter, page or limit",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Permission denied",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to fetch audit log",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/owner/fleet-units": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the physical units behind each car, optionally filtered by car_id",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Role Owner"
+                ],
+                "summary": "List fleet units",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Only units of this car",
+                        "name": "car_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of fleet units",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.FleetUnit"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Permission denied",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to fetch fleet units",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Register a physical unit (license plate, VIN) of a car and refresh the car stock",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Role Owner"
+                ],
+                "summary": "Add a fleet unit",
+                "parameters": [
+                    {
+                        "description": "Fleet unit details",
+                        "name": "unitReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.FleetUnitRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created fleet unit",
+                        "schema": {
+                            "$ref": "#/definitions/models.FleetUnit"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format or validation error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Permission denied",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "User or car not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to create fleet unit",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/owner/fleet-units/{id}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update the plate, colour, odometer or status of a fleet unit. Rented units can only change status through the rental flow.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Role Owner"
+                ],
+                "summary": "Update a fleet unit",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Fleet unit ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to update",
+                        "name": "unitReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.FleetUnitUpdateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated fleet unit",
+                        "schema": {
+                            "$ref": "#/definitions/models.FleetUnit"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format, validation error, or unit is rented",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Permission denied",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "User or fleet unit not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to update fleet unit",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/owner/pricing": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get car rates, add-on prices, surcharges, rounding rule and holidays. Cars without a rate use their rental cost; add-ons without a price use the defaults.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Role Owner"
+                ],
+                "summary": "Get the price list",
+                "responses": {
+                    "200": {
+                        "description": "Current price list",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.PricingResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Permission denied",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to load pricing",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/owner/pricing/addons/{code}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Set the price of driver, pickup_dropoff, airport_transfer or concierge, charged once or per rental day",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Role Owner"
+                ],
+                "summary": "Set the price of an add-on",
+                "parameters": [
+                    {
+                        "enum": [
+                            "driver",
+                            "pickup_dropoff",
+                            "airport_transfer",
+                            "concierge"
+                        ],
+                        "type": "string",
+                        "description": "Add-on code",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Add-on price",
+                        "name": "addonReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.AddonPriceRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated add-on price",
+                        "schema": {
+                            "$ref": "#/definitions/models.AddonPrice"
+                        }
+                    },
+                    "400": {
+                        "description": "Unknown add-on, invalid request format or validation error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Permission denied",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to update add-on price",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/owner/pricing/cars/{id}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Set the daily, weekly and monthly rate of a car. The daily rate is also stored as the car rental cost.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Role Owner"
+                ],
+                "summary": "Set the rates of a car",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Car ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Car rates",
+                        "name": "rateReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CarRateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated car rates",
+                        "schema": {
+                            "$ref": "#/definitions/models.CarRate"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format or validation error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Permission denied",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "User or car not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to update car rates",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/owner/pricing/holidays": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Add or rename a date that carries the holiday surcharge",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Role Owner"
+                ],
+                "summary": "Add a holiday",
+                "parameters": [
+                    {
+                        "description": "Holiday date (YYYY-MM-DD) and name",
+                        "name": "holidayReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HolidayRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Saved holiday",
+                        "schema": {
+                            "$ref": "#/definitions/models.Holiday"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format or validation error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Permission denied",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to save holiday",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/owner/pricing/holidays/{date}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove a date from the holiday surcharge list",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Role Owner"
+                ],
+                "summary": "Remove a holiday",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Holiday date (YYYY-MM-DD)",
+                        "name": "date",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Holiday removed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid date",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Permission denied",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "User or holiday not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to remove holiday",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/owner/pricing/settings": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Set the weekend and holiday surcharge (percent of the daily rate) and how partial days are charged",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Role Owner"
+                ],
+                "summary": "Set surcharges and rounding",
+                "parameters": [
+                    {
+                        "description": "Pricing settings",
+                        "name": "settingsReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.PricingSettingsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated pricing settings",
+                        "schema": {
+                            "$ref": "#/definitions/models.PricingSetting"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format or validation error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Permission denied",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to update pricing settings",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/owner/refunds": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List all refunds, newest first, optionally filtered by status",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Role Owner"
+                ],
+                "summary": "List refunds",
+                "parameters": [
+                    {
+                        "enum": [
+                            "requested",
+                            "processing",
+                            "completed",
+                            "failed"
+                        ],
+                        "type": "string",
+                        "description": "Only refunds with this status",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "List of refunds",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Refund"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Permission denied",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to fetch refunds",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/owner/refunds/{id}/retry": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Send a gateway refund that was never sent, failed, or got no answer from the payment gateway to the gateway again",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Role Owner"
+                ],
+                "summary": "Retry a failed refund",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Refund ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Refund after the retry",
+                        "schema": {
+                            "$ref": "#/definitions/models.Refund"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid refund ID",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Permission denied",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "User or refund not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Refund is completed, wallet refunded or still waiting on the gateway",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to retry refund",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/owner/rentals/{id}/return": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Record the actual return time, odometer and fuel level, charge any late return to the customer deposit and complete the rental",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Role Owner"
+                ],
+                "summary": "Check in a returned car",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Rental ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Return request body containing odometer, fuel level and optional return time",
+                        "name": "returnReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ReturnRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Completed rental with the late fee that was charged",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format, validation error, or returned_at before the rental date or in the future",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Permission denied",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "User, car, or rental history not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Only rentals with status 'Rent' can be returned",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to complete the rental",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/owner/templates": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the subject and body template of every notification event per locale, with the owner's overrides applied",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Role Owner"
+                ],
+                "summary": "List message templates",
+                "responses": {
+                    "200": {
+                        "description": "Message templates",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/services.TemplateSource"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Permission denied",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to load message templates",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/owner/templates/{event}/{locale}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replace the subject and body of a notification in one locale. Templates use Go text/template syntax with the money, date and percent helpers, and are checked against sample data before saving.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Role Owner"
+                ],
+                "summary": "Update a message template",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Event, e.g. booking_confirmation",
+                        "name": "event",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Locale (id or en)",
+                        "name": "locale",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Subject and body templates",
+                        "name": "templateReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.MessageTemplateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Saved template with a preview rendered from sample data",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format, validation error, or template does not render",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Permission denied",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "User or template not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to save message template",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove the owner's version of a notification so the bundled default is used again",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Role Owner"
+                ],
+                "summary": "Reset a message template",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Event, e.g. booking_confirmation",
+                        "name": "event",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Locale (id or en)",
+                        "name": "locale",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The default template now in use",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Permission denied",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "User or template not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to reset message template",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/owner/wallet/reconciliation": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Compare every user's deposit balance with the sum of their wallet ledger. A non-zero difference means the balance was changed outside the ledger.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Role Owner"
+                ],
+                "summary": "Wallet reconciliation report",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Only list users whose balance does not match the ledger",
+                        "name": "mismatched_only",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Reconciliation rows and the number of mismatches",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Permission denied",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to reconcile wallets",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/packages": {
+            "get": {
+                "description": "Retrieve a list of all available event packages",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Public"
+                ],
+                "summary": "Get available event packages",
+                "responses": {
+                    "200": {
+                        "description": "List of available event packages",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.EventPackage"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Error get event packages",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/password/forgot": {
+            "post": {
+                "description": "Send a password reset code over WhatsApp to the verified phone number of the account. The response is the same whether or not the account exists.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Register and Login"
+                ],
+                "summary": "Forgot password",
+                "parameters": [
+                    {
+                        "description": "Email of the account",
+                        "name": "forgotReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ForgotPasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Code sent if the account exists and its phone number is verified",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format or validation error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/password/reset": {
+            "post": {
+                "description": "Set a new password with the code from /password/forgot. Every session of the account is logged out and a failed login lockout is lifted.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Register and Login"
+                ],
+                "summary": "Reset password",
+                "parameters": [
+                    {
+                        "description": "Email, reset code and new password",
+                        "name": "resetReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ResetPasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Password changed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format, validation error, password does not meet the password policy, or invalid or expired code",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "429": {
+                        "description": "Too many wrong codes",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to reset password",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/payments/xendit/callback": {
+            "post": {
+                "description": "Called by Xendit when an invoice is paid or expires. The x-callback-token header must match XENDIT_CALLBACK_TOKEN and the reported status is confirmed with the payment gateway. A paid booking invoice marks the rental Paid, an expired one cancels a booking that is still unpaid. A paid top up invoice credits the deposit. A payment lower than the invoice is recorded as UNDERPAID and credited to the deposit. A booking invoice paid after the booking was cancelled or already paid is refunded. Replayed callbacks are acknowledged without changing anything.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Public"
+                ],
+                "summary": "Xendit invoice callback",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Xendit callback verification token",
+                        "name": "x-callback-token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Xendit invoice callback",
+                        "name": "callbackReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.XenditInvoiceCallbackRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Callback processed or already processed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format or callback does not match the gateway",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid callback token",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Invoice not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to process callback",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/payments/xendit/refund-callback": {
+            "post": {
+                "description": "Called by Xendit when a refund succeeds or fails. The x-callback-token header must match XENDIT_CALLBACK_TOKEN. Replayed callbacks are acknowledged without changing anything. A refund marked failed is still completed when the gateway reports it succeeded.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Public"
+                ],
+                "summary": "Xendit refund callback",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Xendit callback verification token",
+                        "name": "x-callback-token",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Xendit refund callback",
+                        "name": "callbackReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.XenditRefundCallbackRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Callback processed or already processed",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid callback token",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Refund not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to process callback",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/register": {
+            "post": {
+                "description": "Register a new customer account. Accounts always get the role \"user\"; other roles are granted by an admin. A code to verify the phone number is sent over WhatsApp.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Register and Login"
+                ],
+                "summary": "Register new user",
+                "parameters": [
+                    {
+                        "description": "User registration request body",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RegisterRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid input or password does not meet the password policy",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "User already exists with this email",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "429": {
+                        "description": "Too many registrations from this IP or for this email",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to hash password or register user",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/token/refresh": {
+            "post": {
+                "description": "Exchange a refresh token for a new access token and a new refresh token. Each refresh token works once; using one again logs out that session.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Register and Login"
+                ],
+                "summary": "Refresh the access token",
+                "parameters": [
+                    {
+                        "description": "Refresh token from login or the previous refresh",
+                        "name": "refreshReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.RefreshTokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format or validation error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Refresh token is invalid, expired, revoked or reused",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Account suspended",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to refresh token",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/users/booking": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Book a car. The confirmation message and the invoice are delivered in the background shortly after the booking is created.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Role User"
+                ],
+                "summary": "Book a car",
+                "parameters": [
+                    {
+                        "description": "Booking request body containing car ID and other booking details",
+                        "name": "bookingReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BookingRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success message and details of the car booking",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format, validation error, or car not available for the selected dates",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Car, driver, or package not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to calculate the price or create rental history",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/users/booking/quote": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Itemise what a booking would cost, membership discount included, without creating it",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Role User"
+                ],
+                "summary": "Quote a booking",
+                "parameters": [
+                    {
+                        "description": "Booking request body containing car ID and other booking details",
+                        "name": "bookingReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BookingRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Price breakdown and free units for the requested dates",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format or validation error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Car, driver, or package not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to calculate price or availability",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/users/bookings/{id}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Change dates, driver, package, locations or add-ons of your own booking. Availability and price are recalculated; paid bookings are charged or refunded the difference on the deposit, or get a supplementary invoice for an increase the deposit does not cover, unpaid bookings get a new booking invoice for the new total that replaces the old one. Picked-up rentals can only change return date and dropoff location.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Role User"
+                ],
+                "summary": "Modify or extend a booking",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Rental ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to change",
+                        "name": "modifyReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ModifyBookingRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Updated booking with the price difference",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format, invalid dates, or car not available for the new dates",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Rental history, driver or package not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Booking can no longer be modified",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to modify booking",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/users/bookings/{id}/cancel": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Cancel your own booking before pickup. Paid bookings are refunded according to the cancellation policy, to the deposit or back through the payment gateway when paid by invoice.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Role User"
+                ],
+                "summary": "Cancel a booking",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Rental ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Optional cancellation reason",
+                        "name": "cancelReq",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CancelBookingRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Cancelled booking with the refunded amount",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid rental ID or request format",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Rental history not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Booking can no longer be cancelled, e.g. the car has been picked up",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to cancel booking",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/users/call-assistance": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Call assistance request",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Role User"
+                ],
+                "summary": "Call assistance request",
+                "parameters": [
+                    {
+                        "description": "Call assistance request body containing rental ID, location, and description",
+                        "name": "assistanceReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CallAssistanceRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success message and details of the call assistance request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format or validation error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "User, rental history, or car not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to create call assistance record or send WhatsApp notification",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/users/get-deposit": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "RGet user deposit amount",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Role User"
+                ],
+                "summary": "Get user deposit amount",
+                "responses": {
+                    "200": {
+                        "description": "User's email and deposit amount",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/users/get-membership": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get user membership details",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Role User"
+                ],
+                "summary": "Get user membership details",
+                "responses": {
+                    "200": {
+                        "description": "Membership details including membership ID, user ID, email, and discount level",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "User not found or no membership found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/users/making-payment": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "MakingPayment pays a booking from the deposit and updates status from \"Book\" to \"Paid\". Bookings paid through the Xendit invoice are marked \"Paid\" by the payment callback instead.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Role User"
+                ],
+                "summary": "MakingPayment updates status from \"Book\" to \"Paid\"",
+                "parameters": [
+                    {
+                        "description": "Payment request body containing rental ID and payment details",
+                        "name": "paymentReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.PaymentRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Rental status updated to 'Paid' successfully",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body or insufficient deposit",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Rental history or owner not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Rental status is not 'Book', cannot proceed to 'Paid'",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to update rental status or deposit amount",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/users/notification-preferences": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Choose the channel notifications are sent over first (whatsapp, email or sms) and their language (id or en). The other configured channels are used as fallbacks.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Role User"
+                ],
+                "summary": "Set notification preferences",
+                "parameters": [
+                    {
+                        "description": "Preferred notification channel and language",
+                        "name": "preferenceReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.NotificationPreferenceRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Saved preferences and the configured channels in fallback order",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format or validation error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to save preference",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/users/phone/verification-code": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Send a one-time code over WhatsApp to the phone number of the account. A code is also sent right after registration.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Role User"
+                ],
+                "summary": "Send a phone verification code",
+                "responses": {
+                    "200": {
+                        "description": "Code sent",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Phone number already verified",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "429": {
+                        "description": "A code was sent less than a minute ago",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to send code",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/users/phone/verify": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Verify the phone number of the account with the code sent over WhatsApp",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Role User"
+                ],
+                "summary": "Verify phone number",
+                "parameters": [
+                    {
+                        "description": "Code sent to the phone",
+                        "name": "verifyReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.VerifyPhoneRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Phone number verified",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format, validation error, or invalid or expired code",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "429": {
+                        "description": "Too many wrong codes",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to verify phone number",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/users/refunds": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the refunds of your cancelled or rejected bookings, newest first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Role User"
+                ],
+                "summary": "List your refunds",
+                "responses": {
+                    "200": {
+                        "description": "List of refunds",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Refund"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to fetch refunds",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/users/register-membership": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Register a new membership",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Role User"
+                ],
+                "summary": "Register a new membership",
+                "responses": {
+                    "200": {
+                        "description": "Details of the newly registered membership including membership ID, user ID, email, and discount level",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "User already has a membership registered",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to register membership",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/users/topup": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a pending top up and a payment gateway invoice for it. The deposit is credited once the gateway confirms the payment.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Role User"
+                ],
+                "summary": "Top up user deposit amount",
+                "parameters": [
+                    {
+                        "description": "Top-up request body",
+                        "name": "topUpReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TopUpRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Pending top up with the invoice URL to pay",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format or top up amount",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to create top up invoice",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/users/topups": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List your top ups, newest first, optionally filtered by status",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Role User"
+                ],
+                "summary": "List top ups",
+                "parameters": [
+                    {
+                        "enum": [
+                            "PENDING",
+                            "PAID",
+                            "EXPIRED",
+                            "UNDERPAID"
+                        ],
+                        "type": "string",
+                        "description": "Only top ups with this status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number, starting at 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Top ups per page (default 20, max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Page of top ups",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid status, page or limit",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to fetch top ups",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/users/two-factor": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "With two-factor login, /login also asks for a code sent over WhatsApp. Only owner and admin accounts with a verified phone number can turn it on, and turning it off needs the current password. Every other session of the account is logged out after a change.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Role Owner"
+                ],
+                "summary": "Turn two-factor login on or off",
+                "parameters": [
+                    {
+                        "description": "Whether two-factor login is on",
+                        "name": "twoFactorReq",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TwoFactorRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Two-factor login setting",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request format or phone number not verified",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid password",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "403": {
+                        "description": "Two-factor login is for owner and admin accounts",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to save setting",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/users/wallet/transactions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the top ups, rental payments, refunds, penalties and adjustments on your deposit, newest first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Role User"
+                ],
+                "summary": "List wallet transactions",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page number, starting at 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Transactions per page (default 20, max 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Page of wallet transactions with the current balance",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid page or limit",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Failed to fetch wallet transactions",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "handlers.AcceptInvitationRequest": {
+            "type": "object",
+            "required": [
+                "address",
+                "password",
+                "phone_number",
+                "token"
+            ],
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "password": {
+                    "description": "Checked against the password policy",
+                    "type": "string"
+                },
+                "phone_number": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.AddonPriceRequest": {
+            "type": "object",
+            "properties": {
+                "per_day": {
+                    "type": "boolean"
+                },
+                "price": {
+                    "type": "number",
+                    "minimum": 0
+                }
+            }
+        },
+        "handlers.AdminUserResponse": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "deposit_amount": {
+                    "type": "number"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "phone_number": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "suspended_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.ApprovalRequest": {
+            "type": "object",
+            "required": [
+                "action",
+                "rental_id"
+            ],
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "enum": [
+                        "approve",
+                        "reject"
+                    ]
+                },
+                "reason": {
+                    "description": "Optional, stored with the status change",
+                    "type": "string"
                 },
                 "rental_id": {
                     "type": "integer"
@@ -751,45 +3509,374 @@ const docTemplate = `{
                 }
             }
         },
-        "handlers.CallAssistanceRequest": {
+        "handlers.CallAssistanceRequest": {
+            "type": "object",
+            "required": [
+                "description",
+                "location",
+                "rental_id"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string"
+                },
+                "location": {
+                    "type": "string"
+                },
+                "rental_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.CancelBookingRequest": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "description": "Optional",
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.CarListing": {
+            "type": "object",
+            "properties": {
+                "available_units": {
+                    "type": "integer"
+                },
+                "car_id": {
+                    "type": "integer"
+                },
+                "category": {
+                    "type": "string"
+                },
+                "class": {
+                    "type": "string"
+                },
+                "fuel_type": {
+                    "type": "string"
+                },
+                "make": {
+                    "type": "string"
+                },
+                "model": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "rental_costs": {
+                    "type": "number"
+                },
+                "stock_availability": {
+                    "description": "Number of units in the fleet; free units per date window come from rental histories",
+                    "type": "integer"
+                },
+                "transmission": {
+                    "type": "string"
+                },
+                "year": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.CarRateRequest": {
+            "type": "object",
+            "required": [
+                "daily_rate"
+            ],
+            "properties": {
+                "daily_rate": {
+                    "type": "number"
+                },
+                "monthly_rate": {
+                    "description": "Optional, 0 means 30 x daily",
+                    "type": "number",
+                    "minimum": 0
+                },
+                "weekly_rate": {
+                    "description": "Optional, 0 means 7 x daily",
+                    "type": "number",
+                    "minimum": 0
+                }
+            }
+        },
+        "handlers.FleetUnitRequest": {
+            "type": "object",
+            "required": [
+                "car_id",
+                "colour",
+                "license_plate",
+                "vin"
+            ],
+            "properties": {
+                "car_id": {
+                    "type": "integer"
+                },
+                "colour": {
+                    "type": "string"
+                },
+                "license_plate": {
+                    "type": "string",
+                    "maxLength": 15
+                },
+                "odometer": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "available",
+                        "maintenance",
+                        "retired"
+                    ]
+                },
+                "vin": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.FleetUnitUpdateRequest": {
+            "type": "object",
+            "properties": {
+                "colour": {
+                    "type": "string"
+                },
+                "license_plate": {
+                    "type": "string",
+                    "maxLength": 15
+                },
+                "odometer": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "available",
+                        "maintenance",
+                        "retired"
+                    ]
+                }
+            }
+        },
+        "handlers.ForgotPasswordRequest": {
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.HolidayRequest": {
+            "type": "object",
+            "required": [
+                "date",
+                "name"
+            ],
+            "properties": {
+                "date": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.InvitationRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "role"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "locale": {
+                    "description": "Language of the invitation email, default id",
+                    "type": "string",
+                    "enum": [
+                        "id",
+                        "en"
+                    ]
+                },
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "owner",
+                        "staff",
+                        "driver",
+                        "admin"
+                    ]
+                }
+            }
+        },
+        "handlers.LoginOTPRequest": {
+            "type": "object",
+            "required": [
+                "challenge_token",
+                "code"
+            ],
+            "properties": {
+                "challenge_token": {
+                    "type": "string"
+                },
+                "code": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.LoginRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.MessageTemplateRequest": {
+            "type": "object",
+            "required": [
+                "body",
+                "subject"
+            ],
+            "properties": {
+                "body": {
+                    "type": "string"
+                },
+                "subject": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.ModifyBookingRequest": {
+            "type": "object",
+            "properties": {
+                "airport_transfer": {
+                    "type": "boolean"
+                },
+                "concierge_services": {
+                    "type": "boolean"
+                },
+                "driver_id": {
+                    "type": "integer"
+                },
+                "dropoff_location": {
+                    "type": "string"
+                },
+                "package_id": {
+                    "type": "integer"
+                },
+                "pickup_location": {
+                    "type": "string"
+                },
+                "rental_date": {
+                    "type": "string"
+                },
+                "return_date": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.NotificationPreferenceRequest": {
             "type": "object",
-            "required": [
-                "description",
-                "location",
-                "rental_id"
-            ],
             "properties": {
-                "description": {
-                    "type": "string"
-                },
-                "location": {
-                    "type": "string"
+                "preferred_channel": {
+                    "description": "The log channel is a development-only fallback and cannot be chosen",
+                    "type": "string",
+                    "enum": [
+                        "whatsapp",
+                        "email",
+                        "sms"
+                    ]
                 },
+                "preferred_language": {
+                    "type": "string",
+                    "enum": [
+                        "id",
+                        "en"
+                    ]
+                }
+            }
+        },
+        "handlers.PaymentRequest": {
+            "type": "object",
+            "properties": {
                 "rental_id": {
                     "type": "integer"
                 }
             }
         },
-        "handlers.LoginRequest": {
+        "handlers.PricingResponse": {
+            "type": "object",
+            "properties": {
+                "addons": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AddonPrice"
+                    }
+                },
+                "car_rates": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CarRate"
+                    }
+                },
+                "holidays": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Holiday"
+                    }
+                },
+                "settings": {
+                    "$ref": "#/definitions/models.PricingSetting"
+                }
+            }
+        },
+        "handlers.PricingSettingsRequest": {
             "type": "object",
             "required": [
-                "email",
-                "password"
+                "rounding"
             ],
             "properties": {
-                "email": {
-                    "type": "string"
+                "holiday_surcharge_percent": {
+                    "type": "number",
+                    "maximum": 500,
+                    "minimum": 0
                 },
-                "password": {
-                    "type": "string"
+                "rounding": {
+                    "type": "string",
+                    "enum": [
+                        "full_day",
+                        "hourly"
+                    ]
+                },
+                "weekend_surcharge_percent": {
+                    "type": "number",
+                    "maximum": 500,
+                    "minimum": 0
                 }
             }
         },
-        "handlers.PaymentRequest": {
+        "handlers.RefreshTokenRequest": {
             "type": "object",
+            "required": [
+                "refresh_token"
+            ],
             "properties": {
-                "rental_id": {
-                    "type": "integer"
+                "refresh_token": {
+                    "type": "string"
                 }
             }
         },
@@ -809,26 +3896,95 @@ const docTemplate = `{
                     "type": "string"
                 },
                 "password": {
-                    "type": "string",
-                    "minLength": 3
+                    "description": "Checked against the password policy",
+                    "type": "string"
                 },
                 "phone_number": {
                     "type": "string"
                 },
-                "role": {
-                    "description": "Optional",
+                "preferred_channel": {
+                    "description": "The log channel is a development-only fallback and cannot be chosen",
+                    "type": "string",
+                    "enum": [
+                        "whatsapp",
+                        "email",
+                        "sms"
+                    ]
+                },
+                "preferred_language": {
+                    "type": "string",
+                    "enum": [
+                        "id",
+                        "en"
+                    ]
+                }
+            }
+        },
+        "handlers.ResetPasswordRequest": {
+            "type": "object",
+            "required": [
+                "code",
+                "email",
+                "password"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "password": {
+                    "description": "Checked against the password policy",
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.ReturnRequest": {
+            "type": "object",
+            "required": [
+                "fuel_level",
+                "odometer"
+            ],
+            "properties": {
+                "fuel_level": {
+                    "type": "integer",
+                    "maximum": 100,
+                    "minimum": 0
+                },
+                "odometer": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "returned_at": {
+                    "description": "Optional, defaults to now; between the rental date and now",
                     "type": "string"
                 }
             }
         },
         "handlers.TopUpRequest": {
             "type": "object",
+            "required": [
+                "deposit_amount"
+            ],
             "properties": {
                 "deposit_amount": {
                     "type": "number"
                 }
             }
         },
+        "handlers.TwoFactorRequest": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "type": "boolean"
+                },
+                "password": {
+                    "description": "Required to turn it off",
+                    "type": "string"
+                }
+            }
+        },
         "handlers.UserResponse": {
             "type": "object",
             "properties": {
@@ -838,12 +3994,19 @@ const docTemplate = `{
                 "email": {
                     "type": "string"
                 },
+                "expires_in": {
+                    "description": "Seconds until the access token expires",
+                    "type": "integer"
+                },
                 "id": {
                     "type": "integer"
                 },
                 "phone_number": {
                     "type": "string"
                 },
+                "refresh_token": {
+                    "type": "string"
+                },
                 "role": {
                     "type": "string"
                 },
@@ -852,41 +4015,128 @@ const docTemplate = `{
                 }
             }
         },
-        "models.Car": {
+        "handlers.UserRoleRequest": {
             "type": "object",
+            "required": [
+                "role"
+            ],
             "properties": {
-                "car_id": {
-                    "type": "integer"
-                },
-                "category": {
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "user",
+                        "owner",
+                        "staff",
+                        "driver",
+                        "admin"
+                    ]
+                }
+            }
+        },
+        "handlers.VerifyPhoneRequest": {
+            "type": "object",
+            "required": [
+                "code"
+            ],
+            "properties": {
+                "code": {
                     "type": "string"
+                }
+            }
+        },
+        "handlers.XenditInvoiceCallbackRequest": {
+            "type": "object",
+            "required": [
+                "external_id",
+                "id",
+                "status"
+            ],
+            "properties": {
+                "amount": {
+                    "type": "number"
                 },
-                "class": {
+                "external_id": {
                     "type": "string"
                 },
-                "fuel_type": {
+                "id": {
                     "type": "string"
                 },
-                "make": {
+                "paid_amount": {
+                    "type": "number"
+                },
+                "paid_at": {
                     "type": "string"
                 },
-                "model": {
+                "status": {
                     "type": "string"
+                }
+            }
+        },
+        "handlers.XenditRefundCallbackRequest": {
+            "type": "object",
+            "required": [
+                "event"
+            ],
+            "properties": {
+                "data": {
+                    "type": "object",
+                    "required": [
+                        "reference_id",
+                        "status"
+                    ],
+                    "properties": {
+                        "amount": {
+                            "type": "number"
+                        },
+                        "failure_code": {
+                            "type": "string"
+                        },
+                        "id": {
+                            "type": "string"
+                        },
+                        "reference_id": {
+                            "type": "string"
+                        },
+                        "status": {
+                            "type": "string"
+                        }
+                    }
                 },
-                "name": {
+                "event": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.AddonPrice": {
+            "type": "object",
+            "properties": {
+                "code": {
                     "type": "string"
                 },
-                "rental_costs": {
-                    "type": "number"
+                "per_day": {
+                    "type": "boolean"
                 },
-                "stock_availability": {
+                "price": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.CarRate": {
+            "type": "object",
+            "properties": {
+                "car_id": {
                     "type": "integer"
                 },
-                "transmission": {
-                    "type": "string"
+                "daily_rate": {
+                    "type": "number"
                 },
-                "year": {
-                    "type": "integer"
+                "monthly_rate": {
+                    "description": "0 means 30 x daily",
+                    "type": "number"
+                },
+                "weekly_rate": {
+                    "description": "0 means 7 x daily",
+                    "type": "number"
                 }
             }
         },
@@ -929,6 +4179,130 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "models.FleetUnit": {
+            "type": "object",
+            "properties": {
+                "car_id": {
+                    "type": "integer"
+                },
+                "colour": {
+                    "type": "string"
+                },
+                "fleet_unit_id": {
+                    "type": "integer"
+                },
+                "license_plate": {
+                    "type": "string"
+                },
+                "odometer": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "vin": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Holiday": {
+            "type": "object",
+            "properties": {
+                "holiday_date": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.PricingSetting": {
+            "type": "object",
+            "properties": {
+                "holiday_surcharge_percent": {
+                    "type": "number"
+                },
+                "rounding": {
+                    "type": "string"
+                },
+                "weekend_surcharge_percent": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.Refund": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "number"
+                },
+                "completed_at": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "failure_reason": {
+                    "type": "string"
+                },
+                "gateway_refund_id": {
+                    "type": "string"
+                },
+                "invoice_id": {
+                    "description": "Paid invoice refunded through the gateway",
+                    "type": "integer"
+                },
+                "method": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "reference_id": {
+                    "description": "Sent to the gateway so retries are not paid out twice",
+                    "type": "string"
+                },
+                "refund_id": {
+                    "type": "integer"
+                },
+                "rental_id": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                },
+                "wallet_transaction_id": {
+                    "description": "Ledger entry of a wallet refund",
+                    "type": "integer"
+                }
+            }
+        },
+        "services.TemplateSource": {
+            "type": "object",
+            "properties": {
+                "body": {
+                    "type": "string"
+                },
+                "event": {
+                    "type": "string"
+                },
+                "locale": {
+                    "type": "string"
+                },
+                "overridden": {
+                    "type": "boolean"
+                },
+                "subject": {
+                    "type": "string"
+                }
+            }
         }
     },
     "securityDefinitions": {
@@ -0,0 +1,602 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"jakarta-luxury-rent-car/database"
+	"jakarta-luxury-rent-car/models"
+	"jakarta-luxury-rent-car/services"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultUserPageSize = 20
+	maxUserPageSize     = 100
+)
+
+// AdminUserResponse struct to show an account to admins without its password hash
+type AdminUserResponse struct {
+	ID            uint       `json:"id"`
+	Email         string     `json:"email"`
+	PhoneNumber   string     `json:"phone_number"`
+	Address       string     `json:"address"`
+	Role          string     `json:"role"`
+	DepositAmount float64    `json:"deposit_amount"`
+	SuspendedAt   *time.Time `json:"suspended_at"`
+}
+
+// UserRoleRequest struct to capture the new role of a user
+type UserRoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=user owner staff driver admin"`
+}
+
+// InvitationRequest struct to capture who to invite and with which role
+type InvitationRequest struct {
+	Email  string `json:"email" validate:"required,email"`
+	Role   string `json:"role" validate:"required,oneof=owner staff driver admin"`
+	Locale string `json:"locale" validate:"omitempty,oneof=id en"` // Language of the invitation email, default id
+}
+
+// AcceptInvitationRequest struct to capture the account details of an invited user
+type AcceptInvitationRequest struct {
+	Token       string `json:"token" validate:"required"`
+	Password    string `json:"password" validate:"required"` // Checked against the password policy
+	PhoneNumber string `json:"phone_number" validate:"required"`
+	Address     string `json:"address" validate:"required"`
+}
+
+func newAdminUserResponse(user models.User) AdminUserResponse {
+	return AdminUserResponse{
+		ID:            user.UserID,
+		Email:         user.Email,
+		PhoneNumber:   user.PhoneNumber,
+		Address:       user.Address,
+		Role:          user.Role,
+		DepositAmount: user.DepositAmount,
+		SuspendedAt:   user.SuspendedAt,
+	}
+}
+
+// invitationLink is the link sent to an invited user, INVITATION_URL points at the page that accepts it
+func invitationLink(token string) string {
+	base := os.Getenv("INVITATION_URL")
+	if base == "" {
+		base = "http://localhost:8080/invitations/accept"
+	}
+	return base + "?token=" + token
+}
+
+// loadManagedUser loads the user in the :id path parameter after checking the admin is not acting on themselves
+func loadManagedUser(c echo.Context) (models.User, error) {
+	var target models.User
+
+	targetID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return target, err
+	}
+
+	// Extract user ID from JWT token
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*jwt.MapClaims)
+	adminID := uint((*claims)["user_id"].(float64))
+
+	if err := services.CheckUserManagement(adminID, uint(targetID)); err != nil {
+		return target, err
+	}
+
+	err = database.DB.First(&target, targetID).Error
+	return target, err
+}
+
+// managedUserError responds to an error of loadManagedUser
+func managedUserError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, services.ErrSelfManagement):
+		return c.JSON(http.StatusForbidden, echo.Map{
+			"message": "Permission denied",
+			"error":   err.Error(),
+		})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return c.JSON(http.StatusNotFound, echo.Map{
+			"message": "User not found",
+			"error":   err.Error(),
+		})
+	case errors.Is(err, strconv.ErrSyntax), errors.Is(err, strconv.ErrRange):
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Invalid user ID",
+			"error":   err.Error(),
+		})
+	default:
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": "Failed to load user",
+			"error":   err.Error(),
+		})
+	}
+}
+
+// @Summary List users
+// @Description List accounts, optionally filtered by role, email and suspension
+// @Tags Role Admin
+// @Accept json
+// @Produce json
+// @Param role query string false "Only users with this role" Enums(user, owner, staff, driver, admin)
+// @Param email query string false "Only users whose email contains this text"
+// @Param suspended query bool false "Only suspended (true) or active (false) users"
+// @Param page query int false "Page number, starting at 1"
+// @Param limit query int false "Users per page (default 20, max 100)"
+// @Success 200 {object} map[string]interface{} "Page of users"
+// @Failure 400 {object} map[string]interface{} "Invalid filter, page or limit"
+// @Failure 403 {object} map[string]interface{} "Permission denied"
+// @Failure 500 {object} map[string]interface{} "Failed to fetch users"
+// @Router /admin/users [get]
+// @Security BearerAuth
+func GetUsers(c echo.Context) error {
+	page, limit, err := parsePagination(c, defaultUserPageSize, maxUserPageSize)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Invalid page or limit",
+			"error":   err.Error(),
+		})
+	}
+
+	query := database.DB.Model(&models.User{})
+	if role := c.QueryParam("role"); role != "" {
+		if !services.IsRole(role) {
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"message": "Invalid role",
+			})
+		}
+		query = query.Where("role = ?", role)
+	}
+	if email := c.QueryParam("email"); email != "" {
+		escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(email)
+		query = query.Where("email ILIKE ?", "%"+escaped+"%")
+	}
+	if value := c.QueryParam("suspended"); value != "" {
+		suspended, err := strconv.ParseBool(value)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"message": "Invalid suspended filter",
+				"error":   err.Error(),
+			})
+		}
+		if suspended {
+			query = query.Where("suspended_at IS NOT NULL")
+		} else {
+			query = query.Where("suspended_at IS NULL")
+		}
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": "Failed to fetch users",
+			"error":   err.Error(),
+		})
+	}
+
+	var users []models.User
+	if err := query.Order("user_id").Offset((page - 1) * limit).Limit(limit).Find(&users).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": "Failed to fetch users",
+			"error":   err.Error(),
+		})
+	}
+
+	data := make([]AdminUserResponse, 0, len(users))
+	for _, user := range users {
+		data = append(data, newAdminUserResponse(user))
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"page":  page,
+		"limit": limit,
+		"total": total,
+		"data":  data,
+	})
+}
+
+// @Summary Change the role of a user
+// @Description Change the role of another user. The user has to log in again for the new role to apply.
+// @Tags Role Admin
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param roleReq body UserRoleRequest true "New role"
+// @Success 200 {object} AdminUserResponse "Updated user"
+// @Failure 400 {object} map[string]interface{} "Invalid request format or validation error"
+// @Failure 403 {object} map[string]interface{} "Permission denied"
+// @Failure 404 {object} map[string]interface{} "User not found"
+// @Failure 500 {object} map[string]interface{} "Failed to update role"
+// @Router /admin/users/{id}/role [put]
+// @Security BearerAuth
+func UpdateUserRole(c echo.Context) error {
+	var roleReq UserRoleRequest
+
+	// Bind the request body to UserRoleRequest struct
+	if err := c.Bind(&roleReq); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Invalid request format",
+			"error":   err.Error(),
+		})
+	}
+
+	// Validate the request
+	if err := c.Validate(&roleReq); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Validation error",
+			"error":   err.Error(),
+		})
+	}
+
+	target, err := loadManagedUser(c)
+	if err != nil {
+		return managedUserError(c, err)
+	}
+
+	from := target.Role
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&target).Update("role", roleReq.Role).Error; err != nil {
+			return err
+		}
+
+		return services.RecordAudit(tx, auditActor(c), services.AuditEntry{
+			Action:     services.AuditUserRoleChange,
+			EntityType: services.AuditEntityUser,
+			EntityID:   auditID(target.UserID),
+			Before:     echo.Map{"role": from},
+			After:      echo.Map{"role": target.Role},
+		})
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": "Failed to update role",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, newAdminUserResponse(target))
+}
+
+// @Summary Suspend a user
+// @Description Suspend another user. Suspended users are logged out, cannot log in and their tokens are rejected.
+// @Tags Role Admin
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} AdminUserResponse "Suspended user"
+// @Failure 400 {object} map[string]interface{} "Invalid user ID"
+// @Failure 403 {object} map[string]interface{} "Permission denied"
+// @Failure 404 {object} map[string]interface{} "User not found"
+// @Failure 500 {object} map[string]interface{} "Failed to suspend user"
+// @Router /admin/users/{id}/suspend [post]
+// @Security BearerAuth
+func SuspendUser(c echo.Context) error {
+	target, err := loadManagedUser(c)
+	if err != nil {
+		return managedUserError(c, err)
+	}
+
+	if target.SuspendedAt == nil {
+		// Suspending logs the user out everywhere
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			now := time.Now()
+			if err := tx.Model(&target).Update("suspended_at", now).Error; err != nil {
+				return err
+			}
+			if err := services.RevokeUserSessions(tx, target.UserID, now); err != nil {
+				return err
+			}
+
+			return services.RecordAudit(tx, auditActor(c), services.AuditEntry{
+				Action:     services.AuditUserSuspend,
+				EntityType: services.AuditEntityUser,
+				EntityID:   auditID(target.UserID),
+				Before:     echo.Map{"suspended_at": nil},
+				After:      echo.Map{"suspended_at": now},
+			})
+		})
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, echo.Map{
+				"message": "Failed to suspend user",
+				"error":   err.Error(),
+			})
+		}
+	}
+
+	return c.JSON(http.StatusOK, newAdminUserResponse(target))
+}
+
+// @Summary Re-enable a user
+// @Description Lift the suspension and any failed login lockout of another user
+// @Tags Role Admin
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} AdminUserResponse "Re-enabled user"
+// @Failure 400 {object} map[string]interface{} "Invalid user ID"
+// @Failure 403 {object} map[string]interface{} "Permission denied"
+// @Failure 404 {object} map[string]interface{} "User not found"
+// @Failure 500 {object} map[string]interface{} "Failed to re-enable user"
+// @Router /admin/users/{id}/reactivate [post]
+// @Security BearerAuth
+func ReactivateUser(c echo.Context) error {
+	target, err := loadManagedUser(c)
+	if err != nil {
+		return managedUserError(c, err)
+	}
+
+	before := echo.Map{
+		"suspended_at":          target.SuspendedAt,
+		"failed_login_attempts": target.FailedLoginAttempts,
+		"locked_until":          target.LockedUntil,
+	}
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		after := map[string]interface{}{
+			"suspended_at":          nil,
+			"failed_login_attempts": 0,
+			"locked_until":          nil,
+		}
+		if err := tx.Model(&target).Updates(after).Error; err != nil {
+			return err
+		}
+
+		return services.RecordAudit(tx, auditActor(c), services.AuditEntry{
+			Action:     services.AuditUserReactivate,
+			EntityType: services.AuditEntityUser,
+			EntityID:   auditID(target.UserID),
+			Before:     before,
+			After:      after,
+		})
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": "Failed to re-enable user",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, newAdminUserResponse(target))
+}
+
+// @Summary Invite a staff member
+// @Description Email a one-time link to create a staff, driver, owner or admin account. The link is also returned so it can be shared another way.
+// @Tags Role Admin
+// @Accept json
+// @Produce json
+// @Param invitationReq body InvitationRequest true "Email and role of the invited user"
+// @Success 201 {object} map[string]interface{} "Invitation, its link and whether the email was sent"
+// @Failure 400 {object} map[string]interface{} "Invalid request format or validation error"
+// @Failure 403 {object} map[string]interface{} "Permission denied"
+// @Failure 409 {object} map[string]interface{} "User already exists with this email"
+// @Failure 500 {object} map[string]interface{} "Failed to create invitation"
+// @Router /admin/invitations [post]
+// @Security BearerAuth
+func InviteUser(c echo.Context) error {
+	var invitationReq InvitationRequest
+
+	// Bind the request body to InvitationRequest struct
+	if err := c.Bind(&invitationReq); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Invalid request format",
+			"error":   err.Error(),
+		})
+	}
+
+	// Validate the request
+	if err := c.Validate(&invitationReq); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Validation error",
+			"error":   err.Error(),
+		})
+	}
+	if err := services.CheckInvitationRole(invitationReq.Role); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Validation error",
+			"error":   err.Error(),
+		})
+	}
+
+	var existingUser models.User
+	if err := database.DB.Where("email = ?", invitationReq.Email).First(&existingUser).Error; err == nil {
+		return c.JSON(http.StatusConflict, echo.Map{
+			"message": "User already exists with this email",
+		})
+	}
+
+	// Extract user ID from JWT token
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*jwt.MapClaims)
+	adminID := uint((*claims)["user_id"].(float64))
+
+	token, tokenHash, err := services.NewSecretToken()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": "Failed to create invitation",
+			"error":   err.Error(),
+		})
+	}
+
+	invitation := models.UserInvitation{
+		Email:     invitationReq.Email,
+		Role:      invitationReq.Role,
+		TokenHash: tokenHash,
+		InvitedBy: adminID,
+		ExpiresAt: time.Now().Add(services.InvitationTTL),
+	}
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&invitation).Error; err != nil {
+			return err
+		}
+
+		return services.RecordAudit(tx, auditActor(c), services.AuditEntry{
+			Action:     services.AuditUserInvite,
+			EntityType: services.AuditEntityInvitation,
+			EntityID:   auditID(invitation.UserInvitationID),
+			After:      echo.Map{"email": invitation.Email, "role": invitation.Role, "expires_at": invitation.ExpiresAt},
+		})
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": "Failed to create invitation",
+			"error":   err.Error(),
+		})
+	}
+
+	link := invitationLink(token)
+	notified := false
+	msg, err := services.RenderMessage(database.DB, services.EventStaffInvitation, invitationReq.Locale, echo.Map{
+		"Invitation": invitation,
+		"InviteLink": link,
+	})
+	if err == nil {
+		_, err = notificationService.Send(services.Recipient{Email: invitation.Email}, services.ChannelEmail, msg)
+	}
+	if err != nil {
+		log.Printf("Failed to send invitation %d: %v", invitation.UserInvitationID, err)
+	} else {
+		notified = true
+	}
+
+	return c.JSON(http.StatusCreated, echo.Map{
+		"invitation":  invitation,
+		"invite_link": link,
+		"notified":    notified,
+	})
+}
+
+// @Summary Accept an invitation
+// @Description Create the account an invitation was sent for. Each invitation link works once.
+// @Tags Register and Login
+// @Accept json
+// @Produce json
+// @Param acceptReq body AcceptInvitationRequest true "Invitation token and account details"
+// @Success 201 {object} UserResponse
+// @Failure 400 {object} map[string]interface{} "Invalid request format, validation error, or password does not meet the password policy"
+// @Failure 404 {object} map[string]interface{} "Invitation not found"
+// @Failure 409 {object} map[string]interface{} "Invitation already used or user already exists"
+// @Failure 410 {object} map[string]interface{} "Invitation has expired"
+// @Failure 500 {object} map[string]interface{} "Failed to accept invitation"
+// @Router /invitations/accept [post]
+func AcceptInvitation(c echo.Context) error {
+	var acceptReq AcceptInvitationRequest
+
+	// Bind the request body to AcceptInvitationRequest struct
+	if err := c.Bind(&acceptReq); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Invalid request format",
+			"error":   err.Error(),
+		})
+	}
+
+	// Validate the request
+	if err := c.Validate(&acceptReq); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Validation error",
+			"error":   err.Error(),
+		})
+	}
+
+	var user models.User
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock the invitation so the link cannot be used twice concurrently
+		var invitation models.UserInvitation
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ?", services.HashSecretToken(acceptReq.Token)).
+			First(&invitation).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return services.ErrInvitationNotFound
+			}
+			return err
+		}
+
+		if err := services.CheckInvitation(invitation, time.Now()); err != nil {
+			return err
+		}
+
+		if err := passwordPolicy.Validate(acceptReq.Password, invitation.Email); err != nil {
+			return err
+		}
+
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(acceptReq.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return err
+		}
+
+		var existingUser models.User
+		if err := tx.Where("email = ?", invitation.Email).First(&existingUser).Error; err == nil {
+			return services.ErrUserAlreadyExists
+		}
+
+		user = models.User{
+			Email:       invitation.Email,
+			Password:    string(hashedPassword),
+			PhoneNumber: acceptReq.PhoneNumber,
+			Address:     acceptReq.Address,
+			Role:        invitation.Role,
+		}
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&invitation).Updates(map[string]interface{}{
+			"accepted_at": time.Now(),
+			"user_id":     user.UserID,
+		}).Error; err != nil {
+			return err
+		}
+
+		// The new account is the actor, there is no token yet
+		return services.RecordAudit(tx, services.AuditActor{UserID: &user.UserID, Role: user.Role, IPAddress: c.RealIP()}, services.AuditEntry{
+			Action:     services.AuditInvitationAccept,
+			EntityType: services.AuditEntityInvitation,
+			EntityID:   auditID(invitation.UserInvitationID),
+			After:      echo.Map{"user_id": user.UserID, "email": user.Email, "role": user.Role},
+		})
+	})
+
+	switch {
+	case errors.Is(err, services.ErrPasswordPolicy):
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Validation error",
+			"error":   err.Error(),
+		})
+	case errors.Is(err, services.ErrInvitationNotFound):
+		return c.JSON(http.StatusNotFound, echo.Map{
+			"message": "Invitation not found",
+		})
+	case errors.Is(err, services.ErrInvitationUsed), errors.Is(err, services.ErrUserAlreadyExists):
+		return c.JSON(http.StatusConflict, echo.Map{
+			"message": "Failed to accept invitation",
+			"error":   err.Error(),
+		})
+	case errors.Is(err, services.ErrInvitationExpired):
+		return c.JSON(http.StatusGone, echo.Map{
+			"message": "Invitation has expired",
+		})
+	case err != nil:
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": "Failed to accept invitation",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusCreated, UserResponse{
+		ID:          user.UserID,
+		Email:       user.Email,
+		Address:     user.Address,
+		PhoneNumber: user.PhoneNumber,
+		Role:        user.Role,
+	})
+}
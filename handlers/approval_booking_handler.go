@@ -1,33 +1,38 @@
 package handlers
 
 import (
-	"fmt"
+	"errors"
 	"net/http"
+	"time"
 
 	"jakarta-luxury-rent-car/database"
 	"jakarta-luxury-rent-car/models"
+	"jakarta-luxury-rent-car/services"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
 )
 
 // ApprovalRequest struct to capture approval or rejection
 type ApprovalRequest struct {
 	RentalID uint   `json:"rental_id" validate:"required"`
 	Action   string `json:"action" validate:"required,oneof=approve reject"`
+	Reason   string `json:"reason"` // Optional, stored with the status change
 }
 
 // @Summary Approve or reject a car booking
-// @Description Approve or reject a car booking
+// @Description Approve or reject a car booking. Rejecting a paid booking refunds it in full.
 // @Tags Role Owner
 // @Accept json
 // @Produce json
 // @Param approvalReq body ApprovalRequest true "Approval request body containing rental ID and action (approve/reject)"
 // @Success 200 {object} map[string]interface{} "Success message indicating the booking has been approved or rejected"
-// @Failure 400 {object} map[string]interface{} "Invalid request format, validation error, car out of stock, or invalid action"
-// @Failure 403 {object} map[string]interface{} "Permission denied. Only owners can approve or reject bookings."
+// @Failure 400 {object} map[string]interface{} "Invalid request format, validation error, car not available for the rental dates, or invalid action"
+// @Failure 403 {object} map[string]interface{} "Permission denied"
 // @Failure 404 {object} map[string]interface{} "User, car, or rental history not found"
-// @Failure 500 {object} map[string]interface{} "Failed to update car stock, rental history, or send notifications"
+// @Failure 409 {object} map[string]interface{} "Rental status does not allow this action, e.g. approving an unpaid booking"
+// @Failure 500 {object} map[string]interface{} "Failed to update rental history or send notifications"
 // @Router /owner/approve-booking [post]
 // @Security BearerAuth
 func ApprovalBooking(c echo.Context) error {
@@ -63,13 +68,6 @@ func ApprovalBooking(c echo.Context) error {
 		})
 	}
 
-	// Check if the user has the role of "owner"
-	if userModel.Role != "owner" {
-		return c.JSON(http.StatusForbidden, echo.Map{
-			"message": "Permission denied. Only owners can approve or reject bookings.",
-		})
-	}
-
 	// Fetch the rental history record
 	var rentalHistory models.RentalHistory
 	if err := database.DB.First(&rentalHistory, approvalReq.RentalID).Error; err != nil {
@@ -97,80 +95,122 @@ func ApprovalBooking(c echo.Context) error {
 
 	// Approve or Reject booking based on action
 	if approvalReq.Action == "approve" {
-		// Approve the booking: set status to "Rent"
-		rentalHistory.Status = "Rent"
-
-		// Update the car stock availability
-		if carModel.StockAvailability <= 0 {
-			return c.JSON(http.StatusBadRequest, echo.Map{
-				"message": "Car is out of stock",
+		// Approve the booking: move it to "Rent", make sure a unit is still free for the rental window
+		// (ignoring the rental itself), then hand a specific fleet unit to the rental
+		var fleetUnit *models.FleetUnit
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			from := rentalHistory.Status
+			if err := services.TransitionRental(tx, &rentalHistory, services.RentalStatusRent, services.StaffActor(userModel), approvalReq.Reason); err != nil {
+				return err
+			}
+
+			returnDate := rentalHistory.RentalDate.Add(24 * time.Hour)
+			if rentalHistory.ReturnDate != nil {
+				returnDate = *rentalHistory.ReturnDate
+			}
+			if _, err := services.ReserveCar(tx, rentalHistory.CarID, rentalHistory.RentalDate, returnDate, rentalHistory.RentalID); err != nil {
+				return err
+			}
+
+			var err error
+			if fleetUnit, err = services.AssignFleetUnit(tx, &rentalHistory); err != nil {
+				return err
+			}
+
+			return services.RecordAudit(tx, auditActor(c), services.AuditEntry{
+				Action:     services.AuditBookingApprove,
+				EntityType: services.AuditEntityRental,
+				EntityID:   auditID(rentalHistory.RentalID),
+				Before:     echo.Map{"status": from},
+				After:      echo.Map{"status": rentalHistory.Status, "fleet_unit_id": rentalHistory.FleetUnitID, "reason": approvalReq.Reason},
 			})
-		}
-
-		carModel.StockAvailability -= 1
-
-		// Update the car and rental history records in the database
-		if err := database.DB.Save(&carModel).Error; err != nil {
-			return c.JSON(http.StatusInternalServerError, echo.Map{
-				"message": "Failed to update car stock",
+		})
+		if errors.Is(err, services.ErrCarUnavailable) || errors.Is(err, services.ErrNoFleetUnitAvailable) {
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"message": "Car is not available for the rental dates",
 				"error":   err.Error(),
 			})
 		}
-
-		if err := database.DB.Save(&rentalHistory).Error; err != nil {
-			return c.JSON(http.StatusInternalServerError, echo.Map{
+		if err != nil {
+			return c.JSON(rentalTransitionStatusCode(err), echo.Map{
 				"message": "Failed to approve booking",
 				"error":   err.Error(),
 			})
 		}
 
 		// Send To User
-		toPhoneNumberUser := fmt.Sprintf("whatsapp:+%s", userModelWithRoleUser.PhoneNumber)
-
-		messageBodyUser := fmt.Sprintf(
-			"Dear %s - %s,\n\n"+
-				"Your booking for the car '%s' is confirmed and ready for use. Enjoy your ride!\n\n"+
-				"We hope you have a great experience! Please don't forget to leave us a 5-star review!\n\n"+
-				"Best regards,\nJakarta Luxury Rent Car",
-			userModelWithRoleUser.Email,
-			userModelWithRoleUser.Role,
-			carModel.Name,
-		)
-
-		sendWhatsAppNotification(toPhoneNumberUser, messageBodyUser)
+		notifyUser(userModelWithRoleUser, services.EventBookingApproved, echo.Map{"Car": carModel})
 
 		// Send To Owner
-		toPhoneNumberOwner := fmt.Sprintf("whatsapp:+%s", userModel.PhoneNumber)
-
-		messageBodyOwner := fmt.Sprintf(
-			"Dear %s - %s,\n\n"+
-				"The car '%s' has been successfully booked by '%s' and Please ensure it is in perfect condition for the customer\n",
-			userModel.Email,
-			userModel.Role,
-			carModel.Name,
-			userModelWithRoleUser.Email,
-		)
-
-		sendWhatsAppNotification(toPhoneNumberOwner, messageBodyOwner)
+		notifyUser(userModel, services.EventBookingApprovedOwner, echo.Map{
+			"Customer":  userModelWithRoleUser,
+			"Car":       carModel,
+			"FleetUnit": fleetUnit,
+		})
 
 		return c.JSON(http.StatusOK, echo.Map{
-			"message": "Booking approved and car stock updated",
+			"message":    "Booking approved",
+			"fleet_unit": fleetUnit,
 		})
 
 	} else if approvalReq.Action == "reject" {
-		// Reject the booking: set status to "Cancel"
-		rentalHistory.Status = "Cancel"
-
-		// Update the rental history record in the database
-		if err := database.DB.Save(&rentalHistory).Error; err != nil {
-			return c.JSON(http.StatusInternalServerError, echo.Map{
+		// Reject the booking: move it to "Cancel", free any unit that was handed to it
+		// and give a paid booking its full amount back
+		var refund models.Refund
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			from := rentalHistory.Status
+			wasPaid := from == services.RentalStatusPaid
+
+			if err := services.TransitionRental(tx, &rentalHistory, services.RentalStatusCancel, services.StaffActor(userModel), approvalReq.Reason); err != nil {
+				return err
+			}
+			if _, err := services.ReleaseFleetUnit(tx, rentalHistory, nil); err != nil {
+				return err
+			}
+
+			if wasPaid {
+				var err error
+				if refund, err = services.RequestRefund(tx, rentalHistory, rentalHistory.TotalCost, "Booking rejected"); err != nil {
+					return err
+				}
+			}
+
+			after := echo.Map{"status": rentalHistory.Status, "reason": approvalReq.Reason}
+			if refund.RefundID != 0 {
+				after["refund_id"] = refund.RefundID
+				after["refund_amount"] = refund.Amount
+			}
+			return services.RecordAudit(tx, auditActor(c), services.AuditEntry{
+				Action:     services.AuditBookingReject,
+				EntityType: services.AuditEntityRental,
+				EntityID:   auditID(rentalHistory.RentalID),
+				Before:     echo.Map{"status": from},
+				After:      after,
+			})
+		})
+		if err != nil {
+			return c.JSON(rentalTransitionStatusCode(err), echo.Map{
 				"message": "Failed to reject booking",
 				"error":   err.Error(),
 			})
 		}
 
+		expirePendingInvoices(rentalHistory.RentalID)
+
+		// Send To User
+		notifyUser(userModelWithRoleUser, services.EventBookingRejected, echo.Map{
+			"Car":    carModel,
+			"Rental": rentalHistory,
+			"Refund": refund,
+		})
+
+		if refund.RefundID != 0 {
+			refund = processRefund(refund)
+		}
+
 		return c.JSON(http.StatusOK, echo.Map{
 			"message": "Booking rejected",
+			"refund":  refund,
 		})
 	}
 
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"jakarta-luxury-rent-car/database"
+	"jakarta-luxury-rent-car/models"
+	"jakarta-luxury-rent-car/services"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultAuditPageSize = 50
+	maxAuditPageSize     = 200
+)
+
+// AuditLogResponse struct to show an audit log entry with its before and after values as JSON
+type AuditLogResponse struct {
+	ID          uint            `json:"id"`
+	ActorUserID *uint           `json:"actor_user_id"`
+	ActorRole   string          `json:"actor_role"`
+	Action      string          `json:"action"`
+	EntityType  string          `json:"entity_type"`
+	EntityID    string          `json:"entity_id"`
+	Before      json.RawMessage `json:"before"`
+	After       json.RawMessage `json:"after"`
+	IPAddress   string          `json:"ip_address"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+func newAuditLogResponse(log models.AuditLog) AuditLogResponse {
+	response := AuditLogResponse{
+		ID:          log.AuditLogID,
+		ActorUserID: log.ActorUserID,
+		ActorRole:   log.ActorRole,
+		Action:      log.Action,
+		EntityType:  log.EntityType,
+		EntityID:    log.EntityID,
+		IPAddress:   log.IPAddress,
+		CreatedAt:   log.CreatedAt,
+	}
+	if log.Before != nil {
+		response.Before = json.RawMessage(*log.Before)
+	}
+	if log.After != nil {
+		response.After = json.RawMessage(*log.After)
+	}
+	return response
+}
+
+// auditID formats a numeric primary key as the entity_id of an audit entry
+func auditID(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+// auditActor is the authenticated user of the request, or the system for unauthenticated callbacks
+func auditActor(c echo.Context) services.AuditActor {
+	actor := services.AuditActor{Role: services.ActorSystem, IPAddress: c.RealIP()}
+
+	// Extract user ID and role from JWT token
+	if user, ok := c.Get("user").(*jwt.Token); ok {
+		claims := user.Claims.(*jwt.MapClaims)
+		userID := uint((*claims)["user_id"].(float64))
+		actor.UserID = &userID
+		actor.Role, _ = (*claims)["role"].(string)
+	}
+	return actor
+}
+
+// @Summary List the audit log
+// @Description List privileged and money-moving actions, newest first, optionally filtered by actor, action, entity and time
+// @Tags Role Owner
+// @Accept json
+// @Produce json
+// @Param actor_id query int false "Only actions of this user"
+// @Param action query string false "Only this action, e.g. booking.approve or user.role_change"
+// @Param entity_type query string false "Only actions on this kind of entity, e.g. rental, user or deposit_top_up"
+// @Param entity_id query string false "Only actions on this entity, combine with entity_type"
+// @Param from query string false "Only actions at or after this date (YYYY-MM-DD) or time (RFC3339)"
+// @Param to query string false "Only actions before this date (YYYY-MM-DD, exclusive) or time (RFC3339)"
+// @Param page query int false "Page number, starting at 1"
+// @Param limit query int false "Entries per page (default 50, max 200)"
+// @Success 200 {object} map[string]interface{} "Page of audit log entries"
+// @Failure 400 {object} map[string]interface{} "Invalid filter, page or limit"
+// @Failure 403 {object} map[string]interface{} "Permission denied"
+// @Failure 500 {object} map[string]interface{} "Failed to fetch audit log"
+// @Router /owner/audit [get]
+// @Security BearerAuth
+func GetAuditLogs(c echo.Context) error {
+	page, limit, err := parsePagination(c, defaultAuditPageSize, maxAuditPageSize)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Invalid page or limit",
+			"error":   err.Error(),
+		})
+	}
+
+	query := database.DB.Model(&models.AuditLog{})
+	if value := c.QueryParam("actor_id"); value != "" {
+		actorID, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"message": "Invalid actor_id",
+				"error":   err.Error(),
+			})
+		}
+		query = query.Where("actor_user_id = ?", actorID)
+	}
+	if action := c.QueryParam("action"); action != "" {
+		query = query.Where("action = ?", action)
+	}
+	if entityType := c.QueryParam("entity_type"); entityType != "" {
+		query = query.Where("entity_type = ?", entityType)
+	}
+	if entityID := c.QueryParam("entity_id"); entityID != "" {
+		query = query.Where("entity_id = ?", entityID)
+	}
+	if value := c.QueryParam("from"); value != "" {
+		from, err := parseDateParam(value)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"message": "Invalid from, expected YYYY-MM-DD or RFC3339",
+				"error":   err.Error(),
+			})
+		}
+		query = query.Where("created_at >= ?", from)
+	}
+	if value := c.QueryParam("to"); value != "" {
+		to, err := parseDateParam(value)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"message": "Invalid to, expected YYYY-MM-DD or RFC3339",
+				"error":   err.Error(),
+			})
+		}
+		query = query.Where("created_at < ?", to)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": "Failed to fetch audit log",
+			"error":   err.Error(),
+		})
+	}
+
+	var logs []models.AuditLog
+	if err := query.Order("audit_log_id DESC").Offset((page - 1) * limit).Limit(limit).Find(&logs).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": "Failed to fetch audit log",
+			"error":   err.Error(),
+		})
+	}
+
+	data := make([]AuditLogResponse, 0, len(logs))
+	for _, log := range logs {
+		data = append(data, newAuditLogResponse(log))
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"page":  page,
+		"limit": limit,
+		"total": total,
+		"data":  data,
+	})
+}
@@ -1,21 +1,28 @@
 package handlers
 
 import (
-	"bytes"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"io/ioutil"
 	"net/http"
-	"os"
 	"time"
 
 	"jakarta-luxury-rent-car/database"
 	"jakarta-luxury-rent-car/models"
+	"jakarta-luxury-rent-car/services"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
 )
 
+// paymentHoldWindow is how long an unpaid booking holds its car, configured by main
+var paymentHoldWindow = services.DefaultPaymentHoldWindow
+
+// SetPaymentHoldWindow sets how long new unpaid bookings hold their car
+func SetPaymentHoldWindow(hold time.Duration) {
+	paymentHoldWindow = hold
+}
+
 // BookingRequest struct to capture user inputs
 type BookingRequest struct {
 	CarID             uint      `json:"car_id" validate:"required"`
@@ -31,15 +38,15 @@ type BookingRequest struct {
 }
 
 // @Summary Book a car
-// @Description Book a car
+// @Description Book a car. The confirmation message and the invoice are delivered in the background shortly after the booking is created.
 // @Tags Role User
 // @Accept json
 // @Produce json
 // @Param bookingReq body BookingRequest true "Booking request body containing car ID and other booking details"
 // @Success 200 {object} map[string]interface{} "Success message and details of the car booking"
-// @Failure 400 {object} map[string]string "Invalid request format, validation error, or insufficient stock"
+// @Failure 400 {object} map[string]string "Invalid request format, validation error, or car not available for the selected dates"
 // @Failure 404 {object} map[string]string "Car, driver, or package not found"
-// @Failure 500 {object} map[string]string "Failed to create rental history, send notifications, or process booking"
+// @Failure 500 {object} map[string]string "Failed to calculate the price or create rental history"
 // @Router /users/booking [post]
 // @Security BearerAuth
 func BookCar(c echo.Context) error {
@@ -58,52 +65,62 @@ func BookCar(c echo.Context) error {
 	claims := user.Claims.(*jwt.MapClaims)
 	userID := uint((*claims)["user_id"].(float64))
 
-	// Check if the selected car is available
+	if !bookingReq.ReturnDate.After(bookingReq.RentalDate) {
+		return jsonResponse(c, http.StatusBadRequest, "Validation error", "return_date must be after rental_date")
+	}
+
+	// Check if the selected car exists
 	car, err := getCarByID(bookingReq.CarID)
 	if err != nil {
 		return jsonResponse(c, http.StatusNotFound, "Car not found", err.Error())
 	}
 
-	// Check if there is enough stock to reduce
-	if car.StockAvailability < 1 {
-		return jsonResponse(c, http.StatusBadRequest, "Insufficient stock", fmt.Sprintf("Current stock: %d", car.StockAvailability))
-	}
-
 	// checks for driver and package
-	driver, eventPackage, err := getDriverandPackage(bookingReq.DriverID, bookingReq.PackageID)
+	_, eventPackage, err := getDriverandPackage(bookingReq.DriverID, bookingReq.PackageID)
 	if err != nil {
 		return jsonResponse(c, http.StatusNotFound, "driver and eventPackage not found", err.Error())
 	}
 
-	// Calculate the total cost
-	totalCost := calculateTotalCost(bookingReq, car, eventPackage)
-
-	// Apply discount based on membership level
-	totalCost = applyMembershipDiscount(userID, totalCost)
+	// Calculate the itemised cost, membership discount included
+	breakdown, err := priceBooking(bookingReq, car, eventPackage, userID)
+	if err != nil {
+		return jsonResponse(c, http.StatusInternalServerError, "Failed to calculate price", err.Error())
+	}
 
 	// Prepare data rental history entry
-	rentalHistory := createRentalHistoryEntry(bookingReq, userID, totalCost)
-
-	// Save the rental history to the database
-	if err := database.DB.Create(&rentalHistory).Error; err != nil {
-		return jsonResponse(c, http.StatusInternalServerError, "Failed to create rental history", err.Error())
-	}
+	rentalHistory := createRentalHistoryEntry(bookingReq, userID, breakdown)
 
-	// // // Send confirmation notification
-	if err := sendConfirmationNotification(userID, rentalHistory, car, driver, eventPackage); err != nil {
+	// Reserve a unit, save the rental history and queue its notification and invoice in one transaction,
+	// so the booking is never lost because WhatsApp or the payment gateway is down
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := services.ReserveCar(tx, bookingReq.CarID, bookingReq.RentalDate, bookingReq.ReturnDate, 0); err != nil {
+			return err
+		}
+		if err := tx.Create(&rentalHistory).Error; err != nil {
+			return err
+		}
+		if err := services.RecordRentalCreated(tx, rentalHistory, services.CustomerActor(userID), "Booking created"); err != nil {
+			return err
+		}
 
-		return jsonResponse(c, http.StatusInternalServerError, "Failed to send WhatsApp notification", err.Error())
+		payload := BookingOutboxPayload{RentalID: rentalHistory.RentalID, UserID: userID, Breakdown: breakdown}
+		if err := services.EnqueueOutbox(tx, OutboxBookingConfirmation, payload); err != nil {
+			return err
+		}
+		return services.EnqueueOutbox(tx, OutboxBookingInvoice, payload)
+	})
+	if errors.Is(err, services.ErrCarUnavailable) {
+		return jsonResponse(c, http.StatusBadRequest, "Car is not available for the selected dates", err.Error())
 	}
-
-	// // Send Invoice notification
-	if err := CreateInvoiceAndSendWhatsApp(userID, bookingReq, rentalHistory, car, driver, eventPackage); err != nil {
-		return jsonResponse(c, http.StatusInternalServerError, "Failed to send Invoice notification", err.Error())
+	if err != nil {
+		return jsonResponse(c, http.StatusInternalServerError, "Failed to create rental history", err.Error())
 	}
 
 	// Return success response
 	return c.JSON(http.StatusOK, echo.Map{
-		"message": "Car booking successfully created",
-		"data":    rentalHistory,
+		"message":         "Car booking successfully created",
+		"data":            rentalHistory,
+		"price_breakdown": breakdown,
 	})
 }
 
@@ -140,54 +157,35 @@ func getDriverandPackage(driverID, packageID *uint) (models.Driver, models.Event
 	return driver, eventPackage, nil
 }
 
-func calculateTotalCost(bookingReq BookingRequest, car models.Car, eventPackage models.EventPackage) float64 {
-	// Calculate rental duration
-	rentalDays := bookingReq.ReturnDate.Sub(bookingReq.RentalDate).Hours() / 24
-
-	totalCost := rentalDays * car.RentalCosts
-
-	if bookingReq.DriverID != nil {
-		totalCost += rentalDays * 100
-	}
-
-	if bookingReq.PackageID != nil {
-		totalCost += eventPackage.Cost
-	}
-
-	// Add costs for optional services
-	if bookingReq.PickupLocation != "" && bookingReq.DropoffLocation != "" {
-		totalCost += 100
+// priceBooking itemises the cost of a booking request for a user with the current price list, membership discount included
+func priceBooking(bookingReq BookingRequest, car models.Car, eventPackage models.EventPackage, userID uint) (services.PriceBreakdown, error) {
+	config, err := services.LoadPricingConfig(database.DB)
+	if err != nil {
+		return services.PriceBreakdown{}, err
 	}
 
-	if bookingReq.AirportTransfer {
-		totalCost += 50
+	input := services.PriceInput{
+		Car:               car,
+		RentalDate:        bookingReq.RentalDate,
+		ReturnDate:        bookingReq.ReturnDate,
+		RentalDuration:    bookingReq.RentalDuration,
+		WithDriver:        bookingReq.DriverID != nil,
+		PickupDropoff:     bookingReq.PickupLocation != "" && bookingReq.DropoffLocation != "",
+		AirportTransfer:   bookingReq.AirportTransfer,
+		ConciergeServices: bookingReq.ConciergeServices,
+		DiscountLevel:     services.GetMembershipDiscountLevel(database.DB, userID),
 	}
-
-	if bookingReq.ConciergeServices {
-		totalCost += 100
+	if bookingReq.PackageID != nil {
+		input.Package = &eventPackage
 	}
 
-	return totalCost
+	return services.CalculatePrice(config, input), nil
 }
 
-func applyMembershipDiscount(userID uint, totalCost float64) float64 {
-	var membership models.Membership
-	if err := database.DB.Where("user_id = ?", userID).First(&membership).Error; err == nil {
-		// Apply discount based on discount level
-		switch membership.DiscountLevel {
-		case "Silver":
-			totalCost *= 0.90 // 10% discount
-		case "Gold":
-			totalCost *= 0.80 // 20% discount
-		case "Platinum":
-			totalCost *= 0.70 // 30% discount
-		}
-	}
-
-	return totalCost
-}
+func createRentalHistoryEntry(bookingReq BookingRequest, userID uint, breakdown services.PriceBreakdown) models.RentalHistory {
+	// The unpaid booking holds its car until the payment hold window lapses
+	paymentDueAt := services.PaymentDueAt(time.Now(), bookingReq.RentalDate, paymentHoldWindow)
 
-func createRentalHistoryEntry(bookingReq BookingRequest, userID uint, totalCost float64) models.RentalHistory {
 	return models.RentalHistory{
 		UserID:            userID,
 		CarID:             bookingReq.CarID,
@@ -195,12 +193,15 @@ func createRentalHistoryEntry(bookingReq BookingRequest, userID uint, totalCost
 		PackageID:         bookingReq.PackageID,
 		RentalDate:        bookingReq.RentalDate,
 		ReturnDate:        &bookingReq.ReturnDate,
+		RentalDuration:    bookingReq.RentalDuration,
 		PickupLocation:    bookingReq.PickupLocation,
 		DropoffLocation:   bookingReq.DropoffLocation,
-		TotalCost:         totalCost,
-		Status:            "Book",
+		TotalCost:         breakdown.Total,
+		CostBreakdown:     breakdown.Encode(),
+		Status:            services.RentalStatusBook,
 		AirportTransfer:   bookingReq.AirportTransfer,
 		ConciergeServices: bookingReq.ConciergeServices,
+		PaymentDueAt:      &paymentDueAt,
 	}
 }
 
@@ -210,234 +211,90 @@ func sendConfirmationNotification(userID uint, rentalHistory models.RentalHistor
 		return err
 	}
 
-	toPhoneNumber := fmt.Sprintf("whatsapp:+%s", userModel.PhoneNumber)
-
-	// Format the message body
-	messageBody := fmt.Sprintf(
-		"Subject: Booking Confirmation - [Rental ID: %d]\n\n"+
-			"Dear %s - %s,\n\n"+
-			"Congratulations! Your booking has been successfully confirmed. Below are the details of your booking:\n\n"+
-			"User Details:\n"+
-			"  - Email: %s\n"+
-			"  - Phone Number: %s\n\n"+
-			"Rental Details:\n"+
-			"  - Rental ID: %d\n"+
-			"  - Car Name: %s\n"+
-			"  - Car Category: %s\n"+
-			"  - Car Brand: %s\n"+
-			"  - Car Model: %s\n"+
-			"  - Car Transmission: %s\n"+
-			"  - Car Year: %d\n"+
-			"  - Car Fuel Type: %s\n"+
-			"  - Car Class: %s\n\n"+
-			"Booking Details:\n"+
-			"  - Rental Date: %s\n"+
-			"  - Return Date: %s\n"+
-			"  - Pickup Location: %s\n"+
-			"  - Dropoff Location: %s\n"+
-			"  - Total Cost: %.2f\n"+
-			"  - Airport Transfer: %t\n"+
-			"  - Concierge Services: %t\n\n"+
-			"Driver Details:\n"+
-			"  - Driver Name: %s\n"+
-			"  - Driver Contact: %s\n\n"+
-			"Package Details:\n"+
-			"  - Package Name: %s\n"+
-			"  - Package Description: %s\n\n"+
-			"Thank you for choosing our service! We look forward to serving you.\n\n"+
-			"Best regards,\nJakarta Luxury Rent Car",
-		rentalHistory.RentalID,
-		userModel.Email,
-		userModel.Role,
-		userModel.Email,
-		userModel.PhoneNumber,
-		rentalHistory.RentalID,
-		car.Name,
-		car.Category,
-		car.Make,
-		car.Model,
-		car.Transmission,
-		car.Year,
-		car.FuelType,
-		car.Class,
-		rentalHistory.RentalDate.Format("02 January 2006 15:04"),
-		rentalHistory.ReturnDate.Format("02 January 2006 15:04"),
-		rentalHistory.PickupLocation,
-		rentalHistory.DropoffLocation,
-		rentalHistory.TotalCost,
-		rentalHistory.AirportTransfer,
-		rentalHistory.ConciergeServices,
-		driver.Name,
-		driver.PhoneNumber,
-		eventPackage.PackageName,
-		eventPackage.Description,
-	)
-
-	return sendWhatsAppNotification(toPhoneNumber, messageBody)
+	return notifyUser(userModel, services.EventBookingConfirmation, echo.Map{
+		"Rental":  rentalHistory,
+		"Car":     car,
+		"Driver":  driver,
+		"Package": eventPackage,
+	})
 }
 
-func CreateInvoiceAndSendWhatsApp(userID uint, bookingReq BookingRequest, rentalHistory models.RentalHistory, car models.Car, driver models.Driver, eventPackage models.EventPackage) error {
-
-	var costDriver, costPackage, costPickup, costTranferAirport, costConcierge, discountAmount float64
-
+func CreateInvoiceAndSendWhatsApp(userID uint, rentalHistory models.RentalHistory, car models.Car, breakdown services.PriceBreakdown) error {
 	var userModel models.User
 	if err := database.DB.Where("user_id = ?", userID).First(&userModel).Error; err != nil {
 		return err
 	}
 
-	fmt.Println("Go rentalHistory.TotalCost", rentalHistory.TotalCost)
-	var membership models.Membership
-	if err := database.DB.Where("user_id = ?", userID).First(&membership).Error; err == nil {
-		// Apply discount based on discount level
-		switch membership.DiscountLevel {
-		case "Silver":
-			originalCost := rentalHistory.TotalCost / 0.90 // 10% discount
-			discountAmount = originalCost * 0.10
-			fmt.Println("Go originalCost", originalCost)
-			fmt.Println("Go discountAmount", discountAmount)
-		case "Gold":
-			originalCost := rentalHistory.TotalCost / 0.80 // 20% discount
-			discountAmount = originalCost * 0.20
-			fmt.Println("Go originalCost", originalCost)
-			fmt.Println("Go discountAmount", discountAmount)
-		case "Platinum":
-			originalCost := rentalHistory.TotalCost / 0.70 // 30% discount
-			discountAmount = originalCost * 0.30
-			fmt.Println("Go originalCost", originalCost)
-			fmt.Println("Go discountAmount", discountAmount)
-		}
-	}
-
-	rentalDays := bookingReq.ReturnDate.Sub(bookingReq.RentalDate).Hours() / 24
-
-	if bookingReq.DriverID != nil {
-		costDriver = 100
-	}
-	if bookingReq.PackageID != nil {
-		costPackage = eventPackage.Cost
+	invoiceReq := services.InvoiceRequest{
+		Amount:        rentalHistory.TotalCost,
+		Description:   "Invoice Jakarta Luxury Car To : " + userModel.Email + " - " + userModel.PhoneNumber,
+		Duration:      24 * time.Hour,
+		CustomerEmail: userModel.Email,
+		CustomerPhone: userModel.PhoneNumber,
 	}
 
-	// Add costs for optional services
-	if bookingReq.PickupLocation != "" && bookingReq.DropoffLocation != "" {
-		costPickup = 100
-	}
-
-	if bookingReq.AirportTransfer {
-		costTranferAirport = 50
+	// The invoice stays payable for as long as the booking holds its car
+	if rentalHistory.PaymentDueAt != nil {
+		if remaining := time.Until(*rentalHistory.PaymentDueAt); remaining > 0 {
+			invoiceReq.Duration = remaining
+		}
 	}
 
-	if bookingReq.ConciergeServices {
-		costConcierge = 100
+	// One invoice item per booked line of the breakdown
+	for _, line := range breakdown.Lines() {
+		item := services.InvoiceItem{Name: line.Name, Quantity: int(line.Quantity), Price: line.UnitPrice}
+		// Gateways take whole quantities, so a partial day (hourly rounding) is billed as one line
+		if float64(item.Quantity) != line.Quantity {
+			item.Quantity = 1
+			item.Price = line.Amount
+		}
+		if line.Name == "Car Rental" {
+			item.Name = car.Name
+			item.Category = car.Category
+		}
+		invoiceReq.Items = append(invoiceReq.Items, item)
 	}
 
-	// Xendit API URL
-	url := "https://api.xendit.co/v2/invoices"
-
-	// Create request body
-	requestBody := map[string]interface{}{
-		"external_id":      "Invoice Jakarta Luxury Car For : " + car.Name,
-		"amount":           rentalHistory.TotalCost,
-		"description":      "Invoice Jakarta Luxury Car To : " + userModel.Email + " - " + userModel.PhoneNumber,
-		"invoice_duration": 86400,
-		"currency":         "IDR",
-		"customer": map[string]interface{}{
-			"email":         userModel.Email,
-			"mobile_number": userModel.PhoneNumber,
-		},
-		"items": []map[string]interface{}{
-			{
-				"name":     car.Name,
-				"quantity": rentalDays,
-				"price":    car.RentalCosts,
-				"category": car.Category,
-			},
-			{
-				"name":     "Driver",
-				"quantity": rentalDays,
-				"price":    costDriver,
-			},
-			{
-				"name":     "Event Package",
-				"quantity": 1,
-				"price":    costPackage,
-			},
-			{
-				"name":     "Pickup and Dropoff",
-				"quantity": 1,
-				"price":    costPickup,
-			},
-			{
-				"name":     "Transfer Airport",
-				"quantity": 1,
-				"price":    costTranferAirport,
-			},
-			{
-				"name":     "Concierge Service",
-				"quantity": 1,
-				"price":    costConcierge,
-			},
-		},
-		"fees": []map[string]interface{}{
-			{
-				"type":  "Discount Level - " + membership.DiscountLevel,
-				"value": discountAmount,
-			},
-		},
+	// The membership discount is sent as a negative fee
+	if breakdown.DiscountAmount > 0 {
+		invoiceReq.Fees = []services.InvoiceFee{
+			{Type: "Discount Level - " + breakdown.DiscountLevel, Value: -breakdown.DiscountAmount},
+		}
 	}
 
-	// Convert request body to JSON
-	jsonBody, err := json.Marshal(requestBody)
+	invoice, err := issueInvoice(rentalHistory.RentalID, services.InvoicePurposeBooking, invoiceReq)
 	if err != nil {
-		return fmt.Errorf("failed to marshal request body: %v", err)
+		return err
 	}
 
-	// Create a new HTTP request
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonBody))
-	if err != nil {
-		return fmt.Errorf("failed to create HTTP request: %v", err)
-	}
+	return sendInvoiceLink(userID, invoice)
+}
 
-	// Set Content-Type header
-	req.Header.Set("Content-Type", "application/json")
+// issueInvoice creates a gateway invoice for a rental, see createGatewayInvoice
+func issueInvoice(rentalID uint, purpose string, invoiceReq services.InvoiceRequest) (models.Invoice, error) {
+	return createGatewayInvoice(models.Invoice{RentalID: &rentalID, Purpose: purpose}, rentalID, invoiceReq)
+}
 
-	// Set basic authentication header
-	username := os.Getenv("API_KEY_XENDIT")
-	req.SetBasicAuth(username, "")
+// createGatewayInvoice creates a gateway invoice under a unique external_id and stores it,
+// so the payment callback can find the rental or top up the invoice belongs to
+func createGatewayInvoice(invoice models.Invoice, referenceID uint, invoiceReq services.InvoiceRequest) (models.Invoice, error) {
+	invoice.ExternalID = services.NewInvoiceExternalID(invoice.Purpose, referenceID, time.Now())
+	invoice.Amount = invoiceReq.Amount
+	invoice.Status = services.InvoiceStatusPending
+	invoiceReq.ExternalID = invoice.ExternalID
 
-	// Send the HTTP request
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	created, err := paymentGateway.CreateInvoice(invoiceReq)
 	if err != nil {
-		return fmt.Errorf("failed to send HTTP request: %v", err)
+		return invoice, err
 	}
-	defer resp.Body.Close()
 
-	// Check if the response status is 200 OK
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := ioutil.ReadAll(resp.Body)
-		return fmt.Errorf("received non-200 response: %s - %s", resp.Status, string(bodyBytes))
-	}
+	invoice.XenditInvoiceID = created.ID
+	invoice.InvoiceURL = created.InvoiceURL
+	invoice.ExpiresAt = created.ExpiresAt
 
-	// Read the response body
-	var responseBody map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&responseBody); err != nil {
-		return fmt.Errorf("failed to decode response body: %v", err)
+	if err := database.DB.Create(&invoice).Error; err != nil {
+		return invoice, fmt.Errorf("failed to store invoice: %v", err)
 	}
 
-	// Extract invoice URL or ID for WhatsApp message
-	invoiceURL, ok := responseBody["invoice_url"].(string)
-	if !ok {
-		return fmt.Errorf("invoice URL not found in response")
-	}
-
-	toPhoneNumber := fmt.Sprintf("whatsapp:+%s", userModel.PhoneNumber)
-
-	messageBody := fmt.Sprintf(
-		"Dear %s - %s,\n\nThank you for using Jakarta Luxury Car Rental. Please find your invoice at the following link:\n%s\n\nKindly complete the payment within the next 24 hours. If you have any questions, feel free to contact us.\n\nBest regards,\nJakarta Luxury Car Rental",
-		userModel.Email,
-		userModel.Role,
-		invoiceURL,
-	)
-
-	return sendWhatsAppNotification(toPhoneNumber, messageBody)
+	return invoice, nil
 }
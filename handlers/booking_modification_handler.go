@@ -0,0 +1,344 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"jakarta-luxury-rent-car/database"
+	"jakarta-luxury-rent-car/models"
+	"jakarta-luxury-rent-car/services"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ModifyBookingRequest struct to capture the fields a customer wants to change.
+// Fields left out of the request keep their current value.
+type ModifyBookingRequest struct {
+	DriverID          *uint      `json:"driver_id"`
+	PackageID         *uint      `json:"package_id"`
+	RentalDate        *time.Time `json:"rental_date"`
+	ReturnDate        *time.Time `json:"return_date"`
+	PickupLocation    *string    `json:"pickup_location"`
+	DropoffLocation   *string    `json:"dropoff_location"`
+	AirportTransfer   *bool      `json:"airport_transfer"`
+	ConciergeServices *bool      `json:"concierge_services"`
+}
+
+var (
+	errBookingNotModifiable = errors.New("booking can no longer be modified")
+	errOnlyExtendWhileRent  = errors.New("a rental that is already picked up can only change its return date and dropoff location")
+	errInvalidRentalWindow  = errors.New("return_date must be after rental_date")
+)
+
+// @Summary Modify or extend a booking
+// @Description Change dates, driver, package, locations or add-ons of your own booking. Availability and price are recalculated; paid bookings are charged or refunded the difference on the deposit, or get a supplementary invoice for an increase the deposit does not cover, unpaid bookings get a new booking invoice for the new total that replaces the old one. Picked-up rentals can only change return date and dropoff location.
+// @Tags Role User
+// @Accept json
+// @Produce json
+// @Param id path int true "Rental ID"
+// @Param modifyReq body ModifyBookingRequest true "Fields to change"
+// @Success 200 {object} map[string]interface{} "Updated booking with the price difference"
+// @Failure 400 {object} map[string]interface{} "Invalid request format, invalid dates, or car not available for the new dates"
+// @Failure 404 {object} map[string]interface{} "Rental history, driver or package not found"
+// @Failure 409 {object} map[string]interface{} "Booking can no longer be modified"
+// @Failure 500 {object} map[string]interface{} "Failed to modify booking"
+// @Router /users/bookings/{id} [put]
+// @Security BearerAuth
+func ModifyBooking(c echo.Context) error {
+	rentalID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Invalid rental ID",
+			"error":   err.Error(),
+		})
+	}
+
+	var modifyReq ModifyBookingRequest
+	if err := c.Bind(&modifyReq); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Invalid request format",
+			"error":   err.Error(),
+		})
+	}
+
+	// Extract user ID from JWT token
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*jwt.MapClaims)
+	userID := uint((*claims)["user_id"].(float64))
+
+	var rentalHistory models.RentalHistory
+	var car models.Car
+	var previousCost, difference, supplement float64
+	var breakdown services.PriceBreakdown
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("rental_id = ? AND user_id = ?", rentalID, userID).
+			First(&rentalHistory).Error; err != nil {
+			return err
+		}
+
+		switch rentalHistory.Status {
+		case services.RentalStatusBook, services.RentalStatusPaid:
+		case services.RentalStatusRent:
+			if modifyReq.RentalDate != nil || modifyReq.PickupLocation != nil || modifyReq.DriverID != nil ||
+				modifyReq.PackageID != nil || modifyReq.AirportTransfer != nil || modifyReq.ConciergeServices != nil {
+				return errOnlyExtendWhileRent
+			}
+		default:
+			return errBookingNotModifiable
+		}
+
+		bookingReq := applyBookingChanges(bookingRequestFromRental(rentalHistory), modifyReq)
+		if !bookingReq.ReturnDate.After(bookingReq.RentalDate) {
+			return errInvalidRentalWindow
+		}
+
+		var err error
+		if car, err = services.ReserveCar(tx, rentalHistory.CarID, bookingReq.RentalDate, bookingReq.ReturnDate, rentalHistory.RentalID); err != nil {
+			return err
+		}
+
+		_, eventPackage, err := getDriverandPackage(bookingReq.DriverID, bookingReq.PackageID)
+		if err != nil {
+			return err
+		}
+
+		// Same pricing as a new booking, including the membership discount
+		breakdown, err = priceBooking(bookingReq, car, eventPackage, userID)
+		if err != nil {
+			return err
+		}
+		totalCost := breakdown.Total
+
+		previousCost = rentalHistory.TotalCost
+		difference = math.Round((totalCost-previousCost)*100) / 100
+
+		rentalHistory.DriverID = bookingReq.DriverID
+		rentalHistory.PackageID = bookingReq.PackageID
+		rentalHistory.RentalDate = bookingReq.RentalDate
+		rentalHistory.ReturnDate = &bookingReq.ReturnDate
+		rentalHistory.PickupLocation = bookingReq.PickupLocation
+		rentalHistory.DropoffLocation = bookingReq.DropoffLocation
+		rentalHistory.AirportTransfer = bookingReq.AirportTransfer
+		rentalHistory.ConciergeServices = bookingReq.ConciergeServices
+		rentalHistory.TotalCost = totalCost
+		rentalHistory.CostBreakdown = breakdown.Encode()
+
+		// The car model has room, but the physical unit already assigned must be free over the new dates too
+		if _, err := services.KeepFleetUnit(tx, &rentalHistory); err != nil {
+			return err
+		}
+
+		if err := tx.Save(&rentalHistory).Error; err != nil {
+			return err
+		}
+
+		// Bookings that were already paid settle the difference against the deposit right away
+		if rentalHistory.Status == services.RentalStatusBook || difference == 0 {
+			return nil
+		}
+
+		entry := services.WalletEntry{
+			UserID:      userID,
+			Type:        services.WalletRentalPayment,
+			Amount:      difference,
+			RentalID:    &rentalHistory.RentalID,
+			Description: "Booking change",
+		}
+		if difference < 0 {
+			entry.Type = services.WalletRefund
+			entry.Amount = -difference
+		}
+		transaction, err := services.PostWalletTransaction(tx, entry)
+		if errors.Is(err, services.ErrInsufficientFunds) {
+			// The deposit does not cover the increase, so it is billed on a supplementary invoice instead
+			supplement = difference
+			return services.RecordAudit(tx, auditActor(c), services.AuditEntry{
+				Action:     services.AuditBookingModify,
+				EntityType: services.AuditEntityRental,
+				EntityID:   auditID(rentalHistory.RentalID),
+				Before:     echo.Map{"total_cost": previousCost},
+				After:      echo.Map{"total_cost": rentalHistory.TotalCost, "supplementary_amount": supplement},
+			})
+		}
+		if err != nil {
+			return err
+		}
+
+		return services.RecordAudit(tx, auditActor(c), services.AuditEntry{
+			Action:     services.AuditBookingModify,
+			EntityType: services.AuditEntityRental,
+			EntityID:   auditID(rentalHistory.RentalID),
+			Before:     echo.Map{"total_cost": previousCost, "deposit_amount": services.BalanceBefore(transaction)},
+			After: echo.Map{
+				"total_cost":            rentalHistory.TotalCost,
+				"deposit_amount":        transaction.BalanceAfter,
+				"wallet_transaction_id": transaction.WalletTransactionID,
+			},
+		})
+	})
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return c.JSON(http.StatusNotFound, echo.Map{
+			"message": "Rental history, driver or package not found",
+			"error":   err.Error(),
+		})
+	case errors.Is(err, errBookingNotModifiable), errors.Is(err, errOnlyExtendWhileRent):
+		return c.JSON(http.StatusConflict, echo.Map{
+			"message": "Booking cannot be modified",
+			"error":   err.Error(),
+		})
+	case errors.Is(err, errInvalidRentalWindow):
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Validation error",
+			"error":   err.Error(),
+		})
+	case errors.Is(err, services.ErrCarUnavailable), errors.Is(err, services.ErrNoFleetUnitAvailable), errors.Is(err, services.ErrFleetUnitBooked):
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Car is not available for the selected dates",
+			"error":   err.Error(),
+		})
+	case err != nil:
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": "Failed to modify booking",
+			"error":   err.Error(),
+		})
+	}
+
+	var userModel models.User
+	if err := database.DB.First(&userModel, userID).Error; err != nil {
+		return c.JSON(http.StatusNotFound, echo.Map{
+			"message": "User not found",
+			"error":   err.Error(),
+		})
+	}
+
+	switch {
+	case rentalHistory.Status == services.RentalStatusBook && difference != 0:
+		// Unpaid bookings are billed the new total, up or down, on a new booking invoice
+		if err := reissueBookingInvoice(userID, rentalHistory, car, breakdown); err != nil {
+			return c.JSON(http.StatusInternalServerError, echo.Map{
+				"message": "Booking updated but failed to reissue the invoice",
+				"error":   err.Error(),
+			})
+		}
+	case supplement > 0:
+		// Paid bookings whose deposit does not cover the increase are billed for it separately
+		if err := createSupplementaryInvoice(userModel, rentalHistory, car, supplement); err != nil {
+			return c.JSON(http.StatusInternalServerError, echo.Map{
+				"message": "Booking updated but failed to send supplementary invoice",
+				"error":   err.Error(),
+			})
+		}
+	default:
+		// Paid bookings settle the difference with the deposit
+		data := echo.Map{"Rental": rentalHistory, "Charged": 0.0, "Refunded": 0.0}
+		if rentalHistory.Status != services.RentalStatusBook && difference > 0 {
+			data["Charged"] = difference
+		} else if rentalHistory.Status != services.RentalStatusBook && difference < 0 {
+			data["Refunded"] = -difference
+		}
+
+		notifyUser(userModel, services.EventBookingUpdated, data)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"message":         "Booking successfully modified",
+		"previous_cost":   previousCost,
+		"cost_difference": difference,
+		"amount_invoiced": supplement,
+		"data":            rentalHistory,
+	})
+}
+
+// bookingRequestFromRental rebuilds the booking request an existing rental was priced from
+func bookingRequestFromRental(rental models.RentalHistory) BookingRequest {
+	returnDate := rental.RentalDate.Add(24 * time.Hour)
+	if rental.ReturnDate != nil {
+		returnDate = *rental.ReturnDate
+	}
+
+	return BookingRequest{
+		CarID:             rental.CarID,
+		DriverID:          rental.DriverID,
+		PackageID:         rental.PackageID,
+		RentalDate:        rental.RentalDate,
+		ReturnDate:        returnDate,
+		PickupLocation:    rental.PickupLocation,
+		DropoffLocation:   rental.DropoffLocation,
+		RentalDuration:    rental.RentalDuration,
+		AirportTransfer:   rental.AirportTransfer,
+		ConciergeServices: rental.ConciergeServices,
+	}
+}
+
+func applyBookingChanges(bookingReq BookingRequest, modifyReq ModifyBookingRequest) BookingRequest {
+	if modifyReq.DriverID != nil {
+		bookingReq.DriverID = modifyReq.DriverID
+	}
+	if modifyReq.PackageID != nil {
+		bookingReq.PackageID = modifyReq.PackageID
+	}
+	if modifyReq.RentalDate != nil {
+		bookingReq.RentalDate = *modifyReq.RentalDate
+	}
+	if modifyReq.ReturnDate != nil {
+		bookingReq.ReturnDate = *modifyReq.ReturnDate
+	}
+	if modifyReq.PickupLocation != nil {
+		bookingReq.PickupLocation = *modifyReq.PickupLocation
+	}
+	if modifyReq.DropoffLocation != nil {
+		bookingReq.DropoffLocation = *modifyReq.DropoffLocation
+	}
+	if modifyReq.AirportTransfer != nil {
+		bookingReq.AirportTransfer = *modifyReq.AirportTransfer
+	}
+	if modifyReq.ConciergeServices != nil {
+		bookingReq.ConciergeServices = *modifyReq.ConciergeServices
+	}
+
+	return bookingReq
+}
+
+// reissueBookingInvoice replaces the pending booking invoice of a modified unpaid booking with one for its new total.
+// The new invoice is issued before the old ones are expired, so their EXPIRED callbacks find them superseded
+// and leave the booking alone.
+func reissueBookingInvoice(userID uint, rentalHistory models.RentalHistory, car models.Car, breakdown services.PriceBreakdown) error {
+	if err := CreateInvoiceAndSendWhatsApp(userID, rentalHistory, car, breakdown); err != nil {
+		return err
+	}
+	return expireSupersededInvoices(rentalHistory.RentalID)
+}
+
+// createSupplementaryInvoice bills the customer of a paid booking for a price increase their deposit does not cover
+func createSupplementaryInvoice(userModel models.User, rentalHistory models.RentalHistory, car models.Car, amount float64) error {
+	invoiceReq := services.InvoiceRequest{
+		Amount:        amount,
+		Description:   "Supplementary Invoice Jakarta Luxury Car To : " + userModel.Email + " - " + userModel.PhoneNumber,
+		Duration:      paymentHoldWindow,
+		CustomerEmail: userModel.Email,
+		CustomerPhone: userModel.PhoneNumber,
+		Items: []services.InvoiceItem{
+			{Name: "Booking change - " + car.Name, Quantity: 1, Price: amount, Category: car.Category},
+		},
+	}
+
+	invoice, err := issueInvoice(rentalHistory.RentalID, services.InvoicePurposeSupplement, invoiceReq)
+	if err != nil {
+		return err
+	}
+
+	return notifyUser(userModel, services.EventSupplementaryInvoice, echo.Map{
+		"Rental":  rentalHistory,
+		"Amount":  amount,
+		"Invoice": invoice,
+	})
+}
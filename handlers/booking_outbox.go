@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"jakarta-luxury-rent-car/database"
+	"jakarta-luxury-rent-car/models"
+	"jakarta-luxury-rent-car/services"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// Outbox message kinds queued by BookCar, reminders are queued by services.ReminderScheduler
+const (
+	OutboxBookingConfirmation = "booking_confirmation"
+	OutboxBookingInvoice      = "booking_invoice"
+)
+
+// BookingOutboxPayload is the outbox payload of a new booking
+type BookingOutboxPayload struct {
+	RentalID  uint                    `json:"rental_id"`
+	UserID    uint                    `json:"user_id"`
+	Breakdown services.PriceBreakdown `json:"breakdown"`
+}
+
+// OutboxHandlers returns the delivery handler of every outbox kind queued by the handlers package
+func OutboxHandlers() map[string]services.OutboxHandler {
+	return map[string]services.OutboxHandler{
+		OutboxBookingConfirmation:     deliverBookingConfirmation,
+		OutboxBookingInvoice:          deliverBookingInvoice,
+		services.OutboxReminder:       deliverRentalReminder,
+		services.OutboxBookingExpired: deliverBookingExpired,
+	}
+}
+
+func deliverBookingConfirmation(payload []byte) error {
+	var msg BookingOutboxPayload
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		return err
+	}
+
+	var rentalHistory models.RentalHistory
+	if err := database.DB.First(&rentalHistory, msg.RentalID).Error; err != nil {
+		return err
+	}
+
+	car, err := getCarByID(rentalHistory.CarID)
+	if err != nil {
+		return err
+	}
+
+	driver, eventPackage, err := getDriverandPackage(rentalHistory.DriverID, rentalHistory.PackageID)
+	if err != nil {
+		return err
+	}
+
+	return sendConfirmationNotification(msg.UserID, rentalHistory, car, driver, eventPackage)
+}
+
+func deliverBookingInvoice(payload []byte) error {
+	var msg BookingOutboxPayload
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		return err
+	}
+
+	var rentalHistory models.RentalHistory
+	if err := database.DB.First(&rentalHistory, msg.RentalID).Error; err != nil {
+		return err
+	}
+
+	// The booking was paid from the deposit or cancelled before the invoice went out
+	if rentalHistory.Status != services.RentalStatusBook {
+		return nil
+	}
+
+	// A previous attempt created the invoice but failed to send it, so only resend the link
+	var invoice models.Invoice
+	err := database.DB.Where("rental_id = ? AND purpose = ? AND status = ?", rentalHistory.RentalID, services.InvoicePurposeBooking, services.InvoiceStatusPending).
+		Order("invoice_id DESC").
+		First(&invoice).Error
+	if err == nil {
+		return sendInvoiceLink(msg.UserID, invoice)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	car, err := getCarByID(rentalHistory.CarID)
+	if err != nil {
+		return err
+	}
+
+	return CreateInvoiceAndSendWhatsApp(msg.UserID, rentalHistory, car, msg.Breakdown)
+}
+
+// sendInvoiceLink sends the payment link of a booking invoice to the customer
+func sendInvoiceLink(userID uint, invoice models.Invoice) error {
+	var userModel models.User
+	if err := database.DB.Where("user_id = ?", userID).First(&userModel).Error; err != nil {
+		return err
+	}
+
+	return notifyUser(userModel, services.EventBookingInvoice, echo.Map{"Invoice": invoice})
+}
+
+// deliverBookingExpired closes the invoice of a booking the expiry job expired and tells the customer
+func deliverBookingExpired(payload []byte) error {
+	var msg services.BookingExpiredPayload
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		return err
+	}
+
+	if err := expirePendingInvoices(msg.RentalID); err != nil {
+		return err
+	}
+
+	var rentalHistory models.RentalHistory
+	if err := database.DB.First(&rentalHistory, msg.RentalID).Error; err != nil {
+		return err
+	}
+
+	var customer models.User
+	if err := database.DB.First(&customer, rentalHistory.UserID).Error; err != nil {
+		return err
+	}
+
+	return notifyUser(customer, services.EventInvoiceExpired, echo.Map{"Rental": rentalHistory})
+}
+
+// deliverRentalReminder sends a scheduled reminder, unless the rental moved on since it was queued
+func deliverRentalReminder(payload []byte) error {
+	var msg services.ReminderPayload
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		return err
+	}
+
+	var rentalHistory models.RentalHistory
+	if err := database.DB.First(&rentalHistory, msg.RentalID).Error; err != nil {
+		return err
+	}
+
+	var customer models.User
+	if err := database.DB.First(&customer, rentalHistory.UserID).Error; err != nil {
+		return err
+	}
+
+	car, err := getCarByID(rentalHistory.CarID)
+	if err != nil {
+		return err
+	}
+
+	switch msg.Kind {
+	case services.ReminderPickup:
+		if rentalHistory.Status != services.RentalStatusPaid && rentalHistory.Status != services.RentalStatusRent {
+			return nil
+		}
+		return notifyUser(customer, services.EventReminderPickup, echo.Map{"Rental": rentalHistory, "Car": car})
+
+	case services.ReminderReturn:
+		if rentalHistory.Status != services.RentalStatusRent {
+			return nil
+		}
+		return notifyUser(customer, services.EventReminderReturn, echo.Map{"Rental": rentalHistory, "Car": car})
+
+	case services.ReminderOverdue:
+		if rentalHistory.Status != services.RentalStatusRent {
+			return nil
+		}
+
+		var owner models.User
+		if err := database.DB.Where("role = ?", "owner").First(&owner).Error; err != nil {
+			return err
+		}
+		return notifyUser(owner, services.EventOverdueOwner, echo.Map{"Rental": rentalHistory, "Car": car, "Customer": customer})
+
+	case services.ReminderUnpaidInvoice:
+		var invoice models.Invoice
+		if err := database.DB.First(&invoice, msg.InvoiceID).Error; err != nil {
+			return err
+		}
+		if invoice.Status != services.InvoiceStatusPending {
+			return nil
+		}
+		return notifyUser(customer, services.EventInvoiceReminder, echo.Map{"Rental": rentalHistory, "Invoice": invoice})
+	}
+
+	return fmt.Errorf("unknown reminder kind %q", msg.Kind)
+}
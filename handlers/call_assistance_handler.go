@@ -1,14 +1,13 @@
 package handlers
 
 import (
-	"fmt"
 	"net/http"
 	"net/url"
-	"strconv"
 	"time"
 
 	"jakarta-luxury-rent-car/database"
 	"jakarta-luxury-rent-car/models"
+	"jakarta-luxury-rent-car/services"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
@@ -108,41 +107,19 @@ func CallAssistance(c echo.Context) error {
 		})
 	}
 
-	toPhoneNumber := fmt.Sprintf("whatsapp:+%s", userOwnerModel.PhoneNumber)
-
 	gmapsLink := "https://www.google.com/maps/search/?api=1&query=" + url.QueryEscape(callAssistance.Location)
 
-	messageBody := "Subject: Call Assistance Request - [Rental ID: " + strconv.Itoa(int(rentalHistory.RentalID)) + "]\n\n" +
-		"Dear " + userOwnerModel.Email + " - " + userOwnerModel.Role + ",\n\n" +
-		"You received call assistance. Below are the details of user request:\n\n" +
-		"User Details:\n" +
-		"  - Email: " + userModel.Email + "\n" +
-		"  - Phone Number: " + userModel.PhoneNumber + "\n\n" +
-		"Rental Details:\n" +
-		"  - Rental ID: " + strconv.Itoa(int(rentalHistory.RentalID)) + "\n" +
-		"  - Car Name: " + car.Name + "\n" +
-		"  - Car Category: " + car.Category + "\n" +
-		"  - Car Brand: " + car.Make + "\n" +
-		"  - Car Model: " + car.Model + "\n" +
-		"  - Car Transmission: " + car.Transmission + "\n" +
-		"  - Car Year: " + strconv.Itoa(car.Year) + "\n" +
-		"  - Car Fuel Type: " + car.FuelType + "\n" +
-		"  - Car Class: " + car.Class + "\n\n" +
-		"Assistance Request Details:\n" +
-		"  - Date: " + callAssistance.CallAssistanceDate.Format("02 January 2006 15:04:05") + "\n" +
-		"  - Location: " + callAssistance.Location + "\n" +
-		"  - Link to Location: " + gmapsLink + "\n" +
-		"  - Description: " + callAssistance.Description
-
-	// fmt.Println("toPhoneNumber", toPhoneNumber)
-	// fmt.Println("gmapsLink", gmapsLink)
-	// fmt.Println("messageBody", messageBody)
-
-	// sendWhatsAppNotification
-	err := sendWhatsAppNotification(toPhoneNumber, messageBody)
+	// Notify the owner over their preferred channel
+	err := notifyUser(userOwnerModel, services.EventCallAssistanceOwner, echo.Map{
+		"Customer":   userModel,
+		"Rental":     rentalHistory,
+		"Car":        car,
+		"Assistance": callAssistance,
+		"MapsLink":   gmapsLink,
+	})
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, echo.Map{
-			"message": "Failed to send WhatsApp notification",
+			"message": "Failed to send notification",
 			"error":   err.Error(),
 		})
 	}
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"jakarta-luxury-rent-car/database"
+	"jakarta-luxury-rent-car/models"
+	"jakarta-luxury-rent-car/services"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// cancellationPolicy decides the refund of cancelled bookings, configured by main
+var cancellationPolicy = services.DefaultCancellationPolicy()
+
+// SetCancellationPolicy sets the policy cancelled bookings are refunded with
+func SetCancellationPolicy(policy services.CancellationPolicy) {
+	cancellationPolicy = policy
+}
+
+// CancelBookingRequest struct to capture why the customer cancels
+type CancelBookingRequest struct {
+	Reason string `json:"reason"` // Optional
+}
+
+// @Summary Cancel a booking
+// @Description Cancel your own booking before pickup. Paid bookings are refunded according to the cancellation policy, to the deposit or back through the payment gateway when paid by invoice.
+// @Tags Role User
+// @Accept json
+// @Produce json
+// @Param id path int true "Rental ID"
+// @Param cancelReq body CancelBookingRequest false "Optional cancellation reason"
+// @Success 200 {object} map[string]interface{} "Cancelled booking with the refunded amount"
+// @Failure 400 {object} map[string]interface{} "Invalid rental ID or request format"
+// @Failure 404 {object} map[string]interface{} "Rental history not found"
+// @Failure 409 {object} map[string]interface{} "Booking can no longer be cancelled, e.g. the car has been picked up"
+// @Failure 500 {object} map[string]interface{} "Failed to cancel booking"
+// @Router /users/bookings/{id}/cancel [post]
+// @Security BearerAuth
+func CancelBooking(c echo.Context) error {
+	rentalID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Invalid rental ID",
+			"error":   err.Error(),
+		})
+	}
+
+	var cancelReq CancelBookingRequest
+	if err := c.Bind(&cancelReq); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Invalid request format",
+			"error":   err.Error(),
+		})
+	}
+
+	// Extract user ID from JWT token
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*jwt.MapClaims)
+	userID := uint((*claims)["user_id"].(float64))
+
+	reason := cancelReq.Reason
+	if reason == "" {
+		reason = "Cancelled by customer"
+	}
+
+	cancelledAt := time.Now()
+
+	var rentalHistory models.RentalHistory
+	var refund models.Refund
+	var refundPercent, refundAmount float64
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("rental_id = ? AND user_id = ?", rentalID, userID).
+			First(&rentalHistory).Error; err != nil {
+			return err
+		}
+
+		// Only paid bookings have debited the deposit
+		wasPaid := rentalHistory.Status == services.RentalStatusPaid
+
+		if err := services.TransitionRental(tx, &rentalHistory, services.RentalStatusCancel, services.CustomerActor(userID), reason); err != nil {
+			return err
+		}
+
+		// The cancelled rental no longer counts towards availability, so its unit is free again
+		if _, err := services.ReleaseFleetUnit(tx, rentalHistory, nil); err != nil {
+			return err
+		}
+
+		if !wasPaid {
+			return nil
+		}
+
+		// A supplementary invoice still open for a booking change was never collected
+		unpaid, err := services.UnpaidSupplements(tx, rentalHistory.RentalID)
+		if err != nil {
+			return err
+		}
+
+		refundPercent = cancellationPolicy.RefundPercent(rentalHistory.RentalDate, cancelledAt)
+		refundAmount = cancellationPolicy.RefundAmount(rentalHistory.TotalCost-unpaid, rentalHistory.RentalDate, cancelledAt)
+		if refundAmount == 0 {
+			return nil
+		}
+
+		// Refund to the deposit, or back through the gateway when the booking was paid by invoice
+		refund, err = services.RequestRefund(tx, rentalHistory, refundAmount, fmt.Sprintf("Cancellation refund (%.0f%%)", refundPercent))
+		if err != nil {
+			return err
+		}
+
+		return services.RecordAudit(tx, auditActor(c), services.AuditEntry{
+			Action:     services.AuditBookingCancel,
+			EntityType: services.AuditEntityRental,
+			EntityID:   auditID(rentalHistory.RentalID),
+			Before:     echo.Map{"status": services.RentalStatusPaid},
+			After: echo.Map{
+				"status":         rentalHistory.Status,
+				"refund_id":      refund.RefundID,
+				"refund_amount":  refund.Amount,
+				"refund_method":  refund.Method,
+				"refund_percent": refundPercent,
+			},
+		})
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.JSON(http.StatusNotFound, echo.Map{
+			"message": "Rental history not found",
+			"error":   err.Error(),
+		})
+	}
+	if err != nil {
+		return c.JSON(rentalTransitionStatusCode(err), echo.Map{
+			"message": "Failed to cancel booking",
+			"error":   err.Error(),
+		})
+	}
+
+	// An unpaid booking must not be paid after it was cancelled
+	expirePendingInvoices(rentalHistory.RentalID)
+
+	var userModel models.User
+	if err := database.DB.First(&userModel, userID).Error; err == nil {
+		notifyUser(userModel, services.EventBookingCancelled, echo.Map{
+			"Rental":        rentalHistory,
+			"RefundAmount":  refundAmount,
+			"RefundPercent": refundPercent,
+		})
+	}
+
+	if refund.RefundID != 0 {
+		refund = processRefund(refund)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"message":        "Booking cancelled",
+		"refund_percent": refundPercent,
+		"refund_amount":  refundAmount,
+		"refund":         refund,
+		"data":           rentalHistory,
+	})
+}
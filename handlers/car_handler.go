@@ -1,26 +1,46 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
+	"time"
 
 	"jakarta-luxury-rent-car/database"
 	"jakarta-luxury-rent-car/models"
+	"jakarta-luxury-rent-car/services"
 
 	"github.com/labstack/echo/v4"
 )
 
+// CarListing is a car together with the number of units free in the requested window
+type CarListing struct {
+	models.Car
+	AvailableUnits int `json:"available_units"`
+}
+
 // @Summary Get available luxury cars
-// @Description Get available luxury cars
+// @Description Get luxury cars that have at least one free unit between rental_date and return_date (defaults to the next 24 hours)
 // @Tags Public
 // @Accept json
 // @Produce json
-// @Success 200 {array} models.Car "List of available luxury cars"
+// @Param rental_date query string false "Start of the rental window (YYYY-MM-DD or RFC3339)"
+// @Param return_date query string false "End of the rental window (YYYY-MM-DD or RFC3339)"
+// @Success 200 {array} CarListing "List of available luxury cars"
+// @Failure 400 {object} map[string]interface{} "Invalid rental window"
 // @Failure 500 {object} map[string]interface{} "Error retrieving cars from database"
 // @Router /cars [get]
 func GetLuxuryCars(c echo.Context) error {
+	from, to, err := parseRentalWindow(c.QueryParam("rental_date"), c.QueryParam("return_date"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Invalid rental window",
+			"error":   err.Error(),
+		})
+	}
+
 	var cars []models.Car
 
-	// Query the database for available cars
+	// Query the database for cars in the fleet
 	if err := database.DB.Where("stock_availability > ?", 0).Find(&cars).Error; err != nil {
 		return c.JSON(http.StatusInternalServerError, echo.Map{
 			"message": "Error retrieving cars from database",
@@ -28,6 +48,56 @@ func GetLuxuryCars(c echo.Context) error {
 		})
 	}
 
+	availability, err := services.ListCarAvailability(database.DB, cars, from, to)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": "Error calculating car availability",
+			"error":   err.Error(),
+		})
+	}
+
+	// Keep only the cars with a free unit for the whole window
+	listings := []CarListing{}
+	for _, car := range cars {
+		if free := availability[car.CarID].AvailableUnits; free > 0 {
+			listings = append(listings, CarListing{Car: car, AvailableUnits: free})
+		}
+	}
+
 	// Return the list of available luxury cars in JSON format
-	return c.JSON(http.StatusOK, cars)
+	return c.JSON(http.StatusOK, listings)
+}
+
+// parseRentalWindow reads the optional rental_date/return_date query values, defaulting to the next 24 hours
+func parseRentalWindow(rentalDate, returnDate string) (time.Time, time.Time, error) {
+	from := time.Now()
+	if rentalDate != "" {
+		parsed, err := parseDateParam(rentalDate)
+		if err != nil {
+			return from, from, fmt.Errorf("invalid rental_date: %v", err)
+		}
+		from = parsed
+	}
+
+	to := from.Add(24 * time.Hour)
+	if returnDate != "" {
+		parsed, err := parseDateParam(returnDate)
+		if err != nil {
+			return from, to, fmt.Errorf("invalid return_date: %v", err)
+		}
+		to = parsed
+	}
+
+	if !to.After(from) {
+		return from, to, fmt.Errorf("return_date must be after rental_date")
+	}
+
+	return from, to, nil
+}
+
+func parseDateParam(value string) (time.Time, error) {
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed, nil
+	}
+	return time.Parse("2006-01-02", value)
 }
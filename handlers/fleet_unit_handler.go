@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"jakarta-luxury-rent-car/database"
+	"jakarta-luxury-rent-car/models"
+	"jakarta-luxury-rent-car/services"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// FleetUnitRequest struct to capture a physical unit of a car
+type FleetUnitRequest struct {
+	CarID        uint   `json:"car_id" validate:"required"`
+	LicensePlate string `json:"license_plate" validate:"required,max=15"`
+	VIN          string `json:"vin" validate:"required,len=17"`
+	Colour       string `json:"colour" validate:"required"`
+	Odometer     int    `json:"odometer" validate:"gte=0"`
+	Status       string `json:"status" validate:"omitempty,oneof=available maintenance retired"`
+}
+
+// FleetUnitUpdateRequest struct to capture changes to an existing unit
+type FleetUnitUpdateRequest struct {
+	LicensePlate string `json:"license_plate" validate:"omitempty,max=15"`
+	Colour       string `json:"colour"`
+	Odometer     *int   `json:"odometer" validate:"omitempty,gte=0"`
+	Status       string `json:"status" validate:"omitempty,oneof=available maintenance retired"`
+}
+
+// @Summary List fleet units
+// @Description List the physical units behind each car, optionally filtered by car_id
+// @Tags Role Owner
+// @Accept json
+// @Produce json
+// @Param car_id query int false "Only units of this car"
+// @Success 200 {array} models.FleetUnit "List of fleet units"
+// @Failure 403 {object} map[string]interface{} "Permission denied"
+// @Failure 500 {object} map[string]interface{} "Failed to fetch fleet units"
+// @Router /owner/fleet-units [get]
+// @Security BearerAuth
+func GetFleetUnits(c echo.Context) error {
+	query := database.DB.Order("car_id, fleet_unit_id")
+	if carID := c.QueryParam("car_id"); carID != "" {
+		query = query.Where("car_id = ?", carID)
+	}
+
+	var units []models.FleetUnit
+	if err := query.Find(&units).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": "Failed to fetch fleet units",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, units)
+}
+
+// @Summary Add a fleet unit
+// @Description Register a physical unit (license plate, VIN) of a car and refresh the car stock
+// @Tags Role Owner
+// @Accept json
+// @Produce json
+// @Param unitReq body FleetUnitRequest true "Fleet unit details"
+// @Success 201 {object} models.FleetUnit "Created fleet unit"
+// @Failure 400 {object} map[string]interface{} "Invalid request format or validation error"
+// @Failure 403 {object} map[string]interface{} "Permission denied"
+// @Failure 404 {object} map[string]interface{} "User or car not found"
+// @Failure 500 {object} map[string]interface{} "Failed to create fleet unit"
+// @Router /owner/fleet-units [post]
+// @Security BearerAuth
+func CreateFleetUnit(c echo.Context) error {
+	var unitReq FleetUnitRequest
+
+	// Bind the request body to FleetUnitRequest struct
+	if err := c.Bind(&unitReq); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Invalid request format",
+			"error":   err.Error(),
+		})
+	}
+
+	// Validate the request
+	if err := c.Validate(&unitReq); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Validation error",
+			"error":   err.Error(),
+		})
+	}
+
+	if _, err := getCarByID(unitReq.CarID); err != nil {
+		return c.JSON(http.StatusNotFound, echo.Map{
+			"message": "Car not found",
+			"error":   err.Error(),
+		})
+	}
+
+	unit := models.FleetUnit{
+		CarID:        unitReq.CarID,
+		LicensePlate: unitReq.LicensePlate,
+		VIN:          unitReq.VIN,
+		Colour:       unitReq.Colour,
+		Odometer:     unitReq.Odometer,
+		Status:       "available",
+	}
+	if unitReq.Status != "" {
+		unit.Status = unitReq.Status
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&unit).Error; err != nil {
+			return err
+		}
+		if err := services.SyncCarStock(tx, unit.CarID); err != nil {
+			return err
+		}
+
+		return services.RecordAudit(tx, auditActor(c), services.AuditEntry{
+			Action:     services.AuditFleetUnitCreate,
+			EntityType: services.AuditEntityFleetUnit,
+			EntityID:   auditID(unit.FleetUnitID),
+			After:      unit,
+		})
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": "Failed to create fleet unit",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusCreated, unit)
+}
+
+// @Summary Update a fleet unit
+// @Description Update the plate, colour, odometer or status of a fleet unit. Rented units can only change status through the rental flow.
+// @Tags Role Owner
+// @Accept json
+// @Produce json
+// @Param id path int true "Fleet unit ID"
+// @Param unitReq body FleetUnitUpdateRequest true "Fields to update"
+// @Success 200 {object} models.FleetUnit "Updated fleet unit"
+// @Failure 400 {object} map[string]interface{} "Invalid request format, validation error, or unit is rented"
+// @Failure 403 {object} map[string]interface{} "Permission denied"
+// @Failure 404 {object} map[string]interface{} "User or fleet unit not found"
+// @Failure 500 {object} map[string]interface{} "Failed to update fleet unit"
+// @Router /owner/fleet-units/{id} [put]
+// @Security BearerAuth
+func UpdateFleetUnit(c echo.Context) error {
+	unitID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Invalid fleet unit ID",
+			"error":   err.Error(),
+		})
+	}
+
+	var unitReq FleetUnitUpdateRequest
+
+	// Bind the request body to FleetUnitUpdateRequest struct
+	if err := c.Bind(&unitReq); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Invalid request format",
+			"error":   err.Error(),
+		})
+	}
+
+	// Validate the request
+	if err := c.Validate(&unitReq); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Validation error",
+			"error":   err.Error(),
+		})
+	}
+
+	var unit models.FleetUnit
+	if err := database.DB.First(&unit, unitID).Error; err != nil {
+		return c.JSON(http.StatusNotFound, echo.Map{
+			"message": "Fleet unit not found",
+			"error":   err.Error(),
+		})
+	}
+
+	// A unit that is out with a customer is released by the rental flow, not by hand
+	onRent, err := services.FleetUnitOnRent(database.DB, unit.FleetUnitID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": "Failed to check the rentals of the fleet unit",
+			"error":   err.Error(),
+		})
+	}
+	if onRent && unitReq.Status != "" && unitReq.Status != unit.Status {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Fleet unit is currently rented and cannot change status",
+		})
+	}
+
+	before := unit
+	if unitReq.LicensePlate != "" {
+		unit.LicensePlate = unitReq.LicensePlate
+	}
+	if unitReq.Colour != "" {
+		unit.Colour = unitReq.Colour
+	}
+	if unitReq.Odometer != nil {
+		unit.Odometer = *unitReq.Odometer
+	}
+	if unitReq.Status != "" {
+		unit.Status = unitReq.Status
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&unit).Error; err != nil {
+			return err
+		}
+		if err := services.SyncCarStock(tx, unit.CarID); err != nil {
+			return err
+		}
+
+		return services.RecordAudit(tx, auditActor(c), services.AuditEntry{
+			Action:     services.AuditFleetUnitUpdate,
+			EntityType: services.AuditEntityFleetUnit,
+			EntityID:   auditID(unit.FleetUnitID),
+			Before:     before,
+			After:      unit,
+		})
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": "Failed to update fleet unit",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, unit)
+}
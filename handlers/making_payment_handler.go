@@ -1,14 +1,16 @@
 package handlers
 
 import (
-	"fmt"
+	"errors"
 	"net/http"
 
 	"jakarta-luxury-rent-car/database"
 	"jakarta-luxury-rent-car/models"
+	"jakarta-luxury-rent-car/services"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
 )
 
 // PaymentRequest is the struct for the incoming JSON request body
@@ -17,14 +19,15 @@ type PaymentRequest struct {
 }
 
 // @Summary MakingPayment updates status from "Book" to "Paid"
-// @Description MakingPayment updates status from "Book" to "Paid"
+// @Description MakingPayment pays a booking from the deposit and updates status from "Book" to "Paid". Bookings paid through the Xendit invoice are marked "Paid" by the payment callback instead.
 // @Tags Role User
 // @Accept json
 // @Produce json
 // @Param paymentReq body PaymentRequest true "Payment request body containing rental ID and payment details"
 // @Success 200 {object} map[string]string "Rental status updated to 'Paid' successfully"
-// @Failure 400 {object} map[string]string "Invalid request body or rental status is not 'Book'"
-// @Failure 404 {object} map[string]string "Rental history or user not found"
+// @Failure 400 {object} map[string]string "Invalid request body or insufficient deposit"
+// @Failure 404 {object} map[string]string "Rental history or owner not found"
+// @Failure 409 {object} map[string]string "Rental status is not 'Book', cannot proceed to 'Paid'"
 // @Failure 500 {object} map[string]string "Failed to update rental status or deposit amount"
 // @Router /users/making-payment [post]
 // @Security BearerAuth
@@ -50,41 +53,52 @@ func MakingPayment(c echo.Context) error {
 		})
 	}
 
-	// Check if the current status is "Book"
-	if rentalHistory.Status != "Book" {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Rental status is not 'Book', cannot proceed to 'Paid'",
+	// Move the rental to "Paid" and debit the deposit in one transaction
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		from := rentalHistory.Status
+		if err := services.TransitionRental(tx, &rentalHistory, services.RentalStatusPaid, services.CustomerActor(userID), "Paid from deposit"); err != nil {
+			return err
+		}
+
+		transaction, err := services.PostWalletTransaction(tx, services.WalletEntry{
+			UserID:      userID,
+			Type:        services.WalletRentalPayment,
+			Amount:      rentalHistory.TotalCost,
+			RentalID:    &rentalHistory.RentalID,
+			Description: "Rental payment",
 		})
-	}
-
-	// Update the status to "Paid"
-	rentalHistory.Status = "Paid"
-	if err := database.DB.Save(&rentalHistory).Error; err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Failed to update rental status",
+		if err != nil {
+			return err
+		}
+
+		return services.RecordAudit(tx, auditActor(c), services.AuditEntry{
+			Action:     services.AuditRentalPay,
+			EntityType: services.AuditEntityRental,
+			EntityID:   auditID(rentalHistory.RentalID),
+			Before:     echo.Map{"status": from, "deposit_amount": services.BalanceBefore(transaction)},
+			After: echo.Map{
+				"status":                rentalHistory.Status,
+				"deposit_amount":        transaction.BalanceAfter,
+				"wallet_transaction_id": transaction.WalletTransactionID,
+			},
 		})
-	}
-
-	var userModel models.User
-	if err := database.DB.Where("user_id = ?", userID).First(&userModel).Error; err != nil {
-		return c.JSON(http.StatusNotFound, echo.Map{
-			"message": "User not found",
+	})
+	if errors.Is(err, services.ErrInsufficientFunds) {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Insufficient deposit, please top up first",
 			"error":   err.Error(),
 		})
 	}
-
-	// update data models.User terhadap DepositAmount
-	currentAmount := userModel.DepositAmount - rentalHistory.TotalCost
-	userModel.DepositAmount = currentAmount
-
-	// Save the updated user model back to the database
-	if err := database.DB.Save(&userModel).Error; err != nil {
-		return c.JSON(http.StatusInternalServerError, echo.Map{
-			"message": "Failed to update deposit amount",
+	if err != nil {
+		return c.JSON(rentalTransitionStatusCode(err), echo.Map{
+			"message": "Failed to pay rental",
 			"error":   err.Error(),
 		})
 	}
 
+	// The booking invoice can no longer be paid on top of the deposit payment
+	expirePendingInvoices(rentalHistory.RentalID)
+
 	var userOwnerModel models.User
 	if err := database.DB.Where("role = ?", "owner").First(&userOwnerModel).Error; err != nil {
 		return c.JSON(http.StatusNotFound, echo.Map{
@@ -94,16 +108,7 @@ func MakingPayment(c echo.Context) error {
 	}
 
 	// Send To Owner
-	toPhoneNumberOwner := fmt.Sprintf("whatsapp:+%s", userOwnerModel.PhoneNumber)
-
-	messageBodyOwner := fmt.Sprintf(
-		"Dear %s - %s,\n\nPayment for Rental ID - %d has been successfully completed!, please approve the process",
-		userOwnerModel.Email,
-		userOwnerModel.Role,
-		rentalHistory.RentalID,
-	)
-
-	sendWhatsAppNotification(toPhoneNumberOwner, messageBodyOwner)
+	notifyUser(userOwnerModel, services.EventPaymentReceivedOwner, echo.Map{"Rental": rentalHistory})
 
 	// Return a success response
 	return c.JSON(http.StatusOK, map[string]string{
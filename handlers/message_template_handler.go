@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"jakarta-luxury-rent-car/database"
+	"jakarta-luxury-rent-car/models"
+	"jakarta-luxury-rent-car/services"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// MessageTemplateRequest struct to capture an owner's version of a message template
+type MessageTemplateRequest struct {
+	Subject string `json:"subject" validate:"required"`
+	Body    string `json:"body" validate:"required"`
+}
+
+// @Summary List message templates
+// @Description List the subject and body template of every notification event per locale, with the owner's overrides applied
+// @Tags Role Owner
+// @Accept json
+// @Produce json
+// @Success 200 {array} services.TemplateSource "Message templates"
+// @Failure 403 {object} map[string]interface{} "Permission denied"
+// @Failure 500 {object} map[string]interface{} "Failed to load message templates"
+// @Router /owner/templates [get]
+// @Security BearerAuth
+func GetMessageTemplates(c echo.Context) error {
+	var templates []services.TemplateSource
+	for _, event := range services.MessageEvents {
+		for _, locale := range services.MessageLocales {
+			source, err := services.LoadTemplate(database.DB, event, locale)
+			if err != nil {
+				return c.JSON(http.StatusInternalServerError, echo.Map{
+					"message": "Failed to load message templates",
+					"error":   err.Error(),
+				})
+			}
+			templates = append(templates, source)
+		}
+	}
+
+	return c.JSON(http.StatusOK, templates)
+}
+
+// @Summary Update a message template
+// @Description Replace the subject and body of a notification in one locale. Templates use Go text/template syntax with the money, date and percent helpers, and are checked against sample data before saving.
+// @Tags Role Owner
+// @Accept json
+// @Produce json
+// @Param event path string true "Event, e.g. booking_confirmation"
+// @Param locale path string true "Locale (id or en)"
+// @Param templateReq body MessageTemplateRequest true "Subject and body templates"
+// @Success 200 {object} map[string]interface{} "Saved template with a preview rendered from sample data"
+// @Failure 400 {object} map[string]interface{} "Invalid request format, validation error, or template does not render"
+// @Failure 403 {object} map[string]interface{} "Permission denied"
+// @Failure 404 {object} map[string]interface{} "User or template not found"
+// @Failure 500 {object} map[string]interface{} "Failed to save message template"
+// @Router /owner/templates/{event}/{locale} [put]
+// @Security BearerAuth
+func UpdateMessageTemplate(c echo.Context) error {
+	var templateReq MessageTemplateRequest
+
+	// Bind the request body to MessageTemplateRequest struct
+	if err := c.Bind(&templateReq); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Invalid request format",
+			"error":   err.Error(),
+		})
+	}
+
+	// Validate the request
+	if err := c.Validate(&templateReq); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Validation error",
+			"error":   err.Error(),
+		})
+	}
+
+	// Extract user ID from JWT token
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*jwt.MapClaims)
+	userID := uint((*claims)["user_id"].(float64))
+
+	// Fetch user role from the database
+	var userModel models.User
+	if err := database.DB.First(&userModel, userID).Error; err != nil {
+		return c.JSON(http.StatusNotFound, echo.Map{
+			"message": "User not found",
+			"error":   err.Error(),
+		})
+	}
+
+	event, locale := c.Param("event"), c.Param("locale")
+	if _, err := services.DefaultTemplate(event, locale); err != nil {
+		return c.JSON(http.StatusNotFound, echo.Map{
+			"message": "Message template not found",
+			"error":   err.Error(),
+		})
+	}
+
+	preview, err := services.ValidateTemplate(locale, templateReq.Subject, templateReq.Body)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Template does not render",
+			"error":   err.Error(),
+		})
+	}
+
+	override := models.MessageTemplate{
+		Event:     event,
+		Locale:    locale,
+		Subject:   templateReq.Subject,
+		Body:      templateReq.Body,
+		UpdatedBy: userModel.UserID,
+		UpdatedAt: time.Now(),
+	}
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var before *models.MessageTemplate
+		var current models.MessageTemplate
+		if result := tx.Where("event = ? AND locale = ?", event, locale).Limit(1).Find(&current); result.Error != nil {
+			return result.Error
+		} else if result.RowsAffected > 0 {
+			before = &current
+		}
+
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "event"}, {Name: "locale"}},
+			DoUpdates: clause.AssignmentColumns([]string{"subject", "body", "updated_by", "updated_at"}),
+		}).Create(&override).Error; err != nil {
+			return err
+		}
+
+		return services.RecordAudit(tx, auditActor(c), services.AuditEntry{
+			Action:     services.AuditTemplateUpdate,
+			EntityType: services.AuditEntityTemplate,
+			EntityID:   event + "/" + locale,
+			Before:     before,
+			After:      override,
+		})
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": "Failed to save message template",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"message": "Message template saved",
+		"data":    override,
+		"preview": preview,
+	})
+}
+
+// @Summary Reset a message template
+// @Description Remove the owner's version of a notification so the bundled default is used again
+// @Tags Role Owner
+// @Accept json
+// @Produce json
+// @Param event path string true "Event, e.g. booking_confirmation"
+// @Param locale path string true "Locale (id or en)"
+// @Success 200 {object} map[string]interface{} "The default template now in use"
+// @Failure 403 {object} map[string]interface{} "Permission denied"
+// @Failure 404 {object} map[string]interface{} "User or template not found"
+// @Failure 500 {object} map[string]interface{} "Failed to reset message template"
+// @Router /owner/templates/{event}/{locale} [delete]
+// @Security BearerAuth
+func ResetMessageTemplate(c echo.Context) error {
+	event, locale := c.Param("event"), c.Param("locale")
+	source, err := services.DefaultTemplate(event, locale)
+	if errors.Is(err, services.ErrUnknownTemplate) {
+		return c.JSON(http.StatusNotFound, echo.Map{
+			"message": "Message template not found",
+			"error":   err.Error(),
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": "Failed to reset message template",
+			"error":   err.Error(),
+		})
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var override models.MessageTemplate
+		result := tx.Where("event = ? AND locale = ?", event, locale).Limit(1).Find(&override)
+		if result.Error != nil || result.RowsAffected == 0 {
+			// Nothing to reset, the default is already in use
+			return result.Error
+		}
+
+		if err := tx.Delete(&override).Error; err != nil {
+			return err
+		}
+
+		return services.RecordAudit(tx, auditActor(c), services.AuditEntry{
+			Action:     services.AuditTemplateReset,
+			EntityType: services.AuditEntityTemplate,
+			EntityID:   event + "/" + locale,
+			Before:     override,
+		})
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": "Failed to reset message template",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"message": "Message template reset to default",
+		"data":    source,
+	})
+}
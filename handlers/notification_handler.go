@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"jakarta-luxury-rent-car/database"
+	"jakarta-luxury-rent-car/models"
+	"jakarta-luxury-rent-car/services"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+)
+
+// NotificationPreferenceRequest struct to capture how a user wants to be notified, both fields are optional
+type NotificationPreferenceRequest struct {
+	PreferredChannel  string `json:"preferred_channel" validate:"omitempty,oneof=whatsapp email sms"` // The log channel is a development-only fallback and cannot be chosen
+	PreferredLanguage string `json:"preferred_language" validate:"omitempty,oneof=id en"`
+}
+
+// notificationService delivers every customer and owner notification, configured by main
+var notificationService *services.NotificationService
+
+// SetNotificationService sets the service handlers send notifications through
+func SetNotificationService(service *services.NotificationService) {
+	notificationService = service
+}
+
+// notifyUser renders the message of an event in the user's language and sends it over their
+// preferred channel, falling back to the other configured channels. data is passed to the template
+// together with the user as "User". Failures are logged and returned for handlers that need to report them.
+func notifyUser(user models.User, event string, data echo.Map) error {
+	if data == nil {
+		data = echo.Map{}
+	}
+	data["User"] = user
+
+	msg, err := services.RenderMessage(database.DB, event, user.PreferredLanguage, data)
+	if err != nil {
+		log.Printf("Failed to render %s for user %d: %v", event, user.UserID, err)
+		return err
+	}
+
+	recipient := services.Recipient{Email: user.Email, PhoneNumber: user.PhoneNumber}
+	if _, err := notificationService.Send(recipient, user.PreferredChannel, msg); err != nil {
+		log.Printf("Failed to notify user %d: %v", user.UserID, err)
+		return err
+	}
+	return nil
+}
+
+// @Summary Set notification preferences
+// @Description Choose the channel notifications are sent over first (whatsapp, email or sms) and their language (id or en). The other configured channels are used as fallbacks.
+// @Tags Role User
+// @Accept json
+// @Produce json
+// @Param preferenceReq body NotificationPreferenceRequest true "Preferred notification channel and language"
+// @Success 200 {object} map[string]interface{} "Saved preferences and the configured channels in fallback order"
+// @Failure 400 {object} map[string]interface{} "Invalid request format or validation error"
+// @Failure 404 {object} map[string]interface{} "User not found"
+// @Failure 500 {object} map[string]interface{} "Failed to save preference"
+// @Router /users/notification-preferences [put]
+// @Security BearerAuth
+func UpdateNotificationPreference(c echo.Context) error {
+	var preferenceReq NotificationPreferenceRequest
+
+	// Bind the request body to NotificationPreferenceRequest struct
+	if err := c.Bind(&preferenceReq); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Invalid request format",
+			"error":   err.Error(),
+		})
+	}
+
+	// Validate the request
+	if err := c.Validate(&preferenceReq); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Validation error",
+			"error":   err.Error(),
+		})
+	}
+
+	// Extract user ID from JWT token
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*jwt.MapClaims)
+	userID := uint((*claims)["user_id"].(float64))
+
+	var userModel models.User
+	if err := database.DB.First(&userModel, userID).Error; err != nil {
+		return c.JSON(http.StatusNotFound, echo.Map{
+			"message": "User not found",
+			"error":   err.Error(),
+		})
+	}
+
+	if preferenceReq.PreferredChannel != "" {
+		userModel.PreferredChannel = preferenceReq.PreferredChannel
+	}
+	if preferenceReq.PreferredLanguage != "" {
+		userModel.PreferredLanguage = preferenceReq.PreferredLanguage
+	}
+
+	if err := database.DB.Model(&userModel).Updates(map[string]interface{}{
+		"preferred_channel":  userModel.PreferredChannel,
+		"preferred_language": userModel.PreferredLanguage,
+	}).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": "Failed to save preference",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"message":            "Notification preference saved",
+		"preferred_channel":  userModel.PreferredChannel,
+		"preferred_language": userModel.PreferredLanguage,
+		"available_channels": notificationService.Channels(),
+	})
+}
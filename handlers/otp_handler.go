@@ -0,0 +1,478 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"jakarta-luxury-rent-car/database"
+	"jakarta-luxury-rent-car/models"
+	"jakarta-luxury-rent-car/services"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// VerifyPhoneRequest struct to capture the code sent to the user's phone
+type VerifyPhoneRequest struct {
+	Code string `json:"code" validate:"required,len=6,numeric"`
+}
+
+// ForgotPasswordRequest struct to capture the account that forgot its password
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// ResetPasswordRequest struct to capture the reset code and the new password
+type ResetPasswordRequest struct {
+	Email    string `json:"email" validate:"required,email"`
+	Code     string `json:"code" validate:"required,len=6,numeric"`
+	Password string `json:"password" validate:"required"` // Checked against the password policy
+}
+
+// LoginOTPRequest struct to capture the second factor of a login
+type LoginOTPRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required,len=6,numeric"`
+}
+
+// TwoFactorRequest struct to turn the login second factor on or off
+type TwoFactorRequest struct {
+	Enabled  bool   `json:"enabled"`
+	Password string `json:"password"` // Required to turn it off
+}
+
+// sendOTP issues a one-time code and sends it to the user's phone over WhatsApp or SMS only,
+// never over the other notification channels (see services.OTPChannels)
+func sendOTP(user models.User, purpose, challengeHash string) error {
+	code, err := services.IssueOTP(database.DB, user.UserID, purpose, challengeHash, time.Now())
+	if err != nil {
+		return err
+	}
+
+	msg, err := services.RenderMessage(database.DB, services.EventOTPCode, user.PreferredLanguage, echo.Map{
+		"User":             user,
+		"Code":             code,
+		"Purpose":          purpose,
+		"ExpiresInMinutes": int(services.OTPTTL.Minutes()),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = notificationService.SendOnly(services.Recipient{PhoneNumber: user.PhoneNumber}, services.OTPChannels, msg)
+	return err
+}
+
+// otpError responds to an error of issuing or verifying a one-time code
+func otpError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, services.ErrOTPInvalid), errors.Is(err, services.ErrOTPExpired):
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Invalid code",
+			"error":   err.Error(),
+		})
+	case errors.Is(err, services.ErrOTPTooManyAttempts), errors.Is(err, services.ErrOTPResendTooSoon), errors.Is(err, services.ErrOTPQuotaExceeded):
+		return c.JSON(http.StatusTooManyRequests, echo.Map{
+			"message": "Too many requests",
+			"error":   err.Error(),
+		})
+	default:
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": "Failed to send or check code",
+			"error":   err.Error(),
+		})
+	}
+}
+
+// @Summary Send a phone verification code
+// @Description Send a one-time code over WhatsApp to the phone number of the account. A code is also sent right after registration.
+// @Tags Role User
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Code sent"
+// @Failure 404 {object} map[string]interface{} "User not found"
+// @Failure 409 {object} map[string]interface{} "Phone number already verified"
+// @Failure 429 {object} map[string]interface{} "A code was sent less than a minute ago"
+// @Failure 500 {object} map[string]interface{} "Failed to send code"
+// @Router /users/phone/verification-code [post]
+// @Security BearerAuth
+func SendPhoneVerificationCode(c echo.Context) error {
+	// Extract user ID from JWT token
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*jwt.MapClaims)
+	userID := uint((*claims)["user_id"].(float64))
+
+	var userModel models.User
+	if err := database.DB.First(&userModel, userID).Error; err != nil {
+		return c.JSON(http.StatusNotFound, echo.Map{
+			"message": "User not found",
+			"error":   err.Error(),
+		})
+	}
+
+	if userModel.PhoneVerifiedAt != nil {
+		return c.JSON(http.StatusConflict, echo.Map{
+			"message": "Phone number already verified",
+		})
+	}
+
+	if err := sendOTP(userModel, services.OTPPhoneVerification, ""); err != nil {
+		return otpError(c, err)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"message": "Verification code sent",
+	})
+}
+
+// @Summary Verify phone number
+// @Description Verify the phone number of the account with the code sent over WhatsApp
+// @Tags Role User
+// @Accept json
+// @Produce json
+// @Param verifyReq body VerifyPhoneRequest true "Code sent to the phone"
+// @Success 200 {object} map[string]interface{} "Phone number verified"
+// @Failure 400 {object} map[string]interface{} "Invalid request format, validation error, or invalid or expired code"
+// @Failure 429 {object} map[string]interface{} "Too many wrong codes"
+// @Failure 500 {object} map[string]interface{} "Failed to verify phone number"
+// @Router /users/phone/verify [post]
+// @Security BearerAuth
+func VerifyPhone(c echo.Context) error {
+	var verifyReq VerifyPhoneRequest
+
+	// Bind the request body to VerifyPhoneRequest struct
+	if err := c.Bind(&verifyReq); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Invalid request format",
+			"error":   err.Error(),
+		})
+	}
+
+	// Validate the request
+	if err := c.Validate(&verifyReq); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Validation error",
+			"error":   err.Error(),
+		})
+	}
+
+	// Extract user ID from JWT token
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*jwt.MapClaims)
+	userID := uint((*claims)["user_id"].(float64))
+
+	now := time.Now()
+	if err := services.VerifyOTP(database.DB, userID, services.OTPPhoneVerification, verifyReq.Code, now); err != nil {
+		return otpError(c, err)
+	}
+
+	if err := database.DB.Model(&models.User{}).Where("user_id = ?", userID).Update("phone_verified_at", now).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": "Failed to verify phone number",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"message": "Phone number verified",
+	})
+}
+
+// @Summary Forgot password
+// @Description Send a password reset code over WhatsApp to the verified phone number of the account. The response is the same whether or not the account exists.
+// @Tags Register and Login
+// @Accept json
+// @Produce json
+// @Param forgotReq body ForgotPasswordRequest true "Email of the account"
+// @Success 200 {object} map[string]interface{} "Code sent if the account exists and its phone number is verified"
+// @Failure 400 {object} map[string]interface{} "Invalid request format or validation error"
+// @Router /password/forgot [post]
+func ForgotPassword(c echo.Context) error {
+	var forgotReq ForgotPasswordRequest
+
+	// Bind the request body to ForgotPasswordRequest struct
+	if err := c.Bind(&forgotReq); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Invalid request format",
+			"error":   err.Error(),
+		})
+	}
+
+	// Validate the request
+	if err := c.Validate(&forgotReq); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Validation error",
+			"error":   err.Error(),
+		})
+	}
+
+	// Only verified phones get a code, and failures are not reported so accounts cannot be enumerated
+	var user models.User
+	if err := database.DB.Where("email = ?", forgotReq.Email).First(&user).Error; err == nil &&
+		user.PhoneVerifiedAt != nil && user.SuspendedAt == nil {
+		if err := sendOTP(user, services.OTPPasswordReset, ""); err != nil {
+			log.Printf("Failed to send password reset code to user %d: %v", user.UserID, err)
+		}
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"message": "If the account exists and its phone number is verified, a reset code has been sent over WhatsApp",
+	})
+}
+
+// @Summary Reset password
+// @Description Set a new password with the code from /password/forgot. Every session of the account is logged out and a failed login lockout is lifted.
+// @Tags Register and Login
+// @Accept json
+// @Produce json
+// @Param resetReq body ResetPasswordRequest true "Email, reset code and new password"
+// @Success 200 {object} map[string]interface{} "Password changed"
+// @Failure 400 {object} map[string]interface{} "Invalid request format, validation error, password does not meet the password policy, or invalid or expired code"
+// @Failure 429 {object} map[string]interface{} "Too many wrong codes"
+// @Failure 500 {object} map[string]interface{} "Failed to reset password"
+// @Router /password/reset [post]
+func ResetPassword(c echo.Context) error {
+	var resetReq ResetPasswordRequest
+
+	// Bind the request body to ResetPasswordRequest struct
+	if err := c.Bind(&resetReq); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Invalid request format",
+			"error":   err.Error(),
+		})
+	}
+
+	// Validate the request
+	if err := c.Validate(&resetReq); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Validation error",
+			"error":   err.Error(),
+		})
+	}
+
+	var user models.User
+	if err := database.DB.Where("email = ?", resetReq.Email).First(&user).Error; err != nil {
+		return otpError(c, services.ErrOTPInvalid)
+	}
+
+	if err := passwordPolicy.Validate(resetReq.Password, user.Email); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Validation error",
+			"error":   err.Error(),
+		})
+	}
+
+	now := time.Now()
+	if err := services.VerifyOTP(database.DB, user.UserID, services.OTPPasswordReset, resetReq.Code, now); err != nil {
+		return otpError(c, err)
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(resetReq.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": "Failed to hash password",
+			"error":   err.Error(),
+		})
+	}
+
+	// Whoever knew the old password is logged out, and a lockout from their guesses is lifted
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"password":              string(hashedPassword),
+			"failed_login_attempts": 0,
+			"locked_until":          nil,
+		}).Error; err != nil {
+			return err
+		}
+		return services.RevokeUserSessions(tx, user.UserID, now)
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": "Failed to reset password",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"message": "Password changed, please log in again",
+	})
+}
+
+// @Summary Login second factor
+// @Description Finish the login of an account with two-factor login by sending the code it received over WhatsApp
+// @Tags Register and Login
+// @Accept json
+// @Produce json
+// @Param otpReq body LoginOTPRequest true "Challenge token from /login and the code"
+// @Success 200 {object} UserResponse
+// @Failure 400 {object} map[string]interface{} "Invalid request format, validation error, or invalid or expired code"
+// @Failure 403 {object} map[string]interface{} "Account suspended"
+// @Failure 429 {object} map[string]interface{} "Too many wrong codes"
+// @Failure 500 {object} map[string]interface{} "Failed to generate token"
+// @Router /login/otp [post]
+func LoginOTP(c echo.Context) error {
+	var otpReq LoginOTPRequest
+
+	// Bind the request body to LoginOTPRequest struct
+	if err := c.Bind(&otpReq); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Invalid request format",
+			"error":   err.Error(),
+		})
+	}
+
+	// Validate the request
+	if err := c.Validate(&otpReq); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Validation error",
+			"error":   err.Error(),
+		})
+	}
+
+	now := time.Now()
+	userID, err := services.VerifyLoginOTP(database.DB, otpReq.ChallengeToken, otpReq.Code, now)
+	if err != nil {
+		return otpError(c, err)
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, userID).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": "Failed to generate token",
+			"error":   err.Error(),
+		})
+	}
+
+	if user.SuspendedAt != nil {
+		return c.JSON(http.StatusForbidden, echo.Map{
+			"message": "Account suspended",
+		})
+	}
+
+	session, err := services.StartSession(database.DB, user.UserID, "", now, tokenConfig)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": "Failed to generate token",
+			"error":   err.Error(),
+		})
+	}
+
+	response, err := sessionResponse(user, session)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": "Failed to generate token",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// @Summary Turn two-factor login on or off
+// @Description With two-factor login, /login also asks for a code sent over WhatsApp. Only owner and admin accounts with a verified phone number can turn it on, and turning it off needs the current password. Every other session of the account is logged out after a change.
+// @Tags Role Owner
+// @Accept json
+// @Produce json
+// @Param twoFactorReq body TwoFactorRequest true "Whether two-factor login is on"
+// @Success 200 {object} map[string]interface{} "Two-factor login setting"
+// @Failure 400 {object} map[string]interface{} "Invalid request format or phone number not verified"
+// @Failure 401 {object} map[string]interface{} "Invalid password"
+// @Failure 403 {object} map[string]interface{} "Two-factor login is for owner and admin accounts"
+// @Failure 404 {object} map[string]interface{} "User not found"
+// @Failure 500 {object} map[string]interface{} "Failed to save setting"
+// @Router /users/two-factor [put]
+// @Security BearerAuth
+func UpdateTwoFactor(c echo.Context) error {
+	var twoFactorReq TwoFactorRequest
+
+	// Bind the request body to TwoFactorRequest struct
+	if err := c.Bind(&twoFactorReq); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Invalid request format",
+			"error":   err.Error(),
+		})
+	}
+
+	// Extract user ID from JWT token
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*jwt.MapClaims)
+	userID := uint((*claims)["user_id"].(float64))
+	familyID, _ := (*claims)["sid"].(string)
+
+	var userModel models.User
+	if err := database.DB.First(&userModel, userID).Error; err != nil {
+		return c.JSON(http.StatusNotFound, echo.Map{
+			"message": "User not found",
+			"error":   err.Error(),
+		})
+	}
+
+	// A stolen access token alone must not be enough to drop the second factor.
+	// Wrong passwords count towards the login lockout, so they cannot be guessed here either.
+	now := time.Now()
+	if !twoFactorReq.Enabled && userModel.TwoFactorEnabled {
+		if services.IsLocked(userModel, now) {
+			return c.JSON(http.StatusUnauthorized, echo.Map{"error": "Invalid password"})
+		}
+		if err := bcrypt.CompareHashAndPassword([]byte(userModel.Password), []byte(twoFactorReq.Password)); err != nil {
+			lockedUntil, err := services.RecordLoginFailure(database.DB, userModel.UserID, now)
+			if err != nil {
+				log.Printf("Failed to record failed password check of user %d: %v", userModel.UserID, err)
+			}
+			if lockedUntil != nil {
+				notifyUser(userModel, services.EventAccountLocked, echo.Map{"LockedUntil": *lockedUntil})
+			}
+			return c.JSON(http.StatusUnauthorized, echo.Map{"error": "Invalid password"})
+		}
+	}
+
+	if twoFactorReq.Enabled {
+		switch err := services.CanUseTwoFactor(userModel); {
+		case errors.Is(err, services.ErrTwoFactorNotAllowed):
+			return c.JSON(http.StatusForbidden, echo.Map{
+				"message": "Permission denied",
+				"error":   err.Error(),
+			})
+		case err != nil:
+			return c.JSON(http.StatusBadRequest, echo.Map{
+				"message": "Verify your phone number first",
+				"error":   err.Error(),
+			})
+		}
+	}
+
+	// Sessions opened before the change keep only this one, so whoever else held a token has to log in again
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if twoFactorReq.Enabled == userModel.TwoFactorEnabled {
+			return nil
+		}
+		if err := tx.Model(&userModel).Update("two_factor_enabled", twoFactorReq.Enabled).Error; err != nil {
+			return err
+		}
+		if err := services.RevokeOtherSessions(tx, userModel.UserID, familyID, now); err != nil {
+			return err
+		}
+
+		return services.RecordAudit(tx, auditActor(c), services.AuditEntry{
+			Action:     services.AuditUserTwoFactor,
+			EntityType: services.AuditEntityUser,
+			EntityID:   auditID(userModel.UserID),
+			Before:     echo.Map{"two_factor_enabled": !twoFactorReq.Enabled},
+			After:      echo.Map{"two_factor_enabled": twoFactorReq.Enabled},
+		})
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": "Failed to save setting",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"two_factor_enabled": twoFactorReq.Enabled,
+	})
+}
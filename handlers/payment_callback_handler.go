@@ -0,0 +1,276 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"os"
+	"time"
+
+	"jakarta-luxury-rent-car/database"
+	"jakarta-luxury-rent-car/models"
+	"jakarta-luxury-rent-car/services"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// XenditInvoiceCallbackRequest struct to capture the invoice callback sent by Xendit
+type XenditInvoiceCallbackRequest struct {
+	ID         string     `json:"id" validate:"required"`
+	ExternalID string     `json:"external_id" validate:"required"`
+	Status     string     `json:"status" validate:"required"`
+	Amount     float64    `json:"amount"`
+	PaidAmount float64    `json:"paid_amount"`
+	PaidAt     *time.Time `json:"paid_at"`
+}
+
+// @Summary Xendit invoice callback
+// @Description Called by Xendit when an invoice is paid or expires. The x-callback-token header must match XENDIT_CALLBACK_TOKEN and the reported status is confirmed with the payment gateway. A paid booking invoice marks the rental Paid, an expired one cancels a booking that is still unpaid. A paid top up invoice credits the deposit. A payment lower than the invoice is recorded as UNDERPAID and credited to the deposit. A booking invoice paid after the booking was cancelled or already paid is refunded. Replayed callbacks are acknowledged without changing anything.
+// @Tags Public
+// @Accept json
+// @Produce json
+// @Param x-callback-token header string true "Xendit callback verification token"
+// @Param callbackReq body XenditInvoiceCallbackRequest true "Xendit invoice callback"
+// @Success 200 {object} map[string]interface{} "Callback processed or already processed"
+// @Failure 400 {object} map[string]interface{} "Invalid request format or callback does not match the gateway"
+// @Failure 401 {object} map[string]interface{} "Invalid callback token"
+// @Failure 404 {object} map[string]interface{} "Invoice not found"
+// @Failure 500 {object} map[string]interface{} "Failed to process callback"
+// @Router /payments/xendit/callback [post]
+func XenditInvoiceCallback(c echo.Context) error {
+	if !validXenditCallbackToken(c) {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": "Invalid callback token",
+		})
+	}
+
+	var callbackReq XenditInvoiceCallbackRequest
+	if err := c.Bind(&callbackReq); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Invalid request format",
+			"error":   err.Error(),
+		})
+	}
+	if err := c.Validate(&callbackReq); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Validation error",
+			"error":   err.Error(),
+		})
+	}
+
+	// Confirm the reported status with the gateway instead of trusting the callback body alone
+	gatewayInvoice, err := paymentGateway.GetInvoice(callbackReq.ID)
+	if errors.Is(err, services.ErrGatewayInvoiceNotFound) {
+		return c.JSON(http.StatusNotFound, echo.Map{
+			"message": "Invoice not found",
+			"error":   err.Error(),
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": "Failed to verify invoice with the payment gateway",
+			"error":   err.Error(),
+		})
+	}
+	if gatewayInvoice.ExternalID != callbackReq.ExternalID || gatewayInvoice.Status != services.NormalizeInvoiceStatus(callbackReq.Status) {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Callback does not match the invoice at the payment gateway",
+		})
+	}
+
+	// Older callbacks only carry the invoice amount
+	paidAmount := callbackReq.PaidAmount
+	if paidAmount == 0 {
+		paidAmount = callbackReq.Amount
+	}
+
+	var result services.InvoiceCallbackResult
+	var refund models.Refund
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		result, err = services.ApplyInvoiceCallback(tx, services.InvoiceCallback{
+			ExternalID: callbackReq.ExternalID,
+			Status:     gatewayInvoice.Status,
+			PaidAmount: paidAmount,
+			PaidAt:     callbackReq.PaidAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		// Deposit credits are audited as actions of the system, coming from the gateway's address
+		if result.Invoice.Status == services.InvoiceStatusUnderpaid {
+			return services.RecordAudit(tx, auditActor(c), services.AuditEntry{
+				Action:     services.AuditInvoiceUnderpaid,
+				EntityType: services.AuditEntityInvoice,
+				EntityID:   result.Invoice.ExternalID,
+				Before:     echo.Map{"status": services.InvoiceStatusPending, "deposit_amount": services.BalanceBefore(result.Credit)},
+				After: echo.Map{
+					"status":                result.Invoice.Status,
+					"amount":                result.Invoice.Amount,
+					"paid_amount":           result.Invoice.PaidAmount,
+					"deposit_amount":        result.Credit.BalanceAfter,
+					"wallet_transaction_id": result.Credit.WalletTransactionID,
+				},
+			})
+		}
+		if result.Credit.WalletTransactionID != 0 {
+			err = services.RecordAudit(tx, auditActor(c), services.AuditEntry{
+				Action:     services.AuditTopUpSettle,
+				EntityType: services.AuditEntityTopUp,
+				EntityID:   auditID(result.TopUp.DepositTopUpID),
+				Before:     echo.Map{"deposit_amount": services.BalanceBefore(result.Credit)},
+				After: echo.Map{
+					"status":                result.TopUp.Status,
+					"user_id":               result.TopUp.UserID,
+					"deposit_amount":        result.Credit.BalanceAfter,
+					"wallet_transaction_id": result.Credit.WalletTransactionID,
+					"invoice":               result.Invoice.ExternalID,
+				},
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		// A booking paid after it was cancelled, paid twice, or paid on an invoice that a booking change
+		// replaced gets the invoice amount back
+		if result.Applied && result.Invoice.Purpose == services.InvoicePurposeBooking && result.Invoice.Status == services.InvoiceStatusPaid {
+			switch {
+			case result.Superseded:
+				refund, err = services.RequestRefund(tx, result.Rental, result.Invoice.Amount, "Payment received on a replaced invoice")
+			case result.From != services.RentalStatusBook:
+				refund, err = services.RequestRefund(tx, result.Rental, result.Invoice.Amount, "Payment received after the booking was "+result.From)
+			}
+		}
+
+		// A supplementary invoice paid after the booking was cancelled is not owed any more
+		if result.Applied && result.Invoice.Purpose == services.InvoicePurposeSupplement &&
+			result.Invoice.Status == services.InvoiceStatusPaid && result.From == services.RentalStatusCancel {
+			refund, err = services.RequestRefund(tx, result.Rental, result.Invoice.Amount, "Supplementary payment received after the booking was cancelled")
+		}
+		return err
+	})
+	switch {
+	case errors.Is(err, services.ErrUnknownInvoice):
+		return c.JSON(http.StatusNotFound, echo.Map{
+			"message": "Invoice not found",
+			"error":   err.Error(),
+		})
+	case err != nil:
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": "Failed to process callback",
+			"error":   err.Error(),
+		})
+	}
+
+	if !result.Applied {
+		return c.JSON(http.StatusOK, echo.Map{
+			"message": "Callback already processed",
+			"status":  result.Invoice.Status,
+		})
+	}
+
+	notifyInvoiceSettled(result)
+	if refund.RefundID != 0 {
+		processRefund(refund)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"message":       "Callback processed",
+		"status":        result.Invoice.Status,
+		"rental_status": result.Rental.Status,
+	})
+}
+
+// validXenditCallbackToken checks the x-callback-token header against XENDIT_CALLBACK_TOKEN
+func validXenditCallbackToken(c echo.Context) bool {
+	expectedToken := os.Getenv("XENDIT_CALLBACK_TOKEN")
+	token := c.Request().Header.Get("x-callback-token")
+
+	return expectedToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(expectedToken)) == 1
+}
+
+// notifyInvoiceSettled tells the customer, and the owner for paid bookings, what happened to the rental
+func notifyInvoiceSettled(result services.InvoiceCallbackResult) {
+	if result.Invoice.Status == services.InvoiceStatusUnderpaid {
+		notifyUnderpaid(result)
+		return
+	}
+	if result.Invoice.DepositTopUpID != nil {
+		notifyTopUpSettled(result)
+		return
+	}
+
+	var customer models.User
+	if err := database.DB.First(&customer, result.Rental.UserID).Error; err != nil {
+		return
+	}
+
+	event := ""
+	switch {
+	case result.Invoice.Status == services.InvoiceStatusPaid && result.Superseded:
+		event = services.EventPaymentReplacedInvoice
+	case result.Invoice.Status == services.InvoiceStatusPaid && result.Invoice.Purpose == services.InvoicePurposeBooking && result.From != services.RentalStatusBook,
+		result.Invoice.Status == services.InvoiceStatusPaid && result.Invoice.Purpose == services.InvoicePurposeSupplement && result.From == services.RentalStatusCancel:
+		// Paid after the booking was cancelled or already paid from the deposit
+		event = services.EventPaymentAfterClose
+	case result.Invoice.Status == services.InvoiceStatusPaid:
+		event = services.EventPaymentReceived
+	case result.From == services.RentalStatusBook && result.Rental.Status == services.RentalStatusExpired:
+		event = services.EventInvoiceExpired
+	default:
+		return
+	}
+
+	notifyUser(customer, event, echo.Map{
+		"Invoice": result.Invoice,
+		"Rental":  result.Rental,
+		"From":    result.From,
+	})
+
+	// The owner approves paid bookings, same as after a deposit payment
+	if result.From != services.RentalStatusBook || result.Rental.Status != services.RentalStatusPaid {
+		return
+	}
+
+	var owner models.User
+	if err := database.DB.Where("role = ?", "owner").First(&owner).Error; err != nil {
+		return
+	}
+
+	notifyUser(owner, services.EventPaymentReceivedOwner, echo.Map{"Rental": result.Rental})
+}
+
+// notifyTopUpSettled tells the customer their deposit has been credited
+func notifyTopUpSettled(result services.InvoiceCallbackResult) {
+	if result.TopUp.Status != services.InvoiceStatusPaid {
+		return
+	}
+
+	var customer models.User
+	if err := database.DB.First(&customer, result.TopUp.UserID).Error; err != nil {
+		return
+	}
+
+	notifyUser(customer, services.EventTopUpReceived, echo.Map{"TopUp": result.TopUp})
+}
+
+// notifyUnderpaid tells the customer their payment did not cover the invoice and went to their deposit
+func notifyUnderpaid(result services.InvoiceCallbackResult) {
+	userID := result.Rental.UserID
+	if result.Invoice.DepositTopUpID != nil {
+		userID = result.TopUp.UserID
+	}
+
+	var customer models.User
+	if err := database.DB.First(&customer, userID).Error; err != nil {
+		return
+	}
+
+	notifyUser(customer, services.EventPaymentUnderpaid, echo.Map{
+		"Invoice": result.Invoice,
+		"Rental":  result.Rental,
+	})
+}
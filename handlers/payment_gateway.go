@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"jakarta-luxury-rent-car/database"
+	"jakarta-luxury-rent-car/models"
+	"jakarta-luxury-rent-car/services"
+)
+
+// paymentGateway creates, checks and refunds invoices for the booking and payment handlers
+var paymentGateway services.PaymentGateway
+
+// SetPaymentGateway sets the gateway the handlers use. main wires in the configured gateway at startup,
+// and a nil gateway panics there rather than on the first payment.
+func SetPaymentGateway(gateway services.PaymentGateway) {
+	if gateway == nil {
+		panic("handlers: SetPaymentGateway called with a nil payment gateway")
+	}
+	paymentGateway = gateway
+}
+
+// expirePendingInvoices closes the unpaid invoices of a rental at the gateway so they can no longer be paid.
+// Invoices the gateway has already settled stay PENDING and are settled by their callback. The last
+// gateway error is returned so background callers can retry, handlers may ignore it.
+func expirePendingInvoices(rentalID uint) error {
+	var invoices []models.Invoice
+	if err := database.DB.Where("rental_id = ? AND status = ?", rentalID, services.InvoiceStatusPending).Find(&invoices).Error; err != nil {
+		return err
+	}
+
+	return expireInvoices(invoices)
+}
+
+// expireSupersededInvoices closes the unpaid invoices of a rental except its newest booking invoice,
+// after a booking change issued that one for the new total
+func expireSupersededInvoices(rentalID uint) error {
+	var current models.Invoice
+	if err := database.DB.Where("rental_id = ? AND purpose = ?", rentalID, services.InvoicePurposeBooking).
+		Order("invoice_id DESC").
+		First(&current).Error; err != nil {
+		return err
+	}
+
+	var invoices []models.Invoice
+	if err := database.DB.Where("rental_id = ? AND status = ? AND invoice_id <> ?", rentalID, services.InvoiceStatusPending, current.InvoiceID).
+		Find(&invoices).Error; err != nil {
+		return err
+	}
+
+	return expireInvoices(invoices)
+}
+
+func expireInvoices(invoices []models.Invoice) error {
+	var lastErr error
+	for _, invoice := range invoices {
+		if _, err := paymentGateway.ExpireInvoice(invoice.XenditInvoiceID); err != nil {
+			// Paid or expired in the meantime, its callback is on the way
+			if current, getErr := paymentGateway.GetInvoice(invoice.XenditInvoiceID); getErr == nil && services.NormalizeInvoiceStatus(current.Status) != services.InvoiceStatusPending {
+				continue
+			}
+			lastErr = err
+			continue
+		}
+		database.DB.Model(&models.Invoice{}).
+			Where("invoice_id = ? AND status = ?", invoice.InvoiceID, services.InvoiceStatusPending).
+			Update("status", services.InvoiceStatusExpired)
+	}
+
+	return lastErr
+}
@@ -0,0 +1,439 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"jakarta-luxury-rent-car/database"
+	"jakarta-luxury-rent-car/models"
+	"jakarta-luxury-rent-car/services"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PricingResponse struct to show the complete price list
+type PricingResponse struct {
+	Settings models.PricingSetting `json:"settings"`
+	CarRates []models.CarRate      `json:"car_rates"`
+	Addons   []models.AddonPrice   `json:"addons"`
+	Holidays []models.Holiday      `json:"holidays"`
+}
+
+// CarRateRequest struct to capture the rates of a car
+type CarRateRequest struct {
+	DailyRate   float64 `json:"daily_rate" validate:"required,gt=0"`
+	WeeklyRate  float64 `json:"weekly_rate" validate:"gte=0"`  // Optional, 0 means 7 x daily
+	MonthlyRate float64 `json:"monthly_rate" validate:"gte=0"` // Optional, 0 means 30 x daily
+}
+
+// AddonPriceRequest struct to capture the price of an add-on
+type AddonPriceRequest struct {
+	Price  float64 `json:"price" validate:"gte=0"`
+	PerDay bool    `json:"per_day"`
+}
+
+// PricingSettingsRequest struct to capture surcharges and rounding
+type PricingSettingsRequest struct {
+	WeekendSurchargePercent float64 `json:"weekend_surcharge_percent" validate:"gte=0,lte=500"`
+	HolidaySurchargePercent float64 `json:"holiday_surcharge_percent" validate:"gte=0,lte=500"`
+	Rounding                string  `json:"rounding" validate:"required,oneof=full_day hourly"`
+}
+
+// HolidayRequest struct to capture a holiday that carries the holiday surcharge
+type HolidayRequest struct {
+	Date string `json:"date" validate:"required,datetime=2006-01-02"`
+	Name string `json:"name" validate:"required"`
+}
+
+var addonCodes = map[string]bool{
+	services.AddonDriver:          true,
+	services.AddonPickupDropoff:   true,
+	services.AddonAirportTransfer: true,
+	services.AddonConcierge:       true,
+}
+
+// @Summary Get the price list
+// @Description Get car rates, add-on prices, surcharges, rounding rule and holidays. Cars without a rate use their rental cost; add-ons without a price use the defaults.
+// @Tags Role Owner
+// @Accept json
+// @Produce json
+// @Success 200 {object} PricingResponse "Current price list"
+// @Failure 403 {object} map[string]interface{} "Permission denied"
+// @Failure 500 {object} map[string]interface{} "Failed to load pricing"
+// @Router /owner/pricing [get]
+// @Security BearerAuth
+func GetPricing(c echo.Context) error {
+	config, err := services.LoadPricingConfig(database.DB)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": "Failed to load pricing",
+			"error":   err.Error(),
+		})
+	}
+
+	var cars []models.Car
+	if err := database.DB.Order("car_id").Find(&cars).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": "Failed to load pricing",
+			"error":   err.Error(),
+		})
+	}
+
+	var holidays []models.Holiday
+	if err := database.DB.Order("holiday_date").Find(&holidays).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": "Failed to load pricing",
+			"error":   err.Error(),
+		})
+	}
+
+	response := PricingResponse{Settings: config.Settings, Holidays: holidays}
+	for _, car := range cars {
+		response.CarRates = append(response.CarRates, config.CarRate(car))
+	}
+	for _, code := range []string{services.AddonDriver, services.AddonPickupDropoff, services.AddonAirportTransfer, services.AddonConcierge} {
+		response.Addons = append(response.Addons, config.Addon(code))
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// @Summary Set the rates of a car
+// @Description Set the daily, weekly and monthly rate of a car. The daily rate is also stored as the car rental cost.
+// @Tags Role Owner
+// @Accept json
+// @Produce json
+// @Param id path int true "Car ID"
+// @Param rateReq body CarRateRequest true "Car rates"
+// @Success 200 {object} models.CarRate "Updated car rates"
+// @Failure 400 {object} map[string]interface{} "Invalid request format or validation error"
+// @Failure 403 {object} map[string]interface{} "Permission denied"
+// @Failure 404 {object} map[string]interface{} "User or car not found"
+// @Failure 500 {object} map[string]interface{} "Failed to update car rates"
+// @Router /owner/pricing/cars/{id} [put]
+// @Security BearerAuth
+func UpdateCarRate(c echo.Context) error {
+	carID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Invalid car ID",
+			"error":   err.Error(),
+		})
+	}
+
+	var rateReq CarRateRequest
+	if err := c.Bind(&rateReq); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Invalid request format",
+			"error":   err.Error(),
+		})
+	}
+	if err := c.Validate(&rateReq); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Validation error",
+			"error":   err.Error(),
+		})
+	}
+
+	car, err := getCarByID(uint(carID))
+	if err != nil {
+		return c.JSON(http.StatusNotFound, echo.Map{
+			"message": "Car not found",
+			"error":   err.Error(),
+		})
+	}
+
+	rate := models.CarRate{
+		CarID:       car.CarID,
+		DailyRate:   rateReq.DailyRate,
+		WeeklyRate:  rateReq.WeeklyRate,
+		MonthlyRate: rateReq.MonthlyRate,
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var before *models.CarRate
+		var current models.CarRate
+		if result := tx.Where("car_id = ?", car.CarID).Limit(1).Find(&current); result.Error != nil {
+			return result.Error
+		} else if result.RowsAffected > 0 {
+			before = &current
+		}
+
+		if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&rate).Error; err != nil {
+			return err
+		}
+		// Keep the catalog price shown on /cars in line with the daily rate
+		if err := tx.Model(&car).Update("rental_costs", rate.DailyRate).Error; err != nil {
+			return err
+		}
+
+		return services.RecordAudit(tx, auditActor(c), services.AuditEntry{
+			Action:     services.AuditCarRateUpdate,
+			EntityType: services.AuditEntityCarRate,
+			EntityID:   auditID(car.CarID),
+			Before:     before,
+			After:      rate,
+		})
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": "Failed to update car rates",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, rate)
+}
+
+// @Summary Set the price of an add-on
+// @Description Set the price of driver, pickup_dropoff, airport_transfer or concierge, charged once or per rental day
+// @Tags Role Owner
+// @Accept json
+// @Produce json
+// @Param code path string true "Add-on code" Enums(driver, pickup_dropoff, airport_transfer, concierge)
+// @Param addonReq body AddonPriceRequest true "Add-on price"
+// @Success 200 {object} models.AddonPrice "Updated add-on price"
+// @Failure 400 {object} map[string]interface{} "Unknown add-on, invalid request format or validation error"
+// @Failure 403 {object} map[string]interface{} "Permission denied"
+// @Failure 500 {object} map[string]interface{} "Failed to update add-on price"
+// @Router /owner/pricing/addons/{code} [put]
+// @Security BearerAuth
+func UpdateAddonPrice(c echo.Context) error {
+	code := c.Param("code")
+	if !addonCodes[code] {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Unknown add-on code",
+		})
+	}
+
+	var addonReq AddonPriceRequest
+	if err := c.Bind(&addonReq); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Invalid request format",
+			"error":   err.Error(),
+		})
+	}
+	if err := c.Validate(&addonReq); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Validation error",
+			"error":   err.Error(),
+		})
+	}
+
+	addon := models.AddonPrice{Code: code, Price: addonReq.Price, PerDay: addonReq.PerDay}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var before *models.AddonPrice
+		var current models.AddonPrice
+		if result := tx.Where("code = ?", code).Limit(1).Find(&current); result.Error != nil {
+			return result.Error
+		} else if result.RowsAffected > 0 {
+			before = &current
+		}
+
+		if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&addon).Error; err != nil {
+			return err
+		}
+
+		return services.RecordAudit(tx, auditActor(c), services.AuditEntry{
+			Action:     services.AuditAddonPriceUpdate,
+			EntityType: services.AuditEntityAddonPrice,
+			EntityID:   code,
+			Before:     before,
+			After:      addon,
+		})
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": "Failed to update add-on price",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, addon)
+}
+
+// @Summary Set surcharges and rounding
+// @Description Set the weekend and holiday surcharge (percent of the daily rate) and how partial days are charged
+// @Tags Role Owner
+// @Accept json
+// @Produce json
+// @Param settingsReq body PricingSettingsRequest true "Pricing settings"
+// @Success 200 {object} models.PricingSetting "Updated pricing settings"
+// @Failure 400 {object} map[string]interface{} "Invalid request format or validation error"
+// @Failure 403 {object} map[string]interface{} "Permission denied"
+// @Failure 500 {object} map[string]interface{} "Failed to update pricing settings"
+// @Router /owner/pricing/settings [put]
+// @Security BearerAuth
+func UpdatePricingSettings(c echo.Context) error {
+	var settingsReq PricingSettingsRequest
+	if err := c.Bind(&settingsReq); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Invalid request format",
+			"error":   err.Error(),
+		})
+	}
+	if err := c.Validate(&settingsReq); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Validation error",
+			"error":   err.Error(),
+		})
+	}
+
+	// Pricing settings live in a single row
+	settings := models.PricingSetting{
+		PricingSettingID:        1,
+		WeekendSurchargePercent: settingsReq.WeekendSurchargePercent,
+		HolidaySurchargePercent: settingsReq.HolidaySurchargePercent,
+		Rounding:                settingsReq.Rounding,
+	}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var before *models.PricingSetting
+		var current models.PricingSetting
+		if result := tx.Limit(1).Find(&current, settings.PricingSettingID); result.Error != nil {
+			return result.Error
+		} else if result.RowsAffected > 0 {
+			before = &current
+		}
+
+		if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&settings).Error; err != nil {
+			return err
+		}
+
+		return services.RecordAudit(tx, auditActor(c), services.AuditEntry{
+			Action:     services.AuditPricingSettings,
+			EntityType: services.AuditEntityPricingSettings,
+			EntityID:   auditID(settings.PricingSettingID),
+			Before:     before,
+			After:      settings,
+		})
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": "Failed to update pricing settings",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, settings)
+}
+
+// @Summary Add a holiday
+// @Description Add or rename a date that carries the holiday surcharge
+// @Tags Role Owner
+// @Accept json
+// @Produce json
+// @Param holidayReq body HolidayRequest true "Holiday date (YYYY-MM-DD) and name"
+// @Success 200 {object} models.Holiday "Saved holiday"
+// @Failure 400 {object} map[string]interface{} "Invalid request format or validation error"
+// @Failure 403 {object} map[string]interface{} "Permission denied"
+// @Failure 500 {object} map[string]interface{} "Failed to save holiday"
+// @Router /owner/pricing/holidays [post]
+// @Security BearerAuth
+func CreateHoliday(c echo.Context) error {
+	var holidayReq HolidayRequest
+	if err := c.Bind(&holidayReq); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Invalid request format",
+			"error":   err.Error(),
+		})
+	}
+	if err := c.Validate(&holidayReq); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Validation error",
+			"error":   err.Error(),
+		})
+	}
+
+	date, _ := time.Parse("2006-01-02", holidayReq.Date)
+
+	holiday := models.Holiday{HolidayDate: date, Name: holidayReq.Name}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var before *models.Holiday
+		var current models.Holiday
+		if result := tx.Where("holiday_date = ?", date).Limit(1).Find(&current); result.Error != nil {
+			return result.Error
+		} else if result.RowsAffected > 0 {
+			before = &current
+		}
+
+		if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&holiday).Error; err != nil {
+			return err
+		}
+
+		return services.RecordAudit(tx, auditActor(c), services.AuditEntry{
+			Action:     services.AuditHolidayCreate,
+			EntityType: services.AuditEntityHoliday,
+			EntityID:   holidayReq.Date,
+			Before:     before,
+			After:      holiday,
+		})
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": "Failed to save holiday",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, holiday)
+}
+
+// @Summary Remove a holiday
+// @Description Remove a date from the holiday surcharge list
+// @Tags Role Owner
+// @Accept json
+// @Produce json
+// @Param date path string true "Holiday date (YYYY-MM-DD)"
+// @Success 200 {object} map[string]interface{} "Holiday removed"
+// @Failure 400 {object} map[string]interface{} "Invalid date"
+// @Failure 403 {object} map[string]interface{} "Permission denied"
+// @Failure 404 {object} map[string]interface{} "User or holiday not found"
+// @Failure 500 {object} map[string]interface{} "Failed to remove holiday"
+// @Router /owner/pricing/holidays/{date} [delete]
+// @Security BearerAuth
+func DeleteHoliday(c echo.Context) error {
+	date, err := time.Parse("2006-01-02", c.Param("date"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Invalid date, expected YYYY-MM-DD",
+			"error":   err.Error(),
+		})
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var holiday models.Holiday
+		if err := tx.Where("holiday_date = ?", date).First(&holiday).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Delete(&holiday).Error; err != nil {
+			return err
+		}
+
+		return services.RecordAudit(tx, auditActor(c), services.AuditEntry{
+			Action:     services.AuditHolidayDelete,
+			EntityType: services.AuditEntityHoliday,
+			EntityID:   c.Param("date"),
+			Before:     holiday,
+		})
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.JSON(http.StatusNotFound, echo.Map{
+			"message": "Holiday not found",
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": "Failed to remove holiday",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"message": "Holiday removed",
+	})
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"net/http"
+
+	"jakarta-luxury-rent-car/database"
+	"jakarta-luxury-rent-car/services"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+)
+
+// @Summary Quote a booking
+// @Description Itemise what a booking would cost, membership discount included, without creating it
+// @Tags Role User
+// @Accept json
+// @Produce json
+// @Param bookingReq body BookingRequest true "Booking request body containing car ID and other booking details"
+// @Success 200 {object} map[string]interface{} "Price breakdown and free units for the requested dates"
+// @Failure 400 {object} map[string]string "Invalid request format or validation error"
+// @Failure 404 {object} map[string]string "Car, driver, or package not found"
+// @Failure 500 {object} map[string]string "Failed to calculate price or availability"
+// @Router /users/booking/quote [post]
+// @Security BearerAuth
+func QuoteBooking(c echo.Context) error {
+	var bookingReq BookingRequest
+
+	// Bind and validate the request body to BookingRequest struct
+	if err := c.Bind(&bookingReq); err != nil {
+		return jsonResponse(c, http.StatusBadRequest, "Invalid request format", err.Error())
+	}
+	if err := c.Validate(&bookingReq); err != nil {
+		return jsonResponse(c, http.StatusBadRequest, "Validation error", err.Error())
+	}
+
+	if !bookingReq.ReturnDate.After(bookingReq.RentalDate) {
+		return jsonResponse(c, http.StatusBadRequest, "Validation error", "return_date must be after rental_date")
+	}
+
+	// Extract user ID from JWT token
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*jwt.MapClaims)
+	userID := uint((*claims)["user_id"].(float64))
+
+	car, err := getCarByID(bookingReq.CarID)
+	if err != nil {
+		return jsonResponse(c, http.StatusNotFound, "Car not found", err.Error())
+	}
+
+	_, eventPackage, err := getDriverandPackage(bookingReq.DriverID, bookingReq.PackageID)
+	if err != nil {
+		return jsonResponse(c, http.StatusNotFound, "driver and eventPackage not found", err.Error())
+	}
+
+	breakdown, err := priceBooking(bookingReq, car, eventPackage, userID)
+	if err != nil {
+		return jsonResponse(c, http.StatusInternalServerError, "Failed to calculate price", err.Error())
+	}
+
+	availability, err := services.GetCarAvailability(database.DB, car, bookingReq.RentalDate, bookingReq.ReturnDate, 0)
+	if err != nil {
+		return jsonResponse(c, http.StatusInternalServerError, "Failed to calculate availability", err.Error())
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"message":         "Booking quote",
+		"available_units": availability.AvailableUnits,
+		"price_breakdown": breakdown,
+	})
+}
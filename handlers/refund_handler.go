@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"jakarta-luxury-rent-car/database"
+	"jakarta-luxury-rent-car/models"
+	"jakarta-luxury-rent-car/services"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// XenditRefundCallbackRequest struct to capture the refund callback sent by Xendit
+type XenditRefundCallbackRequest struct {
+	Event string `json:"event" validate:"required"`
+	Data  struct {
+		ID            string  `json:"id"`
+		ReferenceID   string  `json:"reference_id" validate:"required"`
+		Status        string  `json:"status" validate:"required"`
+		Amount        float64 `json:"amount"`
+		FailureReason string  `json:"failure_code"`
+	} `json:"data"`
+}
+
+// @Summary List your refunds
+// @Description List the refunds of your cancelled or rejected bookings, newest first
+// @Tags Role User
+// @Accept json
+// @Produce json
+// @Success 200 {array} models.Refund "List of refunds"
+// @Failure 500 {object} map[string]interface{} "Failed to fetch refunds"
+// @Router /users/refunds [get]
+// @Security BearerAuth
+func GetMyRefunds(c echo.Context) error {
+	// Extract user ID from JWT token
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*jwt.MapClaims)
+	userID := uint((*claims)["user_id"].(float64))
+
+	var refunds []models.Refund
+	if err := database.DB.Where("user_id = ?", userID).Order("refund_id DESC").Find(&refunds).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": "Failed to fetch refunds",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, refunds)
+}
+
+// @Summary List refunds
+// @Description List all refunds, newest first, optionally filtered by status
+// @Tags Role Owner
+// @Accept json
+// @Produce json
+// @Param status query string false "Only refunds with this status" Enums(requested, processing, completed, failed)
+// @Success 200 {array} models.Refund "List of refunds"
+// @Failure 403 {object} map[string]interface{} "Permission denied"
+// @Failure 500 {object} map[string]interface{} "Failed to fetch refunds"
+// @Router /owner/refunds [get]
+// @Security BearerAuth
+func GetRefunds(c echo.Context) error {
+	query := database.DB.Order("refund_id DESC")
+	if status := c.QueryParam("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var refunds []models.Refund
+	if err := query.Find(&refunds).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": "Failed to fetch refunds",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, refunds)
+}
+
+// @Summary Retry a failed refund
+// @Description Send a gateway refund that was never sent, failed, or got no answer from the payment gateway to the gateway again
+// @Tags Role Owner
+// @Accept json
+// @Produce json
+// @Param id path int true "Refund ID"
+// @Success 200 {object} models.Refund "Refund after the retry"
+// @Failure 400 {object} map[string]interface{} "Invalid refund ID"
+// @Failure 403 {object} map[string]interface{} "Permission denied"
+// @Failure 404 {object} map[string]interface{} "User or refund not found"
+// @Failure 409 {object} map[string]interface{} "Refund is completed, wallet refunded or still waiting on the gateway"
+// @Failure 500 {object} map[string]interface{} "Failed to retry refund"
+// @Router /owner/refunds/{id}/retry [post]
+// @Security BearerAuth
+func RetryRefund(c echo.Context) error {
+	refundID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Invalid refund ID",
+			"error":   err.Error(),
+		})
+	}
+
+	var refund models.Refund
+	if err := database.DB.First(&refund, refundID).Error; err != nil {
+		return c.JSON(http.StatusNotFound, echo.Map{
+			"message": "Refund not found",
+			"error":   err.Error(),
+		})
+	}
+
+	if !services.RefundRetryable(refund) {
+		return c.JSON(http.StatusConflict, echo.Map{
+			"message": "Refund cannot be retried",
+			"error":   services.ErrRefundNotRetryable.Error(),
+		})
+	}
+
+	from := refund.Status
+	refund, err = services.ProcessGatewayRefund(database.DB, paymentGateway, refund)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": "Failed to retry refund",
+			"error":   err.Error(),
+		})
+	}
+
+	// The gateway has already been called, so a failed audit write is logged instead of failing the retry
+	if err := services.RecordAudit(database.DB, auditActor(c), services.AuditEntry{
+		Action:     services.AuditRefundRetry,
+		EntityType: services.AuditEntityRefund,
+		EntityID:   auditID(refund.RefundID),
+		Before:     echo.Map{"status": from},
+		After:      echo.Map{"status": refund.Status, "amount": refund.Amount, "rental_id": refund.RentalID},
+	}); err != nil {
+		log.Printf("Failed to audit retry of refund %d: %v", refund.RefundID, err)
+	}
+	if refund.Status == services.RefundCompleted {
+		notifyRefundCompleted(refund)
+	}
+
+	return c.JSON(http.StatusOK, refund)
+}
+
+// @Summary Xendit refund callback
+// @Description Called by Xendit when a refund succeeds or fails. The x-callback-token header must match XENDIT_CALLBACK_TOKEN. Replayed callbacks are acknowledged without changing anything. A refund marked failed is still completed when the gateway reports it succeeded.
+// @Tags Public
+// @Accept json
+// @Produce json
+// @Param x-callback-token header string true "Xendit callback verification token"
+// @Param callbackReq body XenditRefundCallbackRequest true "Xendit refund callback"
+// @Success 200 {object} map[string]interface{} "Callback processed or already processed"
+// @Failure 400 {object} map[string]interface{} "Invalid request format"
+// @Failure 401 {object} map[string]interface{} "Invalid callback token"
+// @Failure 404 {object} map[string]interface{} "Refund not found"
+// @Failure 500 {object} map[string]interface{} "Failed to process callback"
+// @Router /payments/xendit/refund-callback [post]
+func XenditRefundCallback(c echo.Context) error {
+	if !validXenditCallbackToken(c) {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": "Invalid callback token",
+		})
+	}
+
+	var callbackReq XenditRefundCallbackRequest
+	if err := c.Bind(&callbackReq); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Invalid request format",
+			"error":   err.Error(),
+		})
+	}
+	if err := c.Validate(&callbackReq); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Validation error",
+			"error":   err.Error(),
+		})
+	}
+
+	var refund models.Refund
+	var changed bool
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		refund, changed, err = services.ApplyGatewayRefundUpdate(tx, callbackReq.Data.ReferenceID, callbackReq.Data.Status, callbackReq.Data.FailureReason)
+		return err
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.JSON(http.StatusNotFound, echo.Map{
+			"message": "Refund not found",
+			"error":   err.Error(),
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": "Failed to process callback",
+			"error":   err.Error(),
+		})
+	}
+
+	if !changed {
+		return c.JSON(http.StatusOK, echo.Map{
+			"message": "Callback already processed",
+			"status":  refund.Status,
+		})
+	}
+
+	if refund.Status == services.RefundCompleted {
+		notifyRefundCompleted(refund)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"message": "Callback processed",
+		"status":  refund.Status,
+	})
+}
+
+// processRefund finishes a refund after the transaction that requested it has committed:
+// gateway refunds are sent to the payment gateway and the customer hears about every completed refund
+func processRefund(refund models.Refund) models.Refund {
+	if refund.Method == services.RefundMethodGateway && refund.Status == services.RefundRequested {
+		processed, err := services.ProcessGatewayRefund(database.DB, paymentGateway, refund)
+		if err != nil {
+			// The refund keeps the state it reached and the owner can retry it from /owner/refunds
+			log.Printf("Failed to process refund %d: %v", refund.RefundID, err)
+		}
+		refund = processed
+	}
+
+	if refund.Status == services.RefundCompleted {
+		notifyRefundCompleted(refund)
+	}
+
+	return refund
+}
+
+// notifyRefundCompleted tells the customer their refund has been paid out
+func notifyRefundCompleted(refund models.Refund) {
+	var customer models.User
+	if err := database.DB.First(&customer, refund.UserID).Error; err != nil {
+		return
+	}
+
+	notifyUser(customer, services.EventRefundCompleted, echo.Map{
+		"Refund":    refund,
+		"ToDeposit": refund.Method == services.RefundMethodWallet,
+	})
+}
@@ -0,0 +1,20 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"jakarta-luxury-rent-car/services"
+)
+
+// rentalTransitionStatusCode maps a rental lifecycle error to the HTTP status returned to the client
+func rentalTransitionStatusCode(err error) int {
+	switch {
+	case errors.Is(err, services.ErrIllegalTransition):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrTransitionForbidden):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -7,8 +7,8 @@ import (
 
 	"jakarta-luxury-rent-car/database"
 	"jakarta-luxury-rent-car/models"
+	"jakarta-luxury-rent-car/services"
 
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
 )
 
@@ -35,6 +35,9 @@ type RentalReportResponse struct {
 	DropoffLocation    string    `json:"dropoff_location"`
 	Duration           string    `json:"duration"`
 	CostDetails        string    `json:"cost_details"`
+	TotalCost          float64   `json:"total_cost"`
+	LateFee            float64   `json:"late_fee"`
+	GrandTotal         float64   `json:"grand_total"`
 	Status             string    `json:"status"`
 	ConciergeServices  bool      `json:"concierge_services"`
 	AirportTransfer    bool      `json:"airport_transfer"`
@@ -46,34 +49,13 @@ type RentalReportResponse struct {
 // @Accept json
 // @Produce json
 // @Success 200 {array} RentalReportResponse "List of rental reports"
-// @Failure 403 {object} map[string]interface{} "Permission denied. Only owners can approve or reject bookings."
+// @Failure 403 {object} map[string]interface{} "Permission denied"
 // @Failure 404 {object} map[string]interface{} "User or related entity not found"
 // @Failure 500 {object} map[string]interface{} "Failed to fetch rental histories or related data"
 // @Router /owner/report [get]
 // @Security BearerAuth
 
 func Report(c echo.Context) error {
-	// Extract user ID from JWT token
-	user := c.Get("user").(*jwt.Token)
-	claims := user.Claims.(*jwt.MapClaims)
-	userID := uint((*claims)["user_id"].(float64))
-
-	// Fetch user role from the database
-	var userModel models.User
-	if err := database.DB.First(&userModel, userID).Error; err != nil {
-		return c.JSON(http.StatusNotFound, echo.Map{
-			"message": "User not found",
-			"error":   err.Error(),
-		})
-	}
-
-	// Check if the user has the role of "owner"
-	if userModel.Role != "owner" {
-		return c.JSON(http.StatusForbidden, echo.Map{
-			"message": "Permission denied. Only owners can approve or reject bookings.",
-		})
-	}
-
 	var rentalHistories []models.RentalHistory
 	var reports []RentalReportResponse
 
@@ -126,21 +108,6 @@ func Report(c echo.Context) error {
 		durationDays := rental.ReturnDate.Sub(rental.RentalDate).Hours() / 24
 		duration := fmt.Sprintf("%.0f days", durationDays)
 
-		costDetails := fmt.Sprintf("Total Cost for %s: %.2f", duration, car.RentalCosts*durationDays)
-		if rental.AirportTransfer {
-			costDetails += " + Airport Transfer: 50"
-		}
-		if rental.ConciergeServices {
-			costDetails += " + Concierge Services: 100"
-		}
-		if rental.DriverID != nil {
-			costDetails += fmt.Sprintf(" + Driver: %.2f", durationDays*100)
-		}
-		if rental.PackageID != nil {
-			costDetails += fmt.Sprintf(" + Package: %.2f", eventPackage.Cost)
-		}
-		costDetails += fmt.Sprintf(" = Total: %.2f", rental.TotalCost)
-
 		// Build the report entry
 		report := RentalReportResponse{
 			Email:              user.Email,
@@ -163,7 +130,10 @@ func Report(c echo.Context) error {
 			PickupLocation:     rental.PickupLocation,
 			DropoffLocation:    rental.DropoffLocation,
 			Duration:           duration,
-			CostDetails:        costDetails,
+			CostDetails:        services.RentalCostDetails(rental),
+			TotalCost:          rental.TotalCost,
+			LateFee:            rental.LateFee,
+			GrandTotal:         services.RentalGrandTotal(rental),
 			Status:             rental.Status,
 			ConciergeServices:  rental.ConciergeServices,
 			AirportTransfer:    rental.AirportTransfer,
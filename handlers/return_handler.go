@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"jakarta-luxury-rent-car/database"
+	"jakarta-luxury-rent-car/models"
+	"jakarta-luxury-rent-car/services"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ReturnRequest struct to capture the vehicle check-in details
+type ReturnRequest struct {
+	ReturnedAt *time.Time `json:"returned_at"` // Optional, defaults to now; between the rental date and now
+	Odometer   *int       `json:"odometer" validate:"required,gte=0"`
+	FuelLevel  *int       `json:"fuel_level" validate:"required,gte=0,lte=100"`
+}
+
+// @Summary Check in a returned car
+// @Description Record the actual return time, odometer and fuel level, charge any late return to the customer deposit and complete the rental
+// @Tags Role Owner
+// @Accept json
+// @Produce json
+// @Param id path int true "Rental ID"
+// @Param returnReq body ReturnRequest true "Return request body containing odometer, fuel level and optional return time"
+// @Success 200 {object} map[string]interface{} "Completed rental with the late fee that was charged"
+// @Failure 400 {object} map[string]interface{} "Invalid request format, validation error, or returned_at before the rental date or in the future"
+// @Failure 403 {object} map[string]interface{} "Permission denied"
+// @Failure 404 {object} map[string]interface{} "User, car, or rental history not found"
+// @Failure 409 {object} map[string]interface{} "Only rentals with status 'Rent' can be returned"
+// @Failure 500 {object} map[string]interface{} "Failed to complete the rental"
+// @Router /owner/rentals/{id}/return [post]
+// @Security BearerAuth
+func ReturnCar(c echo.Context) error {
+	rentalID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Invalid rental ID",
+			"error":   err.Error(),
+		})
+	}
+
+	var returnReq ReturnRequest
+
+	// Bind the request body to ReturnRequest struct
+	if err := c.Bind(&returnReq); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Invalid request format",
+			"error":   err.Error(),
+		})
+	}
+
+	// Validate the request
+	if err := c.Validate(&returnReq); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Validation error",
+			"error":   err.Error(),
+		})
+	}
+
+	// Extract user ID from JWT token
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*jwt.MapClaims)
+	userID := uint((*claims)["user_id"].(float64))
+
+	// Fetch user role from the database
+	var userModel models.User
+	if err := database.DB.First(&userModel, userID).Error; err != nil {
+		return c.JSON(http.StatusNotFound, echo.Map{
+			"message": "User not found",
+			"error":   err.Error(),
+		})
+	}
+
+	now := time.Now()
+	returnedAt := now
+	if returnReq.ReturnedAt != nil {
+		returnedAt = *returnReq.ReturnedAt
+	}
+
+	var rentalHistory models.RentalHistory
+	var carModel models.Car
+	var lateDays int
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&rentalHistory, rentalID).Error; err != nil {
+			return err
+		}
+
+		if err := services.ValidateReturnTime(rentalHistory.RentalDate, returnedAt, now); err != nil {
+			return err
+		}
+
+		if err := tx.First(&carModel, rentalHistory.CarID).Error; err != nil {
+			return err
+		}
+
+		// Late days are charged at the car's current daily rate
+		pricing, err := services.LoadPricingConfig(tx)
+		if err != nil {
+			return err
+		}
+
+		var lateFee float64
+		lateDays, lateFee = services.CalculateLateFee(rentalHistory.ReturnDate, returnedAt, pricing.CarRate(carModel).DailyRate)
+
+		before := echo.Map{"status": rentalHistory.Status, "fleet_unit_id": rentalHistory.FleetUnitID}
+		if err := services.TransitionRental(tx, &rentalHistory, services.RentalStatusCompleted, services.StaffActor(userModel), "Car returned"); err != nil {
+			return err
+		}
+
+		rentalHistory.ActualReturnDate = &returnedAt
+		rentalHistory.ReturnOdometer = returnReq.Odometer
+		rentalHistory.ReturnFuelLevel = returnReq.FuelLevel
+		rentalHistory.LateFee = lateFee
+
+		if err := tx.Save(&rentalHistory).Error; err != nil {
+			return err
+		}
+
+		// Put the physical unit back into the pool with its new mileage
+		if _, err := services.ReleaseFleetUnit(tx, rentalHistory, returnReq.Odometer); err != nil {
+			return err
+		}
+
+		after := echo.Map{
+			"status":          rentalHistory.Status,
+			"returned_at":     returnedAt,
+			"return_odometer": rentalHistory.ReturnOdometer,
+			"late_fee":        lateFee,
+		}
+
+		// Charge the late return against the customer deposit
+		if lateFee > 0 {
+			transaction, err := services.PostWalletTransaction(tx, services.WalletEntry{
+				UserID:      rentalHistory.UserID,
+				Type:        services.WalletPenalty,
+				Amount:      lateFee,
+				RentalID:    &rentalHistory.RentalID,
+				Description: fmt.Sprintf("Late return fee for %d day(s)", lateDays),
+			})
+			if err != nil {
+				return err
+			}
+			before["deposit_amount"] = services.BalanceBefore(transaction)
+			after["deposit_amount"] = transaction.BalanceAfter
+			after["wallet_transaction_id"] = transaction.WalletTransactionID
+		}
+
+		return services.RecordAudit(tx, auditActor(c), services.AuditEntry{
+			Action:     services.AuditRentalReturn,
+			EntityType: services.AuditEntityRental,
+			EntityID:   auditID(rentalHistory.RentalID),
+			Before:     before,
+			After:      after,
+		})
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.JSON(http.StatusNotFound, echo.Map{
+			"message": "Rental history or car not found",
+			"error":   err.Error(),
+		})
+	}
+	if errors.Is(err, services.ErrInvalidReturnTime) {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Validation error",
+			"error":   err.Error(),
+		})
+	}
+	if err != nil {
+		return c.JSON(rentalTransitionStatusCode(err), echo.Map{
+			"message": "Failed to complete the rental",
+			"error":   err.Error(),
+		})
+	}
+
+	var customer models.User
+	if err := database.DB.First(&customer, rentalHistory.UserID).Error; err == nil {
+		notifyUser(customer, services.EventRentalCompleted, echo.Map{
+			"Rental":   rentalHistory,
+			"Car":      carModel,
+			"LateDays": lateDays,
+		})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"message":   "Car returned and rental completed",
+		"late_days": lateDays,
+		"data":      rentalHistory,
+	})
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"jakarta-luxury-rent-car/database"
+	"jakarta-luxury-rent-car/models"
+	"jakarta-luxury-rent-car/services"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// tokenConfig is how long issued tokens are valid, configured by main
+var tokenConfig = services.DefaultTokenConfig()
+
+// SetTokenConfig sets the lifetimes of the access and refresh tokens issued from now on
+func SetTokenConfig(config services.TokenConfig) {
+	tokenConfig = config
+}
+
+// RefreshTokenRequest struct to capture the refresh token to exchange
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// sessionResponse signs the access token of a session and returns it with the refresh token
+func sessionResponse(user models.User, session services.Session) (UserResponse, error) {
+	token, err := generateJWT(user, session)
+	if err != nil {
+		return UserResponse{}, err
+	}
+
+	return UserResponse{
+		ID:           user.UserID,
+		Email:        user.Email,
+		Address:      user.Address,
+		PhoneNumber:  user.PhoneNumber,
+		Role:         user.Role,
+		Token:        token,
+		RefreshToken: session.RefreshToken,
+		ExpiresIn:    int64(time.Until(session.AccessExpiresAt).Seconds()),
+	}, nil
+}
+
+// @Summary Refresh the access token
+// @Description Exchange a refresh token for a new access token and a new refresh token. Each refresh token works once; using one again logs out that session.
+// @Tags Register and Login
+// @Accept json
+// @Produce json
+// @Param refreshReq body RefreshTokenRequest true "Refresh token from login or the previous refresh"
+// @Success 200 {object} UserResponse
+// @Failure 400 {object} map[string]interface{} "Invalid request format or validation error"
+// @Failure 401 {object} map[string]interface{} "Refresh token is invalid, expired, revoked or reused"
+// @Failure 403 {object} map[string]interface{} "Account suspended"
+// @Failure 500 {object} map[string]interface{} "Failed to refresh token"
+// @Router /token/refresh [post]
+func RefreshToken(c echo.Context) error {
+	var refreshReq RefreshTokenRequest
+
+	// Bind the request body to RefreshTokenRequest struct
+	if err := c.Bind(&refreshReq); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Invalid request format",
+			"error":   err.Error(),
+		})
+	}
+
+	// Validate the request
+	if err := c.Validate(&refreshReq); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Validation error",
+			"error":   err.Error(),
+		})
+	}
+
+	now := time.Now()
+	session, err := services.RotateRefreshToken(database.DB, refreshReq.RefreshToken, now, tokenConfig)
+	if errors.Is(err, services.ErrRefreshTokenInvalid) || errors.Is(err, services.ErrRefreshTokenReused) {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": "Failed to refresh token",
+			"error":   err.Error(),
+		})
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": "Failed to refresh token",
+			"error":   err.Error(),
+		})
+	}
+
+	// The role may have changed since login, so the new token is built from the stored account
+	var user models.User
+	if err := database.DB.First(&user, session.UserID).Error; err != nil {
+		return c.JSON(http.StatusUnauthorized, echo.Map{
+			"message": "User not found",
+			"error":   err.Error(),
+		})
+	}
+
+	if user.SuspendedAt != nil {
+		// The rotated tokens were just issued, so they are revoked before answering
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			return services.RevokeSessionFamily(tx, session.FamilyID, now)
+		})
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, echo.Map{
+				"message": "Failed to refresh token",
+				"error":   err.Error(),
+			})
+		}
+		return c.JSON(http.StatusForbidden, echo.Map{
+			"message": "Account suspended",
+		})
+	}
+
+	response, err := sessionResponse(user, session)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": "Failed to refresh token",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// @Summary Logout
+// @Description Revoke the current session: its refresh tokens and the access tokens issued with them
+// @Tags Register and Login
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Logged out"
+// @Failure 500 {object} map[string]interface{} "Failed to log out"
+// @Router /logout [post]
+// @Security BearerAuth
+func Logout(c echo.Context) error {
+	// Extract the session ID from JWT token
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*jwt.MapClaims)
+	familyID, _ := (*claims)["sid"].(string)
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		return services.RevokeSessionFamily(tx, familyID, time.Now())
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": "Failed to log out",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"message": "Logged out",
+	})
+}
+
+// @Summary Logout from all devices
+// @Description Revoke every session of the current user, including the one making the request
+// @Tags Register and Login
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Logged out from all devices"
+// @Failure 500 {object} map[string]interface{} "Failed to log out"
+// @Router /logout-all [post]
+// @Security BearerAuth
+func LogoutAll(c echo.Context) error {
+	// Extract user ID from JWT token
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*jwt.MapClaims)
+	userID := uint((*claims)["user_id"].(float64))
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		return services.RevokeUserSessions(tx, userID, time.Now())
+	})
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": "Failed to log out",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"message": "Logged out from all devices",
+	})
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"io"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"jakarta-luxury-rent-car/database"
+	"jakarta-luxury-rent-car/models"
+	"jakarta-luxury-rent-car/services"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openTestDB connects to dsn and migrates every model the handlers touch
+func openTestDB(dsn string, config *gorm.Config) (*gorm.DB, error) {
+	db, err := gorm.Open(postgres.Open(dsn), config)
+	if err != nil {
+		return nil, err
+	}
+
+	err = db.AutoMigrate(
+		&models.User{},
+		&models.Car{},
+		&models.Driver{},
+		&models.EventPackage{},
+		&models.RentalHistory{},
+		&models.CallAssistance{},
+		&models.Membership{},
+		&models.FleetUnit{},
+		&models.RentalStatusEvent{},
+		&models.CarRate{},
+		&models.AddonPrice{},
+		&models.PricingSetting{},
+		&models.Holiday{},
+		&models.Invoice{},
+		&models.WalletTransaction{},
+		&models.DepositTopUp{},
+		&models.Refund{},
+		&models.OutboxMessage{},
+		&models.MessageTemplate{},
+		&models.ReminderLog{},
+		&models.UserInvitation{},
+		&models.RefreshToken{},
+		&models.RevokedToken{},
+		&models.OneTimeCode{},
+		&models.AuditLog{},
+	)
+	return db, err
+}
+
+// setupTestDB connects database.DB to the Postgres database in TEST_DATABASE_DSN
+func setupTestDB(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+
+	db, err := openTestDB(dsn, &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("Failed to set up database: %v", err)
+	}
+
+	database.DB = db
+
+	// Notifications are discarded, handlers still go through the notification service
+	SetNotificationService(services.NewNotificationService(nil, services.NewWriterNotifier(io.Discard)))
+}
+
+// createTestUser stores a user with a bcrypt hashed password, like registration does
+func createTestUser(t *testing.T, user models.User, password string) models.User {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("Failed to hash password: %v", err)
+	}
+	user.Password = string(hashedPassword)
+
+	if err := database.DB.Create(&user).Error; err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	return user
+}
+
+// newUserContext builds a request context authenticated as userID
+func newUserContext(e *echo.Echo, method, target, body string, userID uint) (echo.Context, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+
+	c := e.NewContext(req, rec)
+	c.Set("user", &jwt.Token{Claims: &jwt.MapClaims{"user_id": float64(userID)}})
+
+	return c, rec
+}
@@ -2,28 +2,31 @@ package handlers
 
 import (
 	"net/http"
+	"time"
 
 	"jakarta-luxury-rent-car/database"
 	"jakarta-luxury-rent-car/models"
+	"jakarta-luxury-rent-car/services"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
 )
 
 type TopUpRequest struct {
-	Amount float64 `json:"deposit_amount"`
+	Amount float64 `json:"deposit_amount" validate:"required,gt=0"`
 }
 
 // @Summary Top up user deposit amount
-// @Description Top up user deposit amount
+// @Description Create a pending top up and a payment gateway invoice for it. The deposit is credited once the gateway confirms the payment.
 // @Tags Role User
 // @Accept json
 // @Produce json
 // @Param topUpReq body TopUpRequest true "Top-up request body"
-// @Success 200 {object} map[string]interface{} "Updated email and deposit amount"
-// @Failure 400 {object} map[string]string "Invalid request format"
+// @Success 201 {object} map[string]interface{} "Pending top up with the invoice URL to pay"
+// @Failure 400 {object} map[string]string "Invalid request format or top up amount"
 // @Failure 404 {object} map[string]interface{} "User not found"
-// @Failure 500 {object} map[string]interface{} "Failed to update deposit amount"
+// @Failure 500 {object} map[string]interface{} "Failed to create top up invoice"
 // @Router /users/topup [post]
 // @Security BearerAuth
 func TopUp(c echo.Context) error {
@@ -34,6 +37,14 @@ func TopUp(c echo.Context) error {
 		})
 	}
 
+	// Validate the request
+	if err := c.Validate(&topUpReq); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Validation error",
+			"error":   err.Error(),
+		})
+	}
+
 	// Extract user ID from JWT token
 	user := c.Get("user").(*jwt.Token)
 	claims := user.Claims.(*jwt.MapClaims)
@@ -47,18 +58,127 @@ func TopUp(c echo.Context) error {
 		})
 	}
 
-	userModel.DepositAmount += topUpReq.Amount
+	topUp := models.DepositTopUp{
+		UserID: userID,
+		Amount: topUpReq.Amount,
+		Status: services.InvoiceStatusPending,
+	}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&topUp).Error; err != nil {
+			return err
+		}
 
-	if err := database.DB.Save(&userModel).Error; err != nil {
+		return services.RecordAudit(tx, auditActor(c), services.AuditEntry{
+			Action:     services.AuditTopUpCreate,
+			EntityType: services.AuditEntityTopUp,
+			EntityID:   auditID(topUp.DepositTopUpID),
+			After:      echo.Map{"user_id": topUp.UserID, "amount": topUp.Amount, "status": topUp.Status},
+		})
+	})
+	if err != nil {
 		return c.JSON(http.StatusInternalServerError, echo.Map{
-			"message": "Failed to update deposit amount",
+			"message": "Failed to create top up",
 			"error":   err.Error(),
 		})
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"Email":          userModel.Email,
-		"deposit_amount": userModel.DepositAmount,
+	invoice, err := createGatewayInvoice(
+		models.Invoice{DepositTopUpID: &topUp.DepositTopUpID, Purpose: services.InvoicePurposeTopUp},
+		topUp.DepositTopUpID,
+		services.InvoiceRequest{
+			Amount:        topUp.Amount,
+			Description:   "Deposit Top Up Jakarta Luxury Car To : " + userModel.Email + " - " + userModel.PhoneNumber,
+			Duration:      24 * time.Hour,
+			CustomerEmail: userModel.Email,
+			CustomerPhone: userModel.PhoneNumber,
+			Items: []services.InvoiceItem{
+				{Name: "Deposit top up", Quantity: 1, Price: topUp.Amount},
+			},
+		},
+	)
+	if err != nil {
+		// Without an invoice the top up can never be paid
+		database.DB.Model(&topUp).Update("status", services.InvoiceStatusExpired)
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": "Failed to create top up invoice",
+			"error":   err.Error(),
+		})
+	}
+
+	topUp.InvoiceURL = invoice.InvoiceURL
+	topUp.ExpiresAt = invoice.ExpiresAt
+	if err := database.DB.Save(&topUp).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": "Failed to create top up",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusCreated, echo.Map{
+		"message": "Top up created, your deposit is credited once the invoice is paid",
+		"data":    topUp,
+	})
+}
+
+// @Summary List top ups
+// @Description List your top ups, newest first, optionally filtered by status
+// @Tags Role User
+// @Accept json
+// @Produce json
+// @Param status query string false "Only top ups with this status" Enums(PENDING, PAID, EXPIRED, UNDERPAID)
+// @Param page query int false "Page number, starting at 1"
+// @Param limit query int false "Top ups per page (default 20, max 100)"
+// @Success 200 {object} map[string]interface{} "Page of top ups"
+// @Failure 400 {object} map[string]interface{} "Invalid status, page or limit"
+// @Failure 500 {object} map[string]interface{} "Failed to fetch top ups"
+// @Router /users/topups [get]
+// @Security BearerAuth
+func GetTopUps(c echo.Context) error {
+	page, limit, err := parsePagination(c, defaultWalletPageSize, maxWalletPageSize)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Invalid page or limit",
+			"error":   err.Error(),
+		})
+	}
+
+	// Extract user ID from JWT token
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*jwt.MapClaims)
+	userID := uint((*claims)["user_id"].(float64))
+
+	query := database.DB.Model(&models.DepositTopUp{}).Where("user_id = ?", userID)
+	switch status := c.QueryParam("status"); status {
+	case "":
+	case services.InvoiceStatusPending, services.InvoiceStatusPaid, services.InvoiceStatusExpired, services.InvoiceStatusUnderpaid:
+		query = query.Where("status = ?", status)
+	default:
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Invalid status, expected PENDING, PAID, EXPIRED or UNDERPAID",
+		})
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": "Failed to fetch top ups",
+			"error":   err.Error(),
+		})
+	}
+
+	var topUps []models.DepositTopUp
+	if err := query.Order("deposit_top_up_id DESC").Offset((page - 1) * limit).Limit(limit).Find(&topUps).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": "Failed to fetch top ups",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"page":  page,
+		"limit": limit,
+		"total": total,
+		"data":  topUps,
 	})
 }
 
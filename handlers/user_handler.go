@@ -1,8 +1,12 @@
 package handlers
 
 import (
+	"log"
+	"math"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -11,25 +15,29 @@ import (
 
 	"jakarta-luxury-rent-car/database"
 	"jakarta-luxury-rent-car/models"
+	"jakarta-luxury-rent-car/services"
 )
 
 // Struct untuk mengirimkan response
 type UserResponse struct {
-	ID          uint   `json:"id"`
-	Email       string `json:"email"`
-	Address     string `json:"address"`
-	PhoneNumber string `json:"phone_number"`
-	Role        string `json:"role"`
-	Token       string `json:"token,omitempty"`
+	ID           uint   `json:"id"`
+	Email        string `json:"email"`
+	Address      string `json:"address"`
+	PhoneNumber  string `json:"phone_number"`
+	Role         string `json:"role"`
+	Token        string `json:"token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	ExpiresIn    int64  `json:"expires_in,omitempty"` // Seconds until the access token expires
 }
 
 // Struct untuk input registrasi
 type RegisterRequest struct {
-	Email       string `json:"email" validate:"required,email"`
-	Password    string `json:"password" validate:"required,min=3"`
-	PhoneNumber string `json:"phone_number" validate:"required"`
-	Address     string `json:"address" validate:"required"`
-	Role        string `json:"role"` // Optional
+	Email             string `json:"email" validate:"required,email"`
+	Password          string `json:"password" validate:"required"` // Checked against the password policy
+	PhoneNumber       string `json:"phone_number" validate:"required"`
+	Address           string `json:"address" validate:"required"`
+	PreferredChannel  string `json:"preferred_channel" validate:"omitempty,oneof=whatsapp email sms"` // The log channel is a development-only fallback and cannot be chosen
+	PreferredLanguage string `json:"preferred_language" validate:"omitempty,oneof=id en"`
 }
 
 // Struct untuk input login
@@ -38,15 +46,36 @@ type LoginRequest struct {
 	Password string `json:"password" validate:"required"`
 }
 
+// Per-account limits of /register and /login, per-IP limits are set on the routes in main
+var (
+	registerAccountLimiter = services.NewRateLimiter(3, time.Hour)
+	loginAccountLimiter    = services.NewRateLimiter(10, 15*time.Minute)
+)
+
+// passwordPolicy checks every new password, configured by main
+var passwordPolicy services.PasswordPolicy
+
+// SetPasswordPolicy sets the policy new passwords are checked against
+func SetPasswordPolicy(policy services.PasswordPolicy) {
+	passwordPolicy = policy
+}
+
+// rateLimited responds with 429 and when to try again
+func rateLimited(c echo.Context, retryAfter time.Duration) error {
+	c.Response().Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	return c.JSON(http.StatusTooManyRequests, echo.Map{"error": "Too many attempts, please try again later"})
+}
+
 // @Summary Register new user
-// @Description Register new user
+// @Description Register a new customer account. Accounts always get the role "user"; other roles are granted by an admin. A code to verify the phone number is sent over WhatsApp.
 // @Tags Register and Login
 // @Accept json
 // @Produce json
 // @Param request body RegisterRequest true "User registration request body"
 // @Success 201 {object} UserResponse
-// @Failure 400 {object} map[string]interface{} "Invalid input"
+// @Failure 400 {object} map[string]interface{} "Invalid input or password does not meet the password policy"
 // @Failure 409 {object} map[string]interface{} "User already exists with this email"
+// @Failure 429 {object} map[string]interface{} "Too many registrations from this IP or for this email"
 // @Failure 500 {object} map[string]interface{} "Failed to hash password or register user"
 // @Router /register [post]
 func RegisterUser(c echo.Context) error {
@@ -59,6 +88,14 @@ func RegisterUser(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
 	}
 
+	if allowed, retryAfter := registerAccountLimiter.Allow(strings.ToLower(req.Email)); !allowed {
+		return rateLimited(c, retryAfter)
+	}
+
+	if err := passwordPolicy.Validate(req.Password, req.Email); err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
+	}
+
 	// Check if the user already exists by email
 	var existingUser models.User
 	if err := database.DB.Where("email = ?", req.Email).First(&existingUser).Error; err == nil {
@@ -78,7 +115,13 @@ func RegisterUser(c echo.Context) error {
 		Password:    string(hashedPassword),
 		PhoneNumber: req.PhoneNumber,
 		Address:     req.Address,
-		Role:        req.Role,
+		Role:        services.RoleUser, // Other roles are granted by an admin or an invitation
+	}
+	if req.PreferredChannel != "" {
+		user.PreferredChannel = req.PreferredChannel
+	}
+	if req.PreferredLanguage != "" {
+		user.PreferredLanguage = req.PreferredLanguage
 	}
 
 	// Save user to database
@@ -86,6 +129,11 @@ func RegisterUser(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, echo.Map{"error": err.Error()})
 	}
 
+	// Prove the phone number belongs to the user, the code is entered at /users/phone/verify
+	if err := sendOTP(user, services.OTPPhoneVerification, ""); err != nil {
+		log.Printf("Failed to send phone verification code to user %d: %v", user.UserID, err)
+	}
+
 	return c.JSON(http.StatusCreated, UserResponse{
 		ID:          user.UserID,
 		Email:       user.Email,
@@ -96,14 +144,17 @@ func RegisterUser(c echo.Context) error {
 }
 
 // @Summary User login
-// @Description Login user and return JWT token
+// @Description Login user and return a short-lived JWT access token and a refresh token
 // @Tags Register and Login
 // @Accept json
 // @Produce json
 // @Param request body LoginRequest true "User login request body"
 // @Success 200 {object} UserResponse
+// @Success 202 {object} map[string]interface{} "Two-factor login: challenge token for /login/otp"
 // @Failure 400 {object} map[string]interface{} "Invalid input"
-// @Failure 401 {object} map[string]interface{} "Invalid email or password"
+// @Failure 401 {object} map[string]interface{} "Invalid email or password, or account temporarily locked"
+// @Failure 403 {object} map[string]interface{} "Account suspended"
+// @Failure 429 {object} map[string]interface{} "Too many login attempts, or a code was sent less than a minute ago"
 // @Failure 500 {object} map[string]interface{} "Failed to generate token"
 // @Router /login [post]
 func LoginUser(c echo.Context) error {
@@ -116,39 +167,87 @@ func LoginUser(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, echo.Map{"error": err.Error()})
 	}
 
+	if allowed, retryAfter := loginAccountLimiter.Allow(strings.ToLower(req.Email)); !allowed {
+		return rateLimited(c, retryAfter)
+	}
+
 	// Find user by email
 	var user models.User
 	if err := database.DB.Where("email = ?", req.Email).First(&user).Error; err != nil {
 		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "Invalid email or password"})
 	}
 
+	// Locked accounts are not even checked, so guessing cannot go on during the lockout. They get the
+	// same answer as a wrong email or password so the lockout does not reveal that the account exists;
+	// the owner of the account learns when it opens again from the account-locked notification.
+	now := time.Now()
+	if services.IsLocked(user, now) {
+		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "Invalid email or password"})
+	}
+
 	// Compare password
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		lockedUntil, err := services.RecordLoginFailure(database.DB, user.UserID, now)
+		if err != nil {
+			log.Printf("Failed to record failed login of user %d: %v", user.UserID, err)
+		}
+		if lockedUntil != nil {
+			notifyUser(user, services.EventAccountLocked, echo.Map{"LockedUntil": *lockedUntil})
+		}
 		return c.JSON(http.StatusUnauthorized, echo.Map{"error": "Invalid email or password"})
 	}
 
-	// Generate JWT token
-	token, err := generateJWT(user)
+	if user.SuspendedAt != nil {
+		return c.JSON(http.StatusForbidden, echo.Map{"error": "Account suspended"})
+	}
+
+	if user.FailedLoginAttempts > 0 || user.LockedUntil != nil {
+		if err := services.ResetLoginFailures(database.DB, user.UserID); err != nil {
+			log.Printf("Failed to reset failed logins of user %d: %v", user.UserID, err)
+		}
+	}
+
+	// Accounts with two-factor login get a WhatsApp code and finish at /login/otp
+	if user.TwoFactorEnabled {
+		challenge, challengeHash, err := services.NewSecretToken()
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to generate token"})
+		}
+		if err := sendOTP(user, services.OTPLogin, challengeHash); err != nil {
+			return otpError(c, err)
+		}
+
+		return c.JSON(http.StatusAccepted, echo.Map{
+			"message":         "Enter the code sent over WhatsApp at /login/otp",
+			"otp_required":    true,
+			"challenge_token": challenge,
+			"expires_in":      int64(services.OTPTTL.Seconds()),
+		})
+	}
+
+	// Start a new session with its own refresh token family
+	session, err := services.StartSession(database.DB, user.UserID, "", now, tokenConfig)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to generate token"})
 	}
 
-	return c.JSON(http.StatusOK, UserResponse{
-		ID:          user.UserID,
-		Email:       user.Email,
-		Address:     user.Address,
-		PhoneNumber: user.PhoneNumber,
-		Role:        user.Role,
-		Token:       token,
-	})
+	response, err := sessionResponse(user, session)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{"error": "Failed to generate token"})
+	}
+
+	return c.JSON(http.StatusOK, response)
 }
 
-// Fungsi untuk menghasilkan JWT
-func generateJWT(user models.User) (string, error) {
+// Fungsi untuk menghasilkan JWT access token dari session
+func generateJWT(user models.User, session services.Session) (string, error) {
 	claims := jwt.MapClaims{
 		"user_id": user.UserID,
 		"email":   user.Email,
-		"exp":     time.Now().Add(time.Hour * 72).Unix(),
+		"role":    user.Role,
+		"jti":     session.AccessJTI,
+		"sid":     session.FamilyID,
+		"exp":     session.AccessExpiresAt.Unix(),
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
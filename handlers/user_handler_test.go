@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"jakarta-luxury-rent-car/models"
 	"log"
 	"net/http"
 	"net/http/httptest"
@@ -13,7 +12,6 @@ import (
 	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
-	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
@@ -29,29 +27,9 @@ func setupTestingInitDB() {
 		"jakarta-luxury-rent-car",
 		"5432")
 
-	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	DB, err = openTestDB(dsn, &gorm.Config{})
 	if err != nil {
-		log.Fatal("Failed to connect to database: ", err)
-	}
-
-	if DB == nil {
-		log.Fatal("Database connection is nil after initialization")
-	} else {
-		fmt.Println("Database initialized successfully.")
-	}
-
-	err = DB.AutoMigrate(
-		&models.User{},
-		&models.Car{},
-		&models.Driver{},
-		&models.EventPackage{},
-		&models.RentalHistory{},
-		&models.CallAssistance{},
-		&models.Membership{},
-	)
-
-	if err != nil {
-		log.Fatal("Failed to auto migrate: ", err)
+		log.Fatal("Failed to set up database: ", err)
 	}
 
 	fmt.Println("Database migrations completed successfully.")
@@ -87,7 +65,7 @@ func TestRegisterUser_Success(t *testing.T) {
 	}
 	e.Validator = &CustomValidator{validator: validatorInstance}
 
-	reqBody := `{"email":"test10@example.com","password":"testtest","phone_number":"1234567890","address":"Jalan 123", "role":"user"}`
+	reqBody := `{"email":"test10@example.com","password":"kuda-lumping-sore","phone_number":"1234567890","address":"Jalan 123", "role":"owner"}`
 
 	req := httptest.NewRequest(http.MethodPost, "/register", bytes.NewReader([]byte(reqBody)))
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
@@ -117,6 +95,7 @@ func TestRegisterUser_Success(t *testing.T) {
 	assert.Equal(t, "test10@example.com", userResponse.Email)
 	assert.Equal(t, "1234567890", userResponse.PhoneNumber)
 	assert.Equal(t, "Jalan 123", userResponse.Address)
+	// Registration never grants the requested role
 	assert.Equal(t, "user", userResponse.Role)
 
 	// Check that the user ID is not empty
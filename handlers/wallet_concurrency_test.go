@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"jakarta-luxury-rent-car/database"
+	"jakarta-luxury-rent-car/models"
+	"jakarta-luxury-rent-car/services"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWalletConcurrentTopUpAndPayment(t *testing.T) {
+	setupTestDB(t)
+
+	e := echo.New()
+	e.Validator = &CustomValidator{validator: validator.New()}
+
+	suffix := time.Now().UnixNano()
+
+	// MakingPayment notifies the owner after paying
+	var owner models.User
+	if database.DB.Where("role = ?", "owner").Limit(1).Find(&owner).RowsAffected == 0 {
+		createTestUser(t, models.User{
+			Email:       fmt.Sprintf("owner-%d@example.com", suffix),
+			PhoneNumber: "620000000000",
+			Address:     "Jakarta",
+			Role:        "owner",
+		}, "owner-password")
+	}
+
+	customer := createTestUser(t, models.User{
+		Email:       fmt.Sprintf("wallet-%d@example.com", suffix),
+		PhoneNumber: "620000000001",
+		Address:     "Jakarta",
+		Role:        "user",
+	}, "wallet-password")
+
+	car := models.Car{Name: "Test Car", StockAvailability: 10, RentalCosts: 30, Category: "Sedan", Make: "Test", Model: "Test", Transmission: "Automatic", Year: 2024, FuelType: "Gas", Class: "Test"}
+	assert.NoError(t, database.DB.Create(&car).Error)
+
+	const (
+		topUps      = 20
+		topUpAmount = 10.0
+		rentals     = 10
+		rentalCost  = 30.0
+	)
+
+	rentalIDs := make([]uint, 0, rentals)
+	for i := 0; i < rentals; i++ {
+		rental := models.RentalHistory{UserID: customer.UserID, CarID: car.CarID, RentalDate: time.Now().Add(48 * time.Hour), TotalCost: rentalCost, Status: services.RentalStatusBook}
+		assert.NoError(t, database.DB.Create(&rental).Error)
+		rentalIDs = append(rentalIDs, rental.RentalID)
+	}
+
+	gateway := services.NewFakeGateway()
+	SetPaymentGateway(gateway)
+	t.Setenv("XENDIT_CALLBACK_TOKEN", "test-callback-token")
+
+	// Top ups only create invoices, nothing is credited yet
+	var invoices []models.Invoice
+	for i := 0; i < topUps; i++ {
+		c, rec := newUserContext(e, http.MethodPost, "/users/topup", fmt.Sprintf(`{"deposit_amount": %.2f}`, topUpAmount), customer.UserID)
+		TopUp(c)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+	}
+	database.DB.Joins("JOIN deposit_top_ups ON deposit_top_ups.deposit_top_up_id = invoices.deposit_top_up_id").
+		Where("deposit_top_ups.user_id = ?", customer.UserID).
+		Find(&invoices)
+	assert.Len(t, invoices, topUps)
+
+	var unpaid models.User
+	database.DB.First(&unpaid, customer.UserID)
+	assert.Equal(t, 0.0, unpaid.DepositAmount)
+
+	var (
+		wg            sync.WaitGroup
+		mu            sync.Mutex
+		callbackCodes []int
+		paymentCodes  []int
+	)
+	start := make(chan struct{})
+
+	// Every paid callback is delivered twice to check replays are not credited again
+	for _, invoice := range invoices {
+		_, err := gateway.SimulatePaid(invoice.XenditInvoiceID)
+		assert.NoError(t, err)
+
+		body := fmt.Sprintf(`{"id": %q, "external_id": %q, "status": "PAID", "amount": %.2f}`, invoice.XenditInvoiceID, invoice.ExternalID, invoice.Amount)
+		for delivery := 0; delivery < 2; delivery++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-start
+				c, rec := newUserContext(e, http.MethodPost, "/payments/xendit/callback", body, 0)
+				c.Request().Header.Set("x-callback-token", "test-callback-token")
+				XenditInvoiceCallback(c)
+				mu.Lock()
+				callbackCodes = append(callbackCodes, rec.Code)
+				mu.Unlock()
+			}()
+		}
+	}
+
+	for _, rentalID := range rentalIDs {
+		wg.Add(1)
+		go func(rentalID uint) {
+			defer wg.Done()
+			<-start
+			c, rec := newUserContext(e, http.MethodPost, "/users/making-payment", fmt.Sprintf(`{"rental_id": %d}`, rentalID), customer.UserID)
+			MakingPayment(c)
+			mu.Lock()
+			paymentCodes = append(paymentCodes, rec.Code)
+			mu.Unlock()
+		}(rentalID)
+	}
+
+	close(start)
+	wg.Wait()
+
+	for _, code := range callbackCodes {
+		assert.Equal(t, http.StatusOK, code)
+	}
+
+	paid := 0
+	for _, code := range paymentCodes {
+		if code == http.StatusOK {
+			paid++
+			continue
+		}
+		assert.Equal(t, http.StatusBadRequest, code, "payments may only fail for insufficient funds")
+	}
+
+	var reloaded models.User
+	assert.NoError(t, database.DB.First(&reloaded, customer.UserID).Error)
+
+	// No update is lost and the balance never goes below zero
+	assert.Equal(t, topUps*topUpAmount-float64(paid)*rentalCost, reloaded.DepositAmount)
+	assert.GreaterOrEqual(t, reloaded.DepositAmount, 0.0)
+
+	var ledgerBalance float64
+	database.DB.Model(&models.WalletTransaction{}).Where("user_id = ?", customer.UserID).Select("COALESCE(SUM(amount), 0)").Scan(&ledgerBalance)
+	assert.Equal(t, reloaded.DepositAmount, ledgerBalance)
+
+	var topUpEntries int64
+	database.DB.Model(&models.WalletTransaction{}).Where("user_id = ? AND type = ?", customer.UserID, services.WalletTopUp).Count(&topUpEntries)
+	assert.Equal(t, int64(topUps), topUpEntries)
+
+	var paidRentals int64
+	database.DB.Model(&models.RentalHistory{}).Where("rental_id IN ? AND status = ?", rentalIDs, services.RentalStatusPaid).Count(&paidRentals)
+	assert.Equal(t, int64(paid), paidRentals)
+}
+
+func TestTopUpRejectsNonPositiveAmount(t *testing.T) {
+	e := echo.New()
+	e.Validator = &CustomValidator{validator: validator.New()}
+
+	for _, body := range []string{`{"deposit_amount": 0}`, `{"deposit_amount": -50}`} {
+		c, rec := newUserContext(e, http.MethodPost, "/users/topup", body, 1)
+		TopUp(c)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, body)
+	}
+}
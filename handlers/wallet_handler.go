@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"jakarta-luxury-rent-car/database"
+	"jakarta-luxury-rent-car/models"
+	"jakarta-luxury-rent-car/services"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	defaultWalletPageSize = 20
+	maxWalletPageSize     = 100
+)
+
+// @Summary List wallet transactions
+// @Description List the top ups, rental payments, refunds, penalties and adjustments on your deposit, newest first
+// @Tags Role User
+// @Accept json
+// @Produce json
+// @Param page query int false "Page number, starting at 1"
+// @Param limit query int false "Transactions per page (default 20, max 100)"
+// @Success 200 {object} map[string]interface{} "Page of wallet transactions with the current balance"
+// @Failure 400 {object} map[string]interface{} "Invalid page or limit"
+// @Failure 404 {object} map[string]interface{} "User not found"
+// @Failure 500 {object} map[string]interface{} "Failed to fetch wallet transactions"
+// @Router /users/wallet/transactions [get]
+// @Security BearerAuth
+func GetWalletTransactions(c echo.Context) error {
+	page, limit, err := parsePagination(c, defaultWalletPageSize, maxWalletPageSize)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, echo.Map{
+			"message": "Invalid page or limit",
+			"error":   err.Error(),
+		})
+	}
+
+	// Extract user ID from JWT token
+	user := c.Get("user").(*jwt.Token)
+	claims := user.Claims.(*jwt.MapClaims)
+	userID := uint((*claims)["user_id"].(float64))
+
+	var userModel models.User
+	if err := database.DB.First(&userModel, userID).Error; err != nil {
+		return c.JSON(http.StatusNotFound, echo.Map{
+			"message": "User not found",
+			"error":   err.Error(),
+		})
+	}
+
+	var total int64
+	if err := database.DB.Model(&models.WalletTransaction{}).Where("user_id = ?", userID).Count(&total).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": "Failed to fetch wallet transactions",
+			"error":   err.Error(),
+		})
+	}
+
+	var transactions []models.WalletTransaction
+	if err := database.DB.Where("user_id = ?", userID).
+		Order("wallet_transaction_id DESC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&transactions).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": "Failed to fetch wallet transactions",
+			"error":   err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"balance": userModel.DepositAmount,
+		"page":    page,
+		"limit":   limit,
+		"total":   total,
+		"data":    transactions,
+	})
+}
+
+// @Summary Wallet reconciliation report
+// @Description Compare every user's deposit balance with the sum of their wallet ledger. A non-zero difference means the balance was changed outside the ledger.
+// @Tags Role Owner
+// @Accept json
+// @Produce json
+// @Param mismatched_only query bool false "Only list users whose balance does not match the ledger"
+// @Success 200 {object} map[string]interface{} "Reconciliation rows and the number of mismatches"
+// @Failure 403 {object} map[string]interface{} "Permission denied"
+// @Failure 500 {object} map[string]interface{} "Failed to reconcile wallets"
+// @Router /owner/wallet/reconciliation [get]
+// @Security BearerAuth
+func WalletReconciliation(c echo.Context) error {
+	rows, err := services.ReconcileWallets(database.DB)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, echo.Map{
+			"message": "Failed to reconcile wallets",
+			"error":   err.Error(),
+		})
+	}
+
+	mismatchedOnly, _ := strconv.ParseBool(c.QueryParam("mismatched_only"))
+
+	report := []services.WalletReconciliation{}
+	mismatches := 0
+	for _, row := range rows {
+		if row.Difference != 0 {
+			mismatches++
+		} else if mismatchedOnly {
+			continue
+		}
+		report = append(report, row)
+	}
+
+	return c.JSON(http.StatusOK, echo.Map{
+		"mismatches": mismatches,
+		"data":       report,
+	})
+}
+
+// parsePagination reads the page and limit query parameters
+func parsePagination(c echo.Context, defaultLimit, maxLimit int) (int, int, error) {
+	page, limit := 1, defaultLimit
+
+	if value := c.QueryParam("page"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			return 0, 0, errors.New("page must be a positive number")
+		}
+		page = parsed
+	}
+
+	if value := c.QueryParam("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxLimit {
+			return 0, 0, fmt.Errorf("limit must be between 1 and %d", maxLimit)
+		}
+		limit = parsed
+	}
+
+	return page, limit, nil
+}
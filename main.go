@@ -1,8 +1,10 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
+	"time"
 
 	_ "jakarta-luxury-rent-car/docs"
 
@@ -15,6 +17,7 @@ import (
 	"jakarta-luxury-rent-car/database"
 	"jakarta-luxury-rent-car/handlers"
 	"jakarta-luxury-rent-car/middlewares"
+	"jakarta-luxury-rent-car/services"
 )
 
 // struct untuk validator custom
@@ -49,38 +52,149 @@ func main() {
 	// Initialize the database
 	database.InitDB()
 
+	// Select the payment gateway (PAYMENT_GATEWAY=xendit|fake)
+	paymentGateway, err := services.NewPaymentGatewayFromEnv()
+	if err != nil {
+		log.Fatal("Failed to configure payment gateway: ", err)
+	}
+	handlers.SetPaymentGateway(paymentGateway)
+
+	// Select the notification channels (Twilio WhatsApp/SMS, SMTP email, log)
+	notificationService, err := services.NewNotificationServiceFromEnv()
+	if err != nil {
+		log.Fatal("Failed to configure notifications: ", err)
+	}
+	handlers.SetNotificationService(notificationService)
+
+	// Minimum length and rejected passwords (PASSWORD_MIN_LENGTH, PASSWORD_BLOCKLIST_FILE)
+	passwordPolicy, err := services.LoadPasswordPolicy()
+	if err != nil {
+		log.Fatal("Failed to configure the password policy: ", err)
+	}
+	handlers.SetPasswordPolicy(passwordPolicy)
+
+	// Refund tiers of cancelled bookings (CANCELLATION_POLICY)
+	cancellationPolicy, err := services.LoadCancellationPolicy()
+	if err != nil {
+		log.Fatal("Failed to configure the cancellation policy: ", err)
+	}
+	handlers.SetCancellationPolicy(cancellationPolicy)
+
+	// How long an unpaid booking holds its car (PAYMENT_HOLD_WINDOW)
+	paymentHoldWindow, err := services.LoadPaymentHoldWindow()
+	if err != nil {
+		log.Fatal("Failed to configure the payment hold window: ", err)
+	}
+	handlers.SetPaymentHoldWindow(paymentHoldWindow)
+
+	// Lifetimes of access and refresh tokens (ACCESS_TOKEN_TTL, REFRESH_TOKEN_TTL)
+	tokenConfig, err := services.LoadTokenConfig()
+	if err != nil {
+		log.Fatal("Failed to configure token lifetimes: ", err)
+	}
+	handlers.SetTokenConfig(tokenConfig)
+
+	// Deliver queued booking notifications, invoices and reminders in the background
+	dispatcher := services.NewOutboxDispatcher(database.DB, handlers.OutboxHandlers())
+	go dispatcher.Run(context.Background())
+
+	// Queue pickup, return, overdue and unpaid invoice reminders
+	scheduler := services.NewReminderScheduler(database.DB, services.DefaultReminderConfig())
+	go scheduler.Run(context.Background())
+
+	// Expire bookings that were not paid within the payment hold window
+	expiryJob := services.NewBookingExpiryJob(database.DB)
+	go expiryJob.Run(context.Background())
+
 	// Create a new Echo instance
 	e := echo.New()
 
 	// Set custom validator
 	e.Validator = &CustomValidator{validator: validator.New()} // Register custom validator
 
+	// Client IP for rate limits and the audit log (TRUSTED_PROXIES)
+	e.IPExtractor, err = middlewares.IPExtractorFromEnv()
+	if err != nil {
+		log.Fatal("Failed to configure trusted proxies: ", err)
+	}
+
 	// Middleware
 	e.Use(middleware.Logger())
 	e.Use(middleware.Recover())
 	e.Use(middleware.CORS())
 
+	// Per-IP limits of registration and of every way to guess a password or code
+	registerLimit := middlewares.RateLimitByIP(services.NewRateLimiter(10, time.Hour))
+	loginLimit := middlewares.RateLimitByIP(services.NewRateLimiter(20, time.Minute))
+
 	// Routes
 	e.GET("/swagger/*", echoSwagger.WrapHandler)
-	e.POST("/register", handlers.RegisterUser)
-	e.POST("/login", handlers.LoginUser)
+	e.POST("/register", handlers.RegisterUser, registerLimit)
+	e.POST("/login", handlers.LoginUser, loginLimit)
+	e.POST("/login/otp", handlers.LoginOTP, loginLimit)
+	e.POST("/token/refresh", handlers.RefreshToken)
+	e.POST("/password/forgot", handlers.ForgotPassword, loginLimit)
+	e.POST("/password/reset", handlers.ResetPassword, loginLimit)
+	e.POST("/invitations/accept", handlers.AcceptInvitation)
 	e.GET("/cars", handlers.GetLuxuryCars)
 	e.GET("/drivers", handlers.GetDriver)
 	e.GET("/packages", handlers.GetEventPackage)
+	e.POST("/payments/xendit/callback", handlers.XenditInvoiceCallback)
+	e.POST("/payments/xendit/refund-callback", handlers.XenditRefundCallback)
 
 	// Secure Routes
 	r := e.Group("")
 	r.Use(middlewares.JWTMiddleware())
+	r.Use(middlewares.ActiveAccount())
+	r.POST("/logout", handlers.Logout)
+	r.POST("/logout-all", handlers.LogoutAll)
 	r.POST("/users/register-membership", handlers.RegisterMembership)
 	r.GET("/users/get-membership", handlers.GetMembership)
 	r.GET("/users/get-deposit", handlers.GetDepositAmount)
 	r.POST("/users/topup", handlers.TopUp)
+	r.GET("/users/topups", handlers.GetTopUps)
+	r.GET("/users/wallet/transactions", handlers.GetWalletTransactions)
 	r.POST("/users/booking", handlers.BookCar)
+	r.POST("/users/booking/quote", handlers.QuoteBooking)
 	r.POST("/users/making-payment", handlers.MakingPayment)
 	r.POST("/users/call-assistance", handlers.CallAssistance)
-
-	r.POST("/owner/approve-booking", handlers.ApprovalBooking)
-	r.GET("/owner/report", handlers.Report)
+	r.PUT("/users/bookings/:id", handlers.ModifyBooking)
+	r.POST("/users/bookings/:id/cancel", handlers.CancelBooking)
+	r.GET("/users/refunds", handlers.GetMyRefunds)
+	r.PUT("/users/notification-preferences", handlers.UpdateNotificationPreference)
+	r.POST("/users/phone/verification-code", handlers.SendPhoneVerificationCode)
+	r.POST("/users/phone/verify", handlers.VerifyPhone)
+	r.PUT("/users/two-factor", handlers.UpdateTwoFactor, loginLimit)
+
+	// Back office routes, each guarded by the permission it needs
+	owner := r.Group("/owner")
+	owner.POST("/approve-booking", handlers.ApprovalBooking, middlewares.RequirePermission(services.PermApproveBookings))
+	owner.GET("/report", handlers.Report, middlewares.RequirePermission(services.PermViewReports))
+	owner.GET("/wallet/reconciliation", handlers.WalletReconciliation, middlewares.RequirePermission(services.PermReconcileWallet))
+	owner.GET("/refunds", handlers.GetRefunds, middlewares.RequirePermission(services.PermManageRefunds))
+	owner.POST("/refunds/:id/retry", handlers.RetryRefund, middlewares.RequirePermission(services.PermManageRefunds))
+	owner.GET("/fleet-units", handlers.GetFleetUnits, middlewares.RequirePermission(services.PermManageFleet))
+	owner.POST("/fleet-units", handlers.CreateFleetUnit, middlewares.RequirePermission(services.PermManageFleet))
+	owner.PUT("/fleet-units/:id", handlers.UpdateFleetUnit, middlewares.RequirePermission(services.PermManageFleet))
+	owner.POST("/rentals/:id/return", handlers.ReturnCar, middlewares.RequirePermission(services.PermCheckInRentals))
+	owner.GET("/pricing", handlers.GetPricing, middlewares.RequirePermission(services.PermManagePricing))
+	owner.PUT("/pricing/cars/:id", handlers.UpdateCarRate, middlewares.RequirePermission(services.PermManagePricing))
+	owner.PUT("/pricing/addons/:code", handlers.UpdateAddonPrice, middlewares.RequirePermission(services.PermManagePricing))
+	owner.PUT("/pricing/settings", handlers.UpdatePricingSettings, middlewares.RequirePermission(services.PermManagePricing))
+	owner.POST("/pricing/holidays", handlers.CreateHoliday, middlewares.RequirePermission(services.PermManagePricing))
+	owner.DELETE("/pricing/holidays/:date", handlers.DeleteHoliday, middlewares.RequirePermission(services.PermManagePricing))
+	owner.GET("/templates", handlers.GetMessageTemplates, middlewares.RequirePermission(services.PermManageTemplates))
+	owner.PUT("/templates/:event/:locale", handlers.UpdateMessageTemplate, middlewares.RequirePermission(services.PermManageTemplates))
+	owner.DELETE("/templates/:event/:locale", handlers.ResetMessageTemplate, middlewares.RequirePermission(services.PermManageTemplates))
+	owner.GET("/audit", handlers.GetAuditLogs, middlewares.RequirePermission(services.PermViewAuditLog))
+
+	// User management, admins only
+	admin := r.Group("/admin", middlewares.RequirePermission(services.PermManageUsers))
+	admin.GET("/users", handlers.GetUsers)
+	admin.PUT("/users/:id/role", handlers.UpdateUserRole)
+	admin.POST("/users/:id/suspend", handlers.SuspendUser)
+	admin.POST("/users/:id/reactivate", handlers.ReactivateUser)
+	admin.POST("/invitations", handlers.InviteUser)
 
 	// Start the server
 	port := os.Getenv("PORT")
@@ -0,0 +1,58 @@
+package middlewares
+
+import (
+	"net/http"
+	"time"
+
+	"jakarta-luxury-rent-car/database"
+	"jakarta-luxury-rent-car/models"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+)
+
+// ActiveAccount rejects tokens of suspended accounts and tokens whose role claim no longer
+// matches the account, so suspensions and role changes apply without waiting for the token to expire.
+// It must run after JWTMiddleware.
+func ActiveAccount() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			token := c.Get("user").(*jwt.Token)
+			claims := token.Claims.(*jwt.MapClaims)
+			userID, ok := (*claims)["user_id"].(float64)
+			if !ok {
+				return c.JSON(http.StatusUnauthorized, echo.Map{
+					"message": "Invalid token",
+				})
+			}
+
+			var account struct {
+				Role        string
+				SuspendedAt *time.Time
+			}
+			if err := database.DB.Model(&models.User{}).
+				Select("role", "suspended_at").
+				Where("user_id = ?", uint(userID)).
+				Take(&account).Error; err != nil {
+				return c.JSON(http.StatusUnauthorized, echo.Map{
+					"message": "User not found",
+					"error":   err.Error(),
+				})
+			}
+
+			if account.SuspendedAt != nil {
+				return c.JSON(http.StatusForbidden, echo.Map{
+					"message": "Account suspended",
+				})
+			}
+
+			if role := CurrentRole(c); role != "" && role != account.Role {
+				return c.JSON(http.StatusUnauthorized, echo.Map{
+					"message": "Your role has changed, please log in again",
+				})
+			}
+
+			return next(c)
+		}
+	}
+}
@@ -2,23 +2,58 @@ package middlewares
 
 import (
 	"log"
+	"net/http"
 	"os"
 
+	"jakarta-luxury-rent-car/database"
+	"jakarta-luxury-rent-car/services"
+
 	"github.com/golang-jwt/jwt/v5"
 	jwtMiddleware "github.com/labstack/echo-jwt/v4"
 	"github.com/labstack/echo/v4"
 )
 
+// JWTMiddleware validates the access token and rejects tokens revoked by logout, by their jti claim
 func JWTMiddleware() echo.MiddlewareFunc {
 	signingKey := os.Getenv("JWT_SECRET")
 	if signingKey == "" {
 		log.Fatal("JWT_SECRET environment variable is not set or is empty")
 	}
 
-	return jwtMiddleware.WithConfig(jwtMiddleware.Config{
+	validate := jwtMiddleware.WithConfig(jwtMiddleware.Config{
 		SigningKey: []byte(signingKey),
 		NewClaimsFunc: func(c echo.Context) jwt.Claims {
 			return new(jwt.MapClaims)
 		},
 	})
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return validate(func(c echo.Context) error {
+			token := c.Get("user").(*jwt.Token)
+			claims := token.Claims.(*jwt.MapClaims)
+
+			// Tokens issued before logout existed cannot be revoked, so they are no longer accepted
+			jti, _ := (*claims)["jti"].(string)
+			if jti == "" {
+				return c.JSON(http.StatusUnauthorized, echo.Map{
+					"message": "Token has no ID, please log in again",
+				})
+			}
+
+			revoked, err := services.IsTokenRevoked(database.DB, jti)
+			if err != nil {
+				return c.JSON(http.StatusInternalServerError, echo.Map{
+					"message": "Failed to check token",
+					"error":   err.Error(),
+				})
+			}
+			if revoked {
+				return c.JSON(http.StatusUnauthorized, echo.Map{
+					"message": "Token has been revoked",
+				})
+			}
+
+			return next(c)
+		})
+	}
 }
@@ -0,0 +1,52 @@
+package middlewares
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// IPExtractorFromEnv decides where c.RealIP() comes from. Without TRUSTED_PROXIES the
+// peer address is used and X-Forwarded-For is ignored, so clients cannot pick their own IP.
+// TRUSTED_PROXIES is a comma separated list of CIDRs or IPs of the load balancers in front
+// of the app; X-Forwarded-For is then read up to the first hop that is not one of them.
+func IPExtractorFromEnv() (echo.IPExtractor, error) {
+	value := strings.TrimSpace(os.Getenv("TRUSTED_PROXIES"))
+	if value == "" {
+		return echo.ExtractIPDirect(), nil
+	}
+
+	options := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+	for _, entry := range strings.Split(value, ",") {
+		ipRange, err := parseTrustedProxy(strings.TrimSpace(entry))
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, echo.TrustIPRange(ipRange))
+	}
+	return echo.ExtractIPFromXFFHeader(options...), nil
+}
+
+// parseTrustedProxy accepts a CIDR or a single IP address
+func parseTrustedProxy(entry string) (*net.IPNet, error) {
+	if _, ipRange, err := net.ParseCIDR(entry); err == nil {
+		return ipRange, nil
+	}
+	ip := net.ParseIP(entry)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid TRUSTED_PROXIES entry %q", entry)
+	}
+	bits := 128
+	if ip.To4() != nil {
+		ip = ip.To4()
+		bits = 32
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+}
@@ -0,0 +1,26 @@
+package middlewares
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"jakarta-luxury-rent-car/services"
+
+	"github.com/labstack/echo/v4"
+)
+
+// RateLimitByIP rejects requests from a client IP that goes over limiter with 429 and a Retry-After header
+func RateLimitByIP(limiter *services.RateLimiter) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if allowed, retryAfter := limiter.Allow("ip:" + c.RealIP()); !allowed {
+				c.Response().Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+				return c.JSON(http.StatusTooManyRequests, echo.Map{
+					"message": "Too many requests, please try again later",
+				})
+			}
+			return next(c)
+		}
+	}
+}
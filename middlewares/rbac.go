@@ -0,0 +1,51 @@
+package middlewares
+
+import (
+	"fmt"
+	"net/http"
+
+	"jakarta-luxury-rent-car/services"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+)
+
+// CurrentRole returns the role claim of the authenticated user, "" when the token has none
+func CurrentRole(c echo.Context) string {
+	token, ok := c.Get("user").(*jwt.Token)
+	if !ok {
+		return ""
+	}
+	claims, ok := token.Claims.(*jwt.MapClaims)
+	if !ok {
+		return ""
+	}
+	role, _ := (*claims)["role"].(string)
+	return role
+}
+
+// RequirePermission only lets requests through whose role claim grants permission.
+// It must run after JWTMiddleware.
+func RequirePermission(permission string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			role := CurrentRole(c)
+			if role == "" {
+				// Tokens issued before roles were added to the claims
+				return c.JSON(http.StatusForbidden, echo.Map{
+					"message": "Permission denied",
+					"error":   "token has no role, please log in again",
+				})
+			}
+
+			if !services.HasPermission(role, permission) {
+				return c.JSON(http.StatusForbidden, echo.Map{
+					"message": "Permission denied",
+					"error":   fmt.Sprintf("role %q does not have permission %q", role, permission),
+				})
+			}
+
+			return next(c)
+		}
+	}
+}
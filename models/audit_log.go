@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+)
+
+// AuditLog is one privileged or money-moving action. Rows are only ever inserted,
+// the database rejects updates and deletes of audit_logs.
+type AuditLog struct {
+	AuditLogID  uint      `gorm:"primaryKey;autoIncrement" json:"audit_log_id"`
+	ActorUserID *uint     `gorm:"index" json:"actor_user_id"` // Nil when the system acted, e.g. a payment callback
+	ActorRole   string    `gorm:"type:varchar(20);not null" json:"actor_role"`
+	Action      string    `gorm:"type:varchar(50);not null;index" json:"action"`
+	EntityType  string    `gorm:"type:varchar(30);not null" json:"entity_type"`
+	EntityID    string    `gorm:"type:varchar(100);not null" json:"entity_id"`
+	Before      *string   `gorm:"type:jsonb" json:"before"` // Nil when the action created the entity
+	After       *string   `gorm:"type:jsonb" json:"after"`  // Nil when the action deleted the entity
+	IPAddress   string    `gorm:"type:varchar(45)" json:"ip_address"`
+	CreatedAt   time.Time `gorm:"not null;index" json:"created_at"`
+}
@@ -3,7 +3,7 @@ package models
 type Car struct {
 	CarID             uint    `gorm:"primaryKey;autoIncrement" json:"car_id"`
 	Name              string  `gorm:"not null" json:"name"`
-	StockAvailability int     `gorm:"not null;check:stock_availability >= 0" json:"stock_availability"`
+	StockAvailability int     `gorm:"not null;check:stock_availability >= 0" json:"stock_availability"` // Number of units in the fleet; free units per date window come from rental histories
 	RentalCosts       float64 `gorm:"type:numeric(10,2);not null" json:"rental_costs"`
 	Category          string  `gorm:"not null" json:"category"`
 	Make              string  `gorm:"not null" json:"make"`
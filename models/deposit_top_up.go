@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+)
+
+type DepositTopUp struct {
+	DepositTopUpID uint       `gorm:"primaryKey;autoIncrement" json:"deposit_top_up_id"`
+	UserID         uint       `gorm:"not null;index" json:"user_id"`
+	Amount         float64    `gorm:"type:numeric(12,2);not null;check:amount > 0" json:"amount"`
+	Status         string     `gorm:"type:varchar(10);not null;default:'PENDING';check:status IN ('PENDING', 'PAID', 'EXPIRED', 'UNDERPAID')" json:"status"`
+	InvoiceURL     string     `json:"invoice_url"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+	PaidAt         *time.Time `json:"paid_at"`
+	CreatedAt      time.Time  `gorm:"not null" json:"created_at"`
+	UpdatedAt      time.Time  `gorm:"not null" json:"updated_at"`
+}
@@ -0,0 +1,11 @@
+package models
+
+type FleetUnit struct {
+	FleetUnitID  uint   `gorm:"primaryKey;autoIncrement" json:"fleet_unit_id"`
+	CarID        uint   `gorm:"not null" json:"car_id"`
+	LicensePlate string `gorm:"type:varchar(15);unique;not null" json:"license_plate"`
+	VIN          string `gorm:"column:vin;type:varchar(17);unique;not null" json:"vin"`
+	Colour       string `gorm:"not null" json:"colour"`
+	Odometer     int    `gorm:"not null;default:0;check:odometer >= 0" json:"odometer"`
+	Status       string `gorm:"type:varchar(20);not null;default:'available';check:status IN ('available', 'rented', 'maintenance', 'retired')" json:"status"`
+}
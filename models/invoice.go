@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+)
+
+type Invoice struct {
+	InvoiceID       uint       `gorm:"primaryKey;autoIncrement" json:"invoice_id"`
+	ExternalID      string     `gorm:"type:varchar(64);unique;not null" json:"external_id"`
+	XenditInvoiceID string     `gorm:"type:varchar(64)" json:"xendit_invoice_id"`
+	RentalID        *uint      `gorm:"index" json:"rental_id"`         // Set for booking and supplement invoices
+	DepositTopUpID  *uint      `gorm:"index" json:"deposit_top_up_id"` // Set for top up invoices
+	Purpose         string     `gorm:"type:varchar(20);not null;check:purpose IN ('booking', 'supplement', 'topup')" json:"purpose"`
+	Amount          float64    `gorm:"type:numeric(10,2);not null" json:"amount"`
+	Status          string     `gorm:"type:varchar(10);not null;default:'PENDING';check:status IN ('PENDING', 'PAID', 'EXPIRED', 'UNDERPAID')" json:"status"`
+	PaidAmount      float64    `gorm:"type:numeric(10,2);not null;default:0" json:"paid_amount"` // Reported by the gateway, lower than Amount when UNDERPAID
+	InvoiceURL      string     `json:"invoice_url"`
+	ExpiresAt       *time.Time `json:"expires_at"`
+	PaidAt          *time.Time `json:"paid_at"`
+	CreatedAt       time.Time  `gorm:"not null" json:"created_at"`
+	UpdatedAt       time.Time  `gorm:"not null" json:"updated_at"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+)
+
+type MessageTemplate struct {
+	MessageTemplateID uint      `gorm:"primaryKey;autoIncrement" json:"message_template_id"`
+	Event             string    `gorm:"type:varchar(50);not null;uniqueIndex:idx_message_templates_event_locale" json:"event"`
+	Locale            string    `gorm:"type:varchar(5);not null;uniqueIndex:idx_message_templates_event_locale" json:"locale"`
+	Subject           string    `gorm:"not null" json:"subject"`
+	Body              string    `gorm:"not null" json:"body"`
+	UpdatedBy         uint      `gorm:"not null" json:"updated_by"`
+	UpdatedAt         time.Time `gorm:"not null" json:"updated_at"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+)
+
+type OneTimeCode struct {
+	OneTimeCodeID uint       `gorm:"primaryKey;autoIncrement" json:"one_time_code_id"`
+	UserID        uint       `gorm:"not null;index:idx_one_time_codes_user_purpose" json:"user_id"`
+	Purpose       string     `gorm:"type:varchar(20);not null;index:idx_one_time_codes_user_purpose;check:purpose IN ('phone_verification', 'password_reset', 'login')" json:"purpose"`
+	CodeHash      string     `gorm:"not null" json:"-"`                  // bcrypt hash of the code sent to the user
+	ChallengeHash *string    `gorm:"type:char(64);unique" json:"-"`      // SHA-256 of the login challenge token, login codes only
+	Attempts      int        `gorm:"not null;default:0" json:"attempts"` // Wrong codes entered
+	ExpiresAt     time.Time  `gorm:"not null" json:"expires_at"`
+	ConsumedAt    *time.Time `json:"consumed_at"` // Used, or replaced by a newer code
+	CreatedAt     time.Time  `gorm:"not null" json:"created_at"`
+}
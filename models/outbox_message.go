@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+)
+
+type OutboxMessage struct {
+	OutboxMessageID uint       `gorm:"primaryKey;autoIncrement" json:"outbox_message_id"`
+	Kind            string     `gorm:"type:varchar(50);not null" json:"kind"`
+	Payload         string     `gorm:"type:jsonb;not null" json:"payload"`
+	Status          string     `gorm:"type:varchar(10);not null;default:'pending';check:status IN ('pending', 'delivered', 'failed')" json:"status"`
+	Attempts        int        `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt   time.Time  `gorm:"not null;index" json:"next_attempt_at"`
+	LastError       string     `json:"last_error"`
+	CreatedAt       time.Time  `gorm:"not null" json:"created_at"`
+	DeliveredAt     *time.Time `json:"delivered_at"`
+}
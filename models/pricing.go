@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+)
+
+type CarRate struct {
+	CarID       uint    `gorm:"primaryKey" json:"car_id"`
+	DailyRate   float64 `gorm:"type:numeric(10,2);not null;check:daily_rate >= 0" json:"daily_rate"`
+	WeeklyRate  float64 `gorm:"type:numeric(10,2);not null;default:0;check:weekly_rate >= 0" json:"weekly_rate"`   // 0 means 7 x daily
+	MonthlyRate float64 `gorm:"type:numeric(10,2);not null;default:0;check:monthly_rate >= 0" json:"monthly_rate"` // 0 means 30 x daily
+}
+
+type AddonPrice struct {
+	Code   string  `gorm:"primaryKey;type:varchar(30)" json:"code"`
+	Price  float64 `gorm:"type:numeric(10,2);not null;check:price >= 0" json:"price"`
+	PerDay bool    `gorm:"not null;default:false" json:"per_day"`
+}
+
+type PricingSetting struct {
+	PricingSettingID        uint    `gorm:"primaryKey" json:"-"`
+	WeekendSurchargePercent float64 `gorm:"type:numeric(5,2);not null;default:0" json:"weekend_surcharge_percent"`
+	HolidaySurchargePercent float64 `gorm:"type:numeric(5,2);not null;default:0" json:"holiday_surcharge_percent"`
+	Rounding                string  `gorm:"type:varchar(10);not null;default:'full_day';check:rounding IN ('full_day', 'hourly')" json:"rounding"`
+}
+
+type Holiday struct {
+	HolidayDate time.Time `gorm:"primaryKey;type:date" json:"holiday_date"`
+	Name        string    `gorm:"not null" json:"name"`
+}
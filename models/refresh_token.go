@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+)
+
+type RefreshToken struct {
+	RefreshTokenID  uint       `gorm:"primaryKey;autoIncrement" json:"refresh_token_id"`
+	UserID          uint       `gorm:"not null;index" json:"user_id"`
+	FamilyID        string     `gorm:"type:varchar(32);not null;index" json:"family_id"` // Every token rotated from the same login
+	TokenHash       string     `gorm:"type:char(64);unique;not null" json:"-"`           // SHA-256 of the token given to the client
+	AccessJTI       string     `gorm:"type:varchar(32);not null" json:"-"`               // Access token issued together with this refresh token
+	AccessExpiresAt time.Time  `gorm:"not null" json:"-"`
+	ExpiresAt       time.Time  `gorm:"not null" json:"expires_at"`
+	RotatedAt       *time.Time `json:"rotated_at"` // Exchanged for a new pair, using it again means it was stolen
+	RevokedAt       *time.Time `json:"revoked_at"`
+	CreatedAt       time.Time  `gorm:"not null" json:"created_at"`
+}
+
+type RevokedToken struct {
+	JTI       string    `gorm:"primaryKey;type:varchar(32)" json:"jti"`
+	UserID    uint      `gorm:"not null" json:"user_id"`
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"` // Can be deleted once the access token has expired anyway
+	RevokedAt time.Time `gorm:"not null" json:"revoked_at"`
+}
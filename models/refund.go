@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+)
+
+type Refund struct {
+	RefundID            uint       `gorm:"primaryKey;autoIncrement" json:"refund_id"`
+	RentalID            uint       `gorm:"not null;index" json:"rental_id"`
+	UserID              uint       `gorm:"not null;index" json:"user_id"`
+	Amount              float64    `gorm:"type:numeric(12,2);not null;check:amount > 0" json:"amount"`
+	Method              string     `gorm:"type:varchar(10);not null;check:method IN ('wallet', 'gateway')" json:"method"`
+	Status              string     `gorm:"type:varchar(12);not null;default:'requested';check:status IN ('requested', 'processing', 'completed', 'failed')" json:"status"`
+	Reason              string     `json:"reason"`
+	InvoiceID           *uint      `json:"invoice_id"`                                           // Paid invoice refunded through the gateway
+	ReferenceID         string     `gorm:"type:varchar(64);unique;not null" json:"reference_id"` // Sent to the gateway so retries are not paid out twice
+	GatewayRefundID     string     `gorm:"type:varchar(64)" json:"gateway_refund_id"`
+	WalletTransactionID *uint      `json:"wallet_transaction_id"` // Ledger entry of a wallet refund
+	FailureReason       string     `json:"failure_reason"`
+	CompletedAt         *time.Time `json:"completed_at"`
+	CreatedAt           time.Time  `gorm:"not null" json:"created_at"`
+	UpdatedAt           time.Time  `gorm:"not null" json:"updated_at"`
+}
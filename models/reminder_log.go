@@ -0,0 +1,12 @@
+package models
+
+import (
+	"time"
+)
+
+type ReminderLog struct {
+	ReminderLogID uint      `gorm:"primaryKey;autoIncrement" json:"reminder_log_id"`
+	RentalID      uint      `gorm:"not null;uniqueIndex:idx_reminder_logs_rental_kind" json:"rental_id"`
+	Kind          string    `gorm:"type:varchar(20);not null;uniqueIndex:idx_reminder_logs_rental_kind;check:kind IN ('pickup', 'return', 'overdue', 'unpaid_invoice')" json:"kind"`
+	SentAt        time.Time `gorm:"not null" json:"sent_at"`
+}
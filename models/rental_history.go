@@ -8,14 +8,22 @@ type RentalHistory struct {
 	RentalID          uint       `gorm:"primaryKey;autoIncrement" json:"rental_id"`
 	UserID            uint       `gorm:"not null" json:"user_id"`
 	CarID             uint       `gorm:"not null" json:"car_id"`
+	FleetUnitID       *uint      `json:"fleet_unit_id"`
 	DriverID          *uint      `json:"driver_id"`
 	RentalDate        time.Time  `gorm:"not null" json:"rental_date"`
 	ReturnDate        *time.Time `json:"return_date"`
+	RentalDuration    string     `gorm:"type:varchar(10);not null;default:'daily';check:rental_duration IN ('daily', 'weekly', 'monthly')" json:"rental_duration"`
 	TotalCost         float64    `gorm:"type:numeric(10,2);not null" json:"total_cost"`
-	Status            string     `gorm:"not null;check:status IN ('Book', 'Paid', 'Rent', 'Cancel')" json:"status"`
+	Status            string     `gorm:"not null;check:status IN ('Book', 'Paid', 'Rent', 'Completed', 'Cancel', 'Expired')" json:"status"`
 	PackageID         *uint      `json:"package_id"`
 	AirportTransfer   bool       `gorm:"default:false" json:"airport_transfer"`
 	PickupLocation    string     `json:"pickup_location"`
 	DropoffLocation   string     `json:"dropoff_location"`
 	ConciergeServices bool       `gorm:"default:false" json:"concierge_services"`
+	ActualReturnDate  *time.Time `json:"actual_return_date"`
+	ReturnOdometer    *int       `json:"return_odometer"`
+	ReturnFuelLevel   *int       `gorm:"check:return_fuel_level BETWEEN 0 AND 100" json:"return_fuel_level"`
+	LateFee           float64    `gorm:"type:numeric(10,2);default:0" json:"late_fee"`
+	PaymentDueAt      *time.Time `gorm:"index" json:"payment_due_at"`
+	CostBreakdown     *string    `gorm:"type:jsonb" json:"cost_breakdown"` // Price breakdown as billed, nil for bookings made before it was stored
 }
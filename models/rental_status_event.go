@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+)
+
+type RentalStatusEvent struct {
+	EventID     uint      `gorm:"primaryKey;autoIncrement" json:"event_id"`
+	RentalID    uint      `gorm:"not null;index" json:"rental_id"`
+	FromStatus  string    `gorm:"type:varchar(10)" json:"from_status"` // Empty for the event that creates the rental
+	ToStatus    string    `gorm:"type:varchar(10);not null" json:"to_status"`
+	ActorUserID *uint     `json:"actor_user_id"` // Nil when the system triggered the transition
+	ActorRole   string    `gorm:"type:varchar(20);not null" json:"actor_role"`
+	Reason      string    `json:"reason"`
+	CreatedAt   time.Time `gorm:"not null" json:"created_at"`
+}
@@ -1,11 +1,22 @@
 package models
 
+import (
+	"time"
+)
+
 type User struct {
-	UserID        uint    `gorm:"primaryKey;autoIncrement" json:"user_id"`
-	Email         string  `gorm:"unique;not null" json:"email"`
-	Password      string  `gorm:"not null" json:"password"`
-	PhoneNumber   string  `gorm:"type:varchar(15);not null" json:"phone_number"`
-	Address       string  `gorm:"not null" json:"address"`
-	DepositAmount float64 `gorm:"type:numeric(10,2);default:0" json:"deposit_amount"`
-	Role          string  `gorm:"type:varchar(20);not null;default:'user'" json:"role"`
+	UserID              uint       `gorm:"primaryKey;autoIncrement" json:"user_id"`
+	Email               string     `gorm:"unique;not null" json:"email"`
+	Password            string     `gorm:"not null" json:"password"`
+	PhoneNumber         string     `gorm:"type:varchar(15);not null" json:"phone_number"`
+	Address             string     `gorm:"not null" json:"address"`
+	DepositAmount       float64    `gorm:"type:numeric(10,2);default:0" json:"deposit_amount"`
+	Role                string     `gorm:"type:varchar(20);not null;default:'user'" json:"role"`
+	PreferredChannel    string     `gorm:"type:varchar(10);not null;default:'whatsapp'" json:"preferred_channel"`
+	PreferredLanguage   string     `gorm:"type:varchar(5);not null;default:'id'" json:"preferred_language"`
+	SuspendedAt         *time.Time `json:"suspended_at"` // Suspended accounts cannot log in or use their tokens
+	PhoneVerifiedAt     *time.Time `json:"phone_verified_at"`
+	TwoFactorEnabled    bool       `gorm:"not null;default:false" json:"two_factor_enabled"` // Login also asks for a WhatsApp code
+	FailedLoginAttempts int        `gorm:"not null;default:0" json:"failed_login_attempts"`  // Wrong passwords in a row
+	LockedUntil         *time.Time `json:"locked_until"`
 }
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+)
+
+type UserInvitation struct {
+	UserInvitationID uint       `gorm:"primaryKey;autoIncrement" json:"user_invitation_id"`
+	Email            string     `gorm:"type:varchar(255);not null;index" json:"email"`
+	Role             string     `gorm:"type:varchar(20);not null" json:"role"`
+	TokenHash        string     `gorm:"type:char(64);unique;not null" json:"-"` // SHA-256 of the token in the invitation link
+	InvitedBy        uint       `gorm:"not null" json:"invited_by"`
+	ExpiresAt        time.Time  `gorm:"not null" json:"expires_at"`
+	AcceptedAt       *time.Time `json:"accepted_at"`
+	UserID           *uint      `json:"user_id"` // Account created from the invitation
+	CreatedAt        time.Time  `gorm:"not null" json:"created_at"`
+}
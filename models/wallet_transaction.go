@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+)
+
+type WalletTransaction struct {
+	WalletTransactionID uint      `gorm:"primaryKey;autoIncrement" json:"wallet_transaction_id"`
+	UserID              uint      `gorm:"not null;index" json:"user_id"`
+	Type                string    `gorm:"type:varchar(20);not null;check:type IN ('opening_balance', 'topup', 'rental_payment', 'refund', 'penalty', 'adjustment')" json:"type"`
+	Amount              float64   `gorm:"type:numeric(12,2);not null" json:"amount"`        // Signed, credits are positive and debits negative
+	BalanceAfter        float64   `gorm:"type:numeric(12,2);not null" json:"balance_after"` // Deposit balance right after this entry
+	RentalID            *uint     `json:"rental_id"`
+	Description         string    `json:"description"`
+	CreatedAt           time.Time `gorm:"not null" json:"created_at"`
+}
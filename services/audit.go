@@ -0,0 +1,127 @@
+package services
+
+import (
+	"encoding/json"
+	"time"
+
+	"jakarta-luxury-rent-car/models"
+
+	"gorm.io/gorm"
+)
+
+// Audited actions stored in audit_logs.action
+const (
+	AuditBookingApprove   = "booking.approve"
+	AuditBookingReject    = "booking.reject"
+	AuditBookingModify    = "booking.modify"
+	AuditBookingCancel    = "booking.cancel"
+	AuditRentalPay        = "rental.pay"
+	AuditRentalReturn     = "rental.return"
+	AuditTopUpCreate      = "topup.create"
+	AuditTopUpSettle      = "topup.settle"
+	AuditInvoiceUnderpaid = "invoice.underpaid"
+	AuditRefundRetry      = "refund.retry"
+	AuditUserRoleChange   = "user.role_change"
+	AuditUserSuspend      = "user.suspend"
+	AuditUserReactivate   = "user.reactivate"
+	AuditUserTwoFactor    = "user.two_factor"
+	AuditUserInvite       = "user.invite"
+	AuditInvitationAccept = "invitation.accept"
+	AuditUserCreate       = "user.create"
+	AuditCarRateUpdate    = "pricing.car_rate_update"
+	AuditAddonPriceUpdate = "pricing.addon_update"
+	AuditPricingSettings  = "pricing.settings_update"
+	AuditHolidayCreate    = "pricing.holiday_create"
+	AuditHolidayDelete    = "pricing.holiday_delete"
+	AuditFleetUnitCreate  = "fleet_unit.create"
+	AuditFleetUnitUpdate  = "fleet_unit.update"
+	AuditTemplateUpdate   = "template.update"
+	AuditTemplateReset    = "template.reset"
+)
+
+// Audited entity types stored in audit_logs.entity_type
+const (
+	AuditEntityRental          = "rental"
+	AuditEntityTopUp           = "deposit_top_up"
+	AuditEntityInvoice         = "invoice"
+	AuditEntityRefund          = "refund"
+	AuditEntityUser            = "user"
+	AuditEntityInvitation      = "user_invitation"
+	AuditEntityCarRate         = "car_rate"
+	AuditEntityAddonPrice      = "addon_price"
+	AuditEntityPricingSettings = "pricing_settings"
+	AuditEntityHoliday         = "holiday"
+	AuditEntityFleetUnit       = "fleet_unit"
+	AuditEntityTemplate        = "message_template"
+)
+
+// AuditActor is who performed an audited action and from where
+type AuditActor struct {
+	UserID    *uint
+	Role      string // ActorSystem when no user was involved
+	IPAddress string
+}
+
+// AuditEntry describes an audited action. Before and After are the changed values of the entity,
+// nil when there was nothing before (a create) or nothing after (a delete).
+type AuditEntry struct {
+	Action     string
+	EntityType string
+	EntityID   string
+	Before     interface{}
+	After      interface{}
+}
+
+// NewAuditLog builds the row recording entry, with Before and After stored as JSON
+func NewAuditLog(actor AuditActor, entry AuditEntry, now time.Time) (models.AuditLog, error) {
+	before, err := auditJSON(entry.Before)
+	if err != nil {
+		return models.AuditLog{}, err
+	}
+	after, err := auditJSON(entry.After)
+	if err != nil {
+		return models.AuditLog{}, err
+	}
+
+	role := actor.Role
+	if role == "" {
+		role = ActorSystem
+	}
+
+	return models.AuditLog{
+		ActorUserID: actor.UserID,
+		ActorRole:   role,
+		Action:      entry.Action,
+		EntityType:  entry.EntityType,
+		EntityID:    entry.EntityID,
+		Before:      before,
+		After:       after,
+		IPAddress:   actor.IPAddress,
+		CreatedAt:   now,
+	}, nil
+}
+
+func auditJSON(value interface{}) (*string, error) {
+	if value == nil {
+		return nil, nil
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	// A nil pointer, e.g. a row that did not exist yet, is stored as NULL like a nil value
+	if string(raw) == "null" {
+		return nil, nil
+	}
+	encoded := string(raw)
+	return &encoded, nil
+}
+
+// RecordAudit appends entry to the audit log inside tx, so it is only kept when the action itself commits
+func RecordAudit(tx *gorm.DB, actor AuditActor, entry AuditEntry) error {
+	log, err := NewAuditLog(actor, entry, time.Now())
+	if err != nil {
+		return err
+	}
+	return tx.Create(&log).Error
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"jakarta-luxury-rent-car/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewAuditLog(t *testing.T) {
+	now := time.Date(2024, time.August, 17, 9, 0, 0, 0, time.UTC)
+	ownerID := uint(3)
+
+	log, err := NewAuditLog(
+		AuditActor{UserID: &ownerID, Role: RoleOwner, IPAddress: "203.0.113.7"},
+		AuditEntry{
+			Action:     AuditBookingApprove,
+			EntityType: AuditEntityRental,
+			EntityID:   "812",
+			Before:     map[string]interface{}{"status": RentalStatusPaid},
+			After:      map[string]interface{}{"status": RentalStatusRent, "fleet_unit_id": 4},
+		},
+		now,
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, &ownerID, log.ActorUserID)
+	assert.Equal(t, RoleOwner, log.ActorRole)
+	assert.Equal(t, "812", log.EntityID)
+	assert.Equal(t, "203.0.113.7", log.IPAddress)
+	assert.Equal(t, now, log.CreatedAt)
+	if assert.NotNil(t, log.Before) && assert.NotNil(t, log.After) {
+		assert.JSONEq(t, `{"status":"Paid"}`, *log.Before)
+		assert.JSONEq(t, `{"status":"Rent","fleet_unit_id":4}`, *log.After)
+	}
+}
+
+func TestNewAuditLogWithoutBeforeOrActor(t *testing.T) {
+	log, err := NewAuditLog(AuditActor{}, AuditEntry{
+		Action:     AuditTopUpSettle,
+		EntityType: AuditEntityTopUp,
+		EntityID:   "9",
+		After:      map[string]float64{"deposit_amount": 150000},
+	}, time.Now())
+	assert.NoError(t, err)
+	assert.Nil(t, log.ActorUserID)
+	assert.Equal(t, ActorSystem, log.ActorRole)
+	assert.Nil(t, log.Before)
+	assert.JSONEq(t, `{"deposit_amount":150000}`, *log.After)
+}
+
+func TestNewAuditLogStoresNilPointersAsNull(t *testing.T) {
+	var missing *models.CarRate
+	log, err := NewAuditLog(AuditActor{}, AuditEntry{Before: missing, After: models.CarRate{CarID: 2, DailyRate: 3500000}}, time.Now())
+	assert.NoError(t, err)
+	assert.Nil(t, log.Before)
+	assert.NotNil(t, log.After)
+}
+
+func TestNewAuditLogRejectsUnencodableValues(t *testing.T) {
+	_, err := NewAuditLog(AuditActor{}, AuditEntry{After: make(chan int)}, time.Now())
+	assert.Error(t, err)
+}
@@ -0,0 +1,62 @@
+package services
+
+// Account roles stored in users.role and carried in the "role" claim of access tokens
+const (
+	RoleUser   = "user"
+	RoleOwner  = "owner"
+	RoleStaff  = "staff"
+	RoleDriver = "driver"
+	RoleAdmin  = "admin"
+)
+
+// Permissions checked by the authorization middleware on back office routes
+const (
+	PermApproveBookings = "bookings:approve"
+	PermCheckInRentals  = "rentals:checkin"
+	PermViewReports     = "reports:view"
+	PermManageFleet     = "fleet:manage"
+	PermManagePricing   = "pricing:manage"
+	PermManageRefunds   = "refunds:manage"
+	PermReconcileWallet = "wallet:reconcile"
+	PermManageTemplates = "templates:manage"
+	PermViewAuditLog    = "audit:view"
+	PermManageUsers     = "users:manage"
+)
+
+// ownerPermissions are the permissions of running the rental business
+var ownerPermissions = []string{
+	PermApproveBookings,
+	PermCheckInRentals,
+	PermViewReports,
+	PermManageFleet,
+	PermManagePricing,
+	PermManageRefunds,
+	PermReconcileWallet,
+	PermManageTemplates,
+	PermViewAuditLog,
+}
+
+// rolePermissions lists what each role may do besides using its own customer account
+var rolePermissions = map[string][]string{
+	RoleUser:   nil,
+	RoleDriver: nil,
+	RoleStaff:  {PermCheckInRentals},
+	RoleOwner:  ownerPermissions,
+	RoleAdmin:  append([]string{PermManageUsers}, ownerPermissions...),
+}
+
+// IsRole reports whether role is a known account role
+func IsRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// HasPermission reports whether role grants permission. Unknown roles have no permissions.
+func HasPermission(role, permission string) bool {
+	for _, granted := range rolePermissions[role] {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
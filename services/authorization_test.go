@@ -0,0 +1,32 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHasPermission(t *testing.T) {
+	assert.True(t, HasPermission(RoleOwner, PermApproveBookings))
+	assert.True(t, HasPermission(RoleOwner, PermManagePricing))
+	assert.False(t, HasPermission(RoleOwner, PermManageUsers))
+
+	assert.True(t, HasPermission(RoleAdmin, PermManageUsers))
+	assert.True(t, HasPermission(RoleAdmin, PermViewReports))
+
+	assert.True(t, HasPermission(RoleStaff, PermCheckInRentals))
+	assert.False(t, HasPermission(RoleStaff, PermApproveBookings))
+
+	assert.False(t, HasPermission(RoleUser, PermViewReports))
+	assert.False(t, HasPermission(RoleDriver, PermCheckInRentals))
+	assert.False(t, HasPermission("", PermCheckInRentals))
+	assert.False(t, HasPermission("superuser", PermCheckInRentals))
+}
+
+func TestIsRole(t *testing.T) {
+	for _, role := range []string{RoleUser, RoleOwner, RoleStaff, RoleDriver, RoleAdmin} {
+		assert.True(t, IsRole(role), role)
+	}
+	assert.False(t, IsRole(""))
+	assert.False(t, IsRole("root"))
+}
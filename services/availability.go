@@ -0,0 +1,174 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"jakarta-luxury-rent-car/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// activeRentalStatuses are the rental states that hold a car unit for their date window
+var activeRentalStatuses = []string{RentalStatusBook, RentalStatusPaid, RentalStatusRent}
+
+// ErrCarUnavailable is returned when no unit of a car is free for the requested window
+var ErrCarUnavailable = errors.New("car is not available for the requested dates")
+
+// CarAvailability describes how many units of a car are free in a date window
+type CarAvailability struct {
+	CarID          uint `json:"car_id"`
+	Capacity       int  `json:"capacity"`
+	Reserved       int  `json:"reserved"`
+	AvailableUnits int  `json:"available_units"`
+}
+
+// GetCarAvailability computes the free units of a car between from and to.
+// excludeRentalID lets a rental be checked against everything except itself (0 to disable).
+func GetCarAvailability(db *gorm.DB, car models.Car, from, to time.Time, excludeRentalID uint) (CarAvailability, error) {
+	rentals, err := overlappingRentals(db, []uint{car.CarID}, from, to, excludeRentalID)
+	if err != nil {
+		return CarAvailability{}, err
+	}
+
+	return buildAvailability(car, rentals, from, to), nil
+}
+
+// ListCarAvailability computes the free units of every given car between from and to with a single query
+func ListCarAvailability(db *gorm.DB, cars []models.Car, from, to time.Time) (map[uint]CarAvailability, error) {
+	carIDs := make([]uint, 0, len(cars))
+	for _, car := range cars {
+		carIDs = append(carIDs, car.CarID)
+	}
+
+	rentals, err := overlappingRentals(db, carIDs, from, to, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	rentalsByCar := make(map[uint][]models.RentalHistory)
+	for _, rental := range rentals {
+		rentalsByCar[rental.CarID] = append(rentalsByCar[rental.CarID], rental)
+	}
+
+	availability := make(map[uint]CarAvailability, len(cars))
+	for _, car := range cars {
+		availability[car.CarID] = buildAvailability(car, rentalsByCar[car.CarID], from, to)
+	}
+
+	return availability, nil
+}
+
+// ReserveCar locks the car row inside tx and makes sure at least one unit is free between from and to.
+// Holding the lock until tx commits serialises concurrent bookings of the same car.
+func ReserveCar(tx *gorm.DB, carID uint, from, to time.Time, excludeRentalID uint) (models.Car, error) {
+	var car models.Car
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&car, carID).Error; err != nil {
+		return car, err
+	}
+
+	availability, err := GetCarAvailability(tx, car, from, to, excludeRentalID)
+	if err != nil {
+		return car, err
+	}
+
+	if availability.AvailableUnits < 1 {
+		return car, ErrCarUnavailable
+	}
+
+	return car, nil
+}
+
+func overlappingRentals(db *gorm.DB, carIDs []uint, from, to time.Time, excludeRentalID uint) ([]models.RentalHistory, error) {
+	var rentals []models.RentalHistory
+	if len(carIDs) == 0 {
+		return rentals, nil
+	}
+
+	query := activeDuring(db.Where("car_id IN ?", carIDs), from, to)
+	if excludeRentalID != 0 {
+		query = query.Where("rental_id <> ?", excludeRentalID)
+	}
+
+	if err := query.Find(&rentals).Error; err != nil {
+		return nil, err
+	}
+
+	return rentals, nil
+}
+
+// activeDuring narrows a rental query to the active rentals that overlap [from, to).
+// Cars still out past their return date keep holding their unit until they are checked in.
+func activeDuring(query *gorm.DB, from, to time.Time) *gorm.DB {
+	return query.Where("status IN ?", activeRentalStatuses).
+		Where("rental_date < ? AND (return_date IS NULL OR return_date > ? OR status = ?)", to, from, RentalStatusRent)
+}
+
+func buildAvailability(car models.Car, rentals []models.RentalHistory, from, to time.Time) CarAvailability {
+	reserved := peakReserved(rentals, from, to)
+
+	available := car.StockAvailability - reserved
+	if available < 0 {
+		available = 0
+	}
+
+	return CarAvailability{
+		CarID:          car.CarID,
+		Capacity:       car.StockAvailability,
+		Reserved:       reserved,
+		AvailableUnits: available,
+	}
+}
+
+// peakReserved returns the highest number of rentals that are running at the same time inside [from, to).
+// Two back-to-back rentals inside a long window only need one unit, so a plain count would overstate usage.
+func peakReserved(rentals []models.RentalHistory, from, to time.Time) int {
+	type boundary struct {
+		at    time.Time
+		delta int
+	}
+
+	boundaries := make([]boundary, 0, len(rentals)*2)
+	for _, rental := range rentals {
+		start := rental.RentalDate
+		if start.Before(from) {
+			start = from
+		}
+
+		end := to
+		if rental.ReturnDate != nil && rental.ReturnDate.Before(to) && !isOverdue(rental) {
+			end = *rental.ReturnDate
+		}
+
+		if !start.Before(end) {
+			continue
+		}
+
+		boundaries = append(boundaries, boundary{at: start, delta: 1}, boundary{at: end, delta: -1})
+	}
+
+	// Ends sort before starts at the same instant so a return and a pickup can share a unit
+	sort.Slice(boundaries, func(i, j int) bool {
+		if boundaries[i].at.Equal(boundaries[j].at) {
+			return boundaries[i].delta < boundaries[j].delta
+		}
+		return boundaries[i].at.Before(boundaries[j].at)
+	})
+
+	current, peak := 0, 0
+	for _, b := range boundaries {
+		current += b.delta
+		if current > peak {
+			peak = current
+		}
+	}
+
+	return peak
+}
+
+// isOverdue reports whether a rented car has passed its return date without being checked in
+func isOverdue(rental models.RentalHistory) bool {
+	return rental.Status == RentalStatusRent && rental.ReturnDate != nil && rental.ReturnDate.Before(time.Now())
+}
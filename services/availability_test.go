@@ -0,0 +1,85 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"jakarta-luxury-rent-car/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func rentalBetween(start, end string) models.RentalHistory {
+	rentalDate, _ := time.Parse("2006-01-02", start)
+	rental := models.RentalHistory{RentalDate: rentalDate}
+	if end != "" {
+		returnDate, _ := time.Parse("2006-01-02", end)
+		rental.ReturnDate = &returnDate
+	}
+	return rental
+}
+
+func TestPeakReserved_BackToBackRentalsShareUnit(t *testing.T) {
+	from, _ := time.Parse("2006-01-02", "2024-10-01")
+	to, _ := time.Parse("2006-01-02", "2024-10-10")
+
+	rentals := []models.RentalHistory{
+		rentalBetween("2024-10-01", "2024-10-03"),
+		rentalBetween("2024-10-03", "2024-10-05"),
+		rentalBetween("2024-10-06", "2024-10-08"),
+	}
+
+	assert.Equal(t, 1, peakReserved(rentals, from, to))
+}
+
+func TestPeakReserved_OverlappingRentals(t *testing.T) {
+	from, _ := time.Parse("2006-01-02", "2024-10-01")
+	to, _ := time.Parse("2006-01-02", "2024-10-10")
+
+	rentals := []models.RentalHistory{
+		rentalBetween("2024-09-28", "2024-10-04"),
+		rentalBetween("2024-10-02", "2024-10-06"),
+		rentalBetween("2024-10-03", ""),
+	}
+
+	assert.Equal(t, 3, peakReserved(rentals, from, to))
+}
+
+func TestPeakReserved_IgnoresRentalsOutsideWindow(t *testing.T) {
+	from, _ := time.Parse("2006-01-02", "2024-10-01")
+	to, _ := time.Parse("2006-01-02", "2024-10-10")
+
+	rentals := []models.RentalHistory{
+		rentalBetween("2024-09-20", "2024-10-01"),
+		rentalBetween("2024-12-01", "2024-12-05"),
+	}
+
+	assert.Equal(t, 0, peakReserved(rentals, from, to))
+}
+
+func TestBuildAvailability_NeverNegative(t *testing.T) {
+	from, _ := time.Parse("2006-01-02", "2024-10-01")
+	to, _ := time.Parse("2006-01-02", "2024-10-05")
+
+	car := models.Car{CarID: 3, StockAvailability: 1}
+	rentals := []models.RentalHistory{
+		rentalBetween("2024-10-01", "2024-10-04"),
+		rentalBetween("2024-10-02", "2024-10-05"),
+	}
+
+	availability := buildAvailability(car, rentals, from, to)
+	assert.Equal(t, 2, availability.Reserved)
+	assert.Equal(t, 0, availability.AvailableUnits)
+}
+
+func TestPeakReserved_OverdueRentalHoldsUnit(t *testing.T) {
+	from := time.Now()
+	to := from.Add(72 * time.Hour)
+
+	overdueReturn := from.Add(-24 * time.Hour)
+	rentals := []models.RentalHistory{
+		{RentalDate: from.Add(-96 * time.Hour), ReturnDate: &overdueReturn, Status: "Rent"},
+	}
+
+	assert.Equal(t, 1, peakReserved(rentals, from, to))
+}
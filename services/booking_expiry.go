@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"jakarta-luxury-rent-car/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultPaymentHoldWindow matches the 24 hour booking invoice the customer is sent
+const DefaultPaymentHoldWindow = 24 * time.Hour
+
+// OutboxBookingExpired is the outbox kind queued for every booking the expiry job expires,
+// its delivery expires the gateway invoice and tells the customer
+const OutboxBookingExpired = "booking_expired"
+
+// BookingExpiredPayload is the outbox payload of an expired booking
+type BookingExpiredPayload struct {
+	RentalID uint `json:"rental_id"`
+}
+
+// LoadPaymentHoldWindow reads PAYMENT_HOLD_WINDOW, how long an unpaid booking holds its car,
+// as a Go duration such as "24h" or "90m"
+func LoadPaymentHoldWindow() (time.Duration, error) {
+	return envValue("PAYMENT_HOLD_WINDOW", DefaultPaymentHoldWindow, parsePositiveDuration)
+}
+
+// PaymentDueAt is when an unpaid booking made at bookedAt expires: after the hold window,
+// but never later than the pickup itself
+func PaymentDueAt(bookedAt, rentalDate time.Time, hold time.Duration) time.Time {
+	due := bookedAt.Add(hold)
+	if rentalDate.Before(due) {
+		return rentalDate
+	}
+	return due
+}
+
+// BookingExpiryJob expires unpaid bookings whose payment hold window has lapsed
+type BookingExpiryJob struct {
+	db       *gorm.DB
+	Interval time.Duration
+}
+
+// NewBookingExpiryJob creates a job that checks every minute
+func NewBookingExpiryJob(db *gorm.DB) *BookingExpiryJob {
+	return &BookingExpiryJob{db: db, Interval: time.Minute}
+}
+
+// Run expires due bookings until ctx is cancelled
+func (j *BookingExpiryJob) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := j.ExpireDue(time.Now()); err != nil {
+			log.Println("Booking expiry failed: ", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ExpireDue expires every booking still unpaid at its payment due time and returns how many were expired
+func (j *BookingExpiryJob) ExpireDue(now time.Time) (int, error) {
+	var rentalIDs []uint
+	if err := j.db.Model(&models.RentalHistory{}).
+		Where("status = ? AND payment_due_at <= ?", RentalStatusBook, now).
+		Order("payment_due_at").
+		Pluck("rental_id", &rentalIDs).Error; err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, rentalID := range rentalIDs {
+		ok, err := j.expire(rentalID, now)
+		if err != nil {
+			return expired, err
+		}
+		if ok {
+			expired++
+		}
+	}
+
+	return expired, nil
+}
+
+// expire moves one booking to Expired, frees its car and queues the invoice expiry and notification.
+// The rental is locked with SKIP LOCKED, so a payment or another instance working on it wins.
+func (j *BookingExpiryJob) expire(rentalID uint, now time.Time) (bool, error) {
+	expired := false
+
+	err := j.db.Transaction(func(tx *gorm.DB) error {
+		var rental models.RentalHistory
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("rental_id = ? AND status = ? AND payment_due_at <= ?", rentalID, RentalStatusBook, now).
+			Limit(1).
+			Find(&rental)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		if err := TransitionRental(tx, &rental, RentalStatusExpired, SystemActor(), "Payment hold window lapsed"); err != nil {
+			return err
+		}
+		if _, err := ReleaseFleetUnit(tx, rental, nil); err != nil {
+			return err
+		}
+		if err := EnqueueOutbox(tx, OutboxBookingExpired, BookingExpiredPayload{RentalID: rental.RentalID}); err != nil {
+			return err
+		}
+
+		expired = true
+		return nil
+	})
+
+	return expired, err
+}
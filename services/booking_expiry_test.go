@@ -0,0 +1,37 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaymentDueAt(t *testing.T) {
+	bookedAt := time.Date(2024, time.June, 1, 10, 0, 0, 0, time.UTC)
+
+	// Far away pickups hold the car for the full window
+	assert.Equal(t, bookedAt.Add(24*time.Hour), PaymentDueAt(bookedAt, bookedAt.AddDate(0, 0, 7), 24*time.Hour))
+
+	// Pickups inside the window must be paid by the pickup time
+	pickup := bookedAt.Add(6 * time.Hour)
+	assert.Equal(t, pickup, PaymentDueAt(bookedAt, pickup, 24*time.Hour))
+}
+
+func TestLoadPaymentHoldWindow(t *testing.T) {
+	t.Setenv("PAYMENT_HOLD_WINDOW", "")
+	hold, err := LoadPaymentHoldWindow()
+	assert.NoError(t, err)
+	assert.Equal(t, 24*time.Hour, hold)
+
+	t.Setenv("PAYMENT_HOLD_WINDOW", "90m")
+	hold, err = LoadPaymentHoldWindow()
+	assert.NoError(t, err)
+	assert.Equal(t, 90*time.Minute, hold)
+
+	for _, value := range []string{"tomorrow", "-1h", "0s"} {
+		t.Setenv("PAYMENT_HOLD_WINDOW", value)
+		_, err := LoadPaymentHoldWindow()
+		assert.ErrorContains(t, err, "invalid PAYMENT_HOLD_WINDOW", value)
+	}
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultCancellationPolicy refunds everything more than 72h before pickup and half of it afterwards
+const defaultCancellationPolicy = "72:100,0:50"
+
+// RefundTier refunds Percent of the paid amount when the booking is cancelled at least
+// MinHoursBeforePickup hours before the rental date
+type RefundTier struct {
+	MinHoursBeforePickup float64 `json:"min_hours_before_pickup"`
+	Percent              float64 `json:"percent"`
+}
+
+// CancellationPolicy decides how much of a paid booking goes back to the customer
+type CancellationPolicy struct {
+	Tiers []RefundTier `json:"tiers"`
+}
+
+// DefaultCancellationPolicy is the policy used when CANCELLATION_POLICY is unset
+func DefaultCancellationPolicy() CancellationPolicy {
+	policy, _ := ParseCancellationPolicy(defaultCancellationPolicy)
+	return policy
+}
+
+// LoadCancellationPolicy reads CANCELLATION_POLICY, a comma separated list of "hours:percent" tiers
+// such as "72:100,24:50,0:25"
+func LoadCancellationPolicy() (CancellationPolicy, error) {
+	return envValue("CANCELLATION_POLICY", DefaultCancellationPolicy(), ParseCancellationPolicy)
+}
+
+// ParseCancellationPolicy parses "hours:percent" tiers, e.g. "72:100,24:50"
+func ParseCancellationPolicy(value string) (CancellationPolicy, error) {
+	var policy CancellationPolicy
+
+	for _, part := range strings.Split(value, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ":")
+		if len(fields) != 2 {
+			return policy, fmt.Errorf("invalid cancellation tier %q, expected hours:percent", part)
+		}
+
+		hours, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil || hours < 0 {
+			return policy, fmt.Errorf("invalid hours in cancellation tier %q", part)
+		}
+
+		percent, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil || percent < 0 || percent > 100 {
+			return policy, fmt.Errorf("invalid percent in cancellation tier %q", part)
+		}
+
+		policy.Tiers = append(policy.Tiers, RefundTier{MinHoursBeforePickup: hours, Percent: percent})
+	}
+
+	// Most generous tier first so the first match wins
+	sort.Slice(policy.Tiers, func(i, j int) bool {
+		return policy.Tiers[i].MinHoursBeforePickup > policy.Tiers[j].MinHoursBeforePickup
+	})
+
+	return policy, nil
+}
+
+// RefundPercent returns the share of the paid amount refunded when cancelling at the given time.
+// Nothing is refunded once the rental date has passed.
+func (p CancellationPolicy) RefundPercent(rentalDate, cancelledAt time.Time) float64 {
+	hoursBefore := rentalDate.Sub(cancelledAt).Hours()
+	if hoursBefore < 0 {
+		return 0
+	}
+
+	for _, tier := range p.Tiers {
+		if hoursBefore >= tier.MinHoursBeforePickup {
+			return tier.Percent
+		}
+	}
+
+	return 0
+}
+
+// RefundAmount applies the policy to a paid amount, rounded to cents
+func (p CancellationPolicy) RefundAmount(paid float64, rentalDate, cancelledAt time.Time) float64 {
+	refund := paid * p.RefundPercent(rentalDate, cancelledAt) / 100
+	return math.Round(refund*100) / 100
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCancellationPolicy_DefaultTiers(t *testing.T) {
+	policy, err := ParseCancellationPolicy(defaultCancellationPolicy)
+	assert.NoError(t, err)
+
+	pickup := time.Date(2024, 12, 20, 9, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, 100.0, policy.RefundPercent(pickup, pickup.Add(-96*time.Hour)))
+	assert.Equal(t, 50.0, policy.RefundPercent(pickup, pickup.Add(-48*time.Hour)))
+	assert.Equal(t, 50.0, policy.RefundPercent(pickup, pickup.Add(-2*time.Hour)))
+	assert.Equal(t, 0.0, policy.RefundPercent(pickup, pickup.Add(time.Hour)))
+}
+
+func TestCancellationPolicy_CustomTiers(t *testing.T) {
+	policy, err := ParseCancellationPolicy("24:50, 72:100")
+	assert.NoError(t, err)
+
+	pickup := time.Date(2024, 12, 20, 9, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, 100.0, policy.RefundPercent(pickup, pickup.Add(-73*time.Hour)))
+	assert.Equal(t, 50.0, policy.RefundPercent(pickup, pickup.Add(-30*time.Hour)))
+	assert.Equal(t, 0.0, policy.RefundPercent(pickup, pickup.Add(-10*time.Hour)))
+	assert.Equal(t, 616.67, policy.RefundAmount(1233.33, pickup, pickup.Add(-30*time.Hour)))
+}
+
+func TestParseCancellationPolicy_Invalid(t *testing.T) {
+	for _, value := range []string{"72", "abc:100", "72:150", "-1:50"} {
+		_, err := ParseCancellationPolicy(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestLoadCancellationPolicy(t *testing.T) {
+	t.Setenv("CANCELLATION_POLICY", "")
+	policy, err := LoadCancellationPolicy()
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultCancellationPolicy(), policy)
+
+	t.Setenv("CANCELLATION_POLICY", "48:100,0:25")
+	policy, err = LoadCancellationPolicy()
+	assert.NoError(t, err)
+	assert.Equal(t, []RefundTier{{MinHoursBeforePickup: 48, Percent: 100}, {MinHoursBeforePickup: 0, Percent: 25}}, policy.Tiers)
+
+	t.Setenv("CANCELLATION_POLICY", "72:150")
+	_, err = LoadCancellationPolicy()
+	assert.ErrorContains(t, err, "invalid CANCELLATION_POLICY")
+}
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// envValue parses the environment variable key with parse. An unset or blank variable gives
+// fallback; a value that does not parse gives fallback and an error naming the variable,
+// so main can refuse to start instead of quietly running with a setting nobody asked for.
+func envValue[T any](key string, fallback T, parse func(string) (T, error)) (T, error) {
+	value := strings.TrimSpace(os.Getenv(key))
+	if value == "" {
+		return fallback, nil
+	}
+
+	parsed, err := parse(value)
+	if err != nil {
+		return fallback, fmt.Errorf("invalid %s %q: %w", key, value, err)
+	}
+	return parsed, nil
+}
+
+// parsePositiveDuration parses a Go duration such as "15m" or "720h" that must be above zero
+func parsePositiveDuration(value string) (time.Duration, error) {
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if duration <= 0 {
+		return 0, errors.New("must be positive")
+	}
+	return duration, nil
+}
+
+// parsePositiveInt parses a whole number above zero
+func parsePositiveInt(value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, err
+	}
+	if n <= 0 {
+		return 0, errors.New("must be positive")
+	}
+	return n, nil
+}
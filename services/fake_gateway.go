@@ -0,0 +1,160 @@
+package services
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// FakeGateway is an in-memory PaymentGateway. Invoices stay PENDING until SimulatePaid or
+// SimulateExpired is called, or until their duration has passed.
+type FakeGateway struct {
+	mu       sync.Mutex
+	now      func() time.Time
+	invoices map[string]*GatewayInvoice
+	refunds  map[string]GatewayRefund // By reference ID
+	refunded map[string]float64       // Refunded amount per invoice
+	sequence int
+}
+
+// NewFakeGateway creates an empty fake gateway that uses the wall clock
+func NewFakeGateway() *FakeGateway {
+	return &FakeGateway{
+		now:      time.Now,
+		invoices: map[string]*GatewayInvoice{},
+		refunds:  map[string]GatewayRefund{},
+		refunded: map[string]float64{},
+	}
+}
+
+// SetClock replaces the clock used to expire invoices, for tests
+func (g *FakeGateway) SetClock(now func() time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.now = now
+}
+
+// CreateInvoice stores a PENDING invoice
+func (g *FakeGateway) CreateInvoice(req InvoiceRequest) (GatewayInvoice, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.sequence++
+	id := fmt.Sprintf("fake-inv-%d", g.sequence)
+	expiresAt := g.now().Add(req.Duration)
+
+	invoice := &GatewayInvoice{
+		ID:         id,
+		ExternalID: req.ExternalID,
+		Status:     InvoiceStatusPending,
+		Amount:     req.Amount,
+		InvoiceURL: "https://checkout.fake-gateway.local/" + id,
+		ExpiresAt:  &expiresAt,
+	}
+	g.invoices[id] = invoice
+
+	return *invoice, nil
+}
+
+// GetInvoice returns an invoice, expiring it first when its duration has passed
+func (g *FakeGateway) GetInvoice(invoiceID string) (GatewayInvoice, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	invoice, err := g.find(invoiceID)
+	if err != nil {
+		return GatewayInvoice{}, err
+	}
+
+	return *invoice, nil
+}
+
+// ExpireInvoice closes a pending invoice
+func (g *FakeGateway) ExpireInvoice(invoiceID string) (GatewayInvoice, error) {
+	return g.SimulateExpired(invoiceID)
+}
+
+// Refund pays back part of a paid invoice. Repeating a reference ID returns the earlier refund.
+func (g *FakeGateway) Refund(req RefundRequest) (GatewayRefund, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if refund, ok := g.refunds[req.ReferenceID]; ok {
+		return refund, nil
+	}
+
+	invoice, err := g.find(req.InvoiceID)
+	if err != nil {
+		return GatewayRefund{}, err
+	}
+	if invoice.Status != InvoiceStatusPaid {
+		return GatewayRefund{}, ErrGatewayInvoiceNotPaid
+	}
+	if roundCents(g.refunded[invoice.ID]+req.Amount) > roundCents(invoice.Amount) {
+		return GatewayRefund{}, ErrGatewayRefundTooLarge
+	}
+
+	g.sequence++
+	refund := GatewayRefund{
+		ID:          fmt.Sprintf("fake-rfd-%d", g.sequence),
+		ReferenceID: req.ReferenceID,
+		Status:      GatewayRefundSucceeded,
+		Amount:      req.Amount,
+	}
+	g.refunds[req.ReferenceID] = refund
+	g.refunded[invoice.ID] += req.Amount
+
+	return refund, nil
+}
+
+// SimulatePaid marks a pending invoice as paid, as if the customer completed the checkout
+func (g *FakeGateway) SimulatePaid(invoiceID string) (GatewayInvoice, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	invoice, err := g.find(invoiceID)
+	if err != nil {
+		return GatewayInvoice{}, err
+	}
+	if invoice.Status != InvoiceStatusPending {
+		return *invoice, ErrGatewayInvoiceNotActive
+	}
+
+	paidAt := g.now()
+	invoice.Status = InvoiceStatusPaid
+	invoice.PaidAt = &paidAt
+
+	return *invoice, nil
+}
+
+// SimulateExpired marks a pending invoice as expired
+func (g *FakeGateway) SimulateExpired(invoiceID string) (GatewayInvoice, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	invoice, err := g.find(invoiceID)
+	if err != nil {
+		return GatewayInvoice{}, err
+	}
+	if invoice.Status != InvoiceStatusPending {
+		return *invoice, ErrGatewayInvoiceNotActive
+	}
+
+	invoice.Status = InvoiceStatusExpired
+
+	return *invoice, nil
+}
+
+// find looks up an invoice and lazily expires it. The caller holds g.mu.
+func (g *FakeGateway) find(invoiceID string) (*GatewayInvoice, error) {
+	invoice, ok := g.invoices[invoiceID]
+	if !ok {
+		return nil, ErrGatewayInvoiceNotFound
+	}
+
+	if invoice.Status == InvoiceStatusPending && invoice.ExpiresAt != nil && !g.now().Before(*invoice.ExpiresAt) {
+		invoice.Status = InvoiceStatusExpired
+	}
+
+	return invoice, nil
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestFakeGateway(now *time.Time) *FakeGateway {
+	gateway := NewFakeGateway()
+	gateway.SetClock(func() time.Time { return *now })
+	return gateway
+}
+
+func TestFakeGatewayPaidInvoice(t *testing.T) {
+	now := time.Date(2024, 10, 5, 10, 0, 0, 0, time.UTC)
+	gateway := newTestFakeGateway(&now)
+
+	invoice, err := gateway.CreateInvoice(InvoiceRequest{ExternalID: "JLRC-booking-1-1", Amount: 300, Duration: 24 * time.Hour})
+	assert.NoError(t, err)
+	assert.Equal(t, InvoiceStatusPending, invoice.Status)
+	assert.NotEmpty(t, invoice.InvoiceURL)
+
+	paid, err := gateway.SimulatePaid(invoice.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, InvoiceStatusPaid, paid.Status)
+	assert.Equal(t, now, *paid.PaidAt)
+
+	// A paid invoice can no longer expire
+	_, err = gateway.ExpireInvoice(invoice.ID)
+	assert.ErrorIs(t, err, ErrGatewayInvoiceNotActive)
+
+	fetched, err := gateway.GetInvoice(invoice.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, InvoiceStatusPaid, fetched.Status)
+}
+
+func TestFakeGatewayExpiresAfterDuration(t *testing.T) {
+	now := time.Date(2024, 10, 5, 10, 0, 0, 0, time.UTC)
+	gateway := newTestFakeGateway(&now)
+
+	invoice, _ := gateway.CreateInvoice(InvoiceRequest{ExternalID: "JLRC-booking-1-1", Amount: 300, Duration: 24 * time.Hour})
+
+	now = now.Add(25 * time.Hour)
+	fetched, err := gateway.GetInvoice(invoice.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, InvoiceStatusExpired, fetched.Status)
+
+	_, err = gateway.SimulatePaid(invoice.ID)
+	assert.ErrorIs(t, err, ErrGatewayInvoiceNotActive)
+}
+
+func TestFakeGatewayRefund(t *testing.T) {
+	now := time.Date(2024, 10, 5, 10, 0, 0, 0, time.UTC)
+	gateway := newTestFakeGateway(&now)
+
+	invoice, _ := gateway.CreateInvoice(InvoiceRequest{ExternalID: "JLRC-booking-1-1", Amount: 300, Duration: time.Hour})
+
+	_, err := gateway.Refund(RefundRequest{InvoiceID: invoice.ID, ReferenceID: "refund-1", Amount: 100})
+	assert.ErrorIs(t, err, ErrGatewayInvoiceNotPaid)
+
+	gateway.SimulatePaid(invoice.ID)
+
+	refund, err := gateway.Refund(RefundRequest{InvoiceID: invoice.ID, ReferenceID: "refund-1", Amount: 200})
+	assert.NoError(t, err)
+	assert.Equal(t, GatewayRefundSucceeded, refund.Status)
+
+	// Retrying the same reference does not refund twice
+	retried, err := gateway.Refund(RefundRequest{InvoiceID: invoice.ID, ReferenceID: "refund-1", Amount: 200})
+	assert.NoError(t, err)
+	assert.Equal(t, refund.ID, retried.ID)
+
+	_, err = gateway.Refund(RefundRequest{InvoiceID: invoice.ID, ReferenceID: "refund-2", Amount: 150})
+	assert.ErrorIs(t, err, ErrGatewayRefundTooLarge)
+
+	_, err = gateway.GetInvoice("missing")
+	assert.ErrorIs(t, err, ErrGatewayInvoiceNotFound)
+}
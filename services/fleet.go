@@ -0,0 +1,142 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"jakarta-luxury-rent-car/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// inServiceFleetStatuses are the fleet unit states that count towards a car's rentable stock
+var inServiceFleetStatuses = []string{"available", "rented"}
+
+// ErrNoFleetUnitAvailable is returned when a car has registered units but none of them is ready to hand over
+var ErrNoFleetUnitAvailable = errors.New("no fleet unit of this car is available for handover")
+
+// ErrFleetUnitBooked is returned when a picked-up car is extended into another rental of the same unit
+var ErrFleetUnitBooked = errors.New("the car is booked by another rental for the new dates")
+
+// AssignFleetUnit links the rental to a unit of its car that is in service and not handed to another
+// active rental overlapping the rental window, so a unit can be promised to back-to-back rentals.
+// Cars without any registered units return a nil unit so legacy catalog entries can still be rented.
+func AssignFleetUnit(tx *gorm.DB, rental *models.RentalHistory) (*models.FleetUnit, error) {
+	var registered int64
+	if err := tx.Model(&models.FleetUnit{}).Where("car_id = ?", rental.CarID).Count(&registered).Error; err != nil {
+		return nil, err
+	}
+	if registered == 0 {
+		return nil, nil
+	}
+
+	// Prefer the unit with the lowest mileage and skip units another approval is already taking.
+	// Units still flagged rented from before assignments followed the rental window stay eligible.
+	var unit models.FleetUnit
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("car_id = ? AND status IN ?", rental.CarID, inServiceFleetStatuses).
+		Where("fleet_unit_id NOT IN (?)", busyFleetUnits(tx, *rental)).
+		Order("odometer ASC").
+		First(&unit).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNoFleetUnitAvailable
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rental.FleetUnitID = &unit.FleetUnitID
+	if err := tx.Model(rental).Update("fleet_unit_id", unit.FleetUnitID).Error; err != nil {
+		return nil, err
+	}
+
+	return &unit, nil
+}
+
+// KeepFleetUnit checks that the unit already assigned to a rental is still free over its changed window.
+// A rental that is not picked up yet moves to another free unit of its car when the assigned one is taken;
+// a car that is already out cannot be swapped, so ErrFleetUnitBooked is returned instead.
+func KeepFleetUnit(tx *gorm.DB, rental *models.RentalHistory) (*models.FleetUnit, error) {
+	if rental.FleetUnitID == nil {
+		return nil, nil
+	}
+
+	// Locking the unit keeps an approval from assigning it while the new window is checked
+	var unit models.FleetUnit
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&unit, *rental.FleetUnitID).Error; err != nil {
+		return nil, err
+	}
+
+	var clashes int64
+	if err := busyFleetUnits(tx, *rental).Where("fleet_unit_id = ?", unit.FleetUnitID).Count(&clashes).Error; err != nil {
+		return nil, err
+	}
+	if clashes == 0 {
+		return &unit, nil
+	}
+	if rental.Status == RentalStatusRent {
+		return nil, ErrFleetUnitBooked
+	}
+
+	return AssignFleetUnit(tx, rental)
+}
+
+// busyFleetUnits selects the units held by other active rentals that overlap the rental's window
+func busyFleetUnits(tx *gorm.DB, rental models.RentalHistory) *gorm.DB {
+	to := rental.RentalDate.Add(24 * time.Hour)
+	if rental.ReturnDate != nil {
+		to = *rental.ReturnDate
+	}
+	return activeDuring(tx.Model(&models.RentalHistory{}), rental.RentalDate, to).
+		Select("fleet_unit_id").
+		Where("fleet_unit_id IS NOT NULL AND rental_id <> ?", rental.RentalID)
+}
+
+// ReleaseFleetUnit records a non-nil odometer reading on the unit assigned to a rental and clears
+// the rented flag that units assigned before rental-window checks still carry.
+func ReleaseFleetUnit(tx *gorm.DB, rental models.RentalHistory, odometer *int) (*models.FleetUnit, error) {
+	if rental.FleetUnitID == nil {
+		return nil, nil
+	}
+
+	var unit models.FleetUnit
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&unit, *rental.FleetUnitID).Error; err != nil {
+		return nil, err
+	}
+
+	if unit.Status == "rented" {
+		unit.Status = "available"
+	}
+	if odometer != nil && *odometer > unit.Odometer {
+		unit.Odometer = *odometer
+	}
+
+	if err := tx.Save(&unit).Error; err != nil {
+		return nil, err
+	}
+
+	return &unit, nil
+}
+
+// FleetUnitOnRent reports whether a unit is handed to a rental that has not been returned yet
+func FleetUnitOnRent(db *gorm.DB, fleetUnitID uint) (bool, error) {
+	var count int64
+	err := db.Model(&models.RentalHistory{}).
+		Where("fleet_unit_id = ? AND status = ?", fleetUnitID, RentalStatusRent).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// SyncCarStock sets a car's stock to the number of its units that are in service.
+// It is called whenever units of the car are added or change status.
+func SyncCarStock(tx *gorm.DB, carID uint) error {
+	var inService int64
+	if err := tx.Model(&models.FleetUnit{}).
+		Where("car_id = ? AND status IN ?", carID, inServiceFleetStatuses).
+		Count(&inService).Error; err != nil {
+		return err
+	}
+
+	return tx.Model(&models.Car{}).Where("car_id = ?", carID).Update("stock_availability", inService).Error
+}
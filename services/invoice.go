@@ -0,0 +1,237 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"jakarta-luxury-rent-car/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Invoice purposes and the invoice statuses reported by Xendit
+const (
+	InvoicePurposeBooking    = "booking"
+	InvoicePurposeSupplement = "supplement" // Price increase of a paid booking that the deposit did not cover
+	InvoicePurposeTopUp      = "topup"
+
+	InvoiceStatusPending   = "PENDING"
+	InvoiceStatusPaid      = "PAID"
+	InvoiceStatusExpired   = "EXPIRED"
+	InvoiceStatusUnderpaid = "UNDERPAID" // Paid less than the invoice amount, the payment went to the deposit
+)
+
+var ErrUnknownInvoice = errors.New("no invoice with this external_id")
+
+// InvoiceCallback is the part of a payment gateway invoice callback the app acts on
+type InvoiceCallback struct {
+	ExternalID string
+	Status     string
+	PaidAmount float64
+	PaidAt     *time.Time
+}
+
+// InvoiceCallbackResult tells the caller what a callback changed
+type InvoiceCallbackResult struct {
+	Invoice    models.Invoice
+	Rental     models.RentalHistory     // Loaded for booking and supplement invoices
+	TopUp      models.DepositTopUp      // Loaded for top up invoices
+	Credit     models.WalletTransaction // Deposit credit of a paid top up or of an underpaid invoice
+	Applied    bool                     // False when the invoice had already been settled or the status is not one we act on
+	Superseded bool                     // A newer booking invoice replaced this one after the booking changed
+	From       string                   // Rental status before the callback
+}
+
+// NewInvoiceExternalID builds the external_id sent to the gateway. It names the purpose and rental
+// so the callback can be traced back, and the timestamp keeps repeated invoices of one rental apart.
+func NewInvoiceExternalID(purpose string, rentalID uint, now time.Time) string {
+	return fmt.Sprintf("JLRC-%s-%d-%d", purpose, rentalID, now.UnixNano())
+}
+
+// ApplyInvoiceCallback stores the status reported for an invoice and moves its rental or top up along.
+// Only PENDING invoices are updated, so a replayed callback is a no-op. A payment lower than the invoice
+// is kept as UNDERPAID and credited to the customer's deposit instead of being rejected, because the
+// gateway retries a callback until it is acknowledged.
+func ApplyInvoiceCallback(tx *gorm.DB, callback InvoiceCallback) (InvoiceCallbackResult, error) {
+	var result InvoiceCallbackResult
+
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("external_id = ?", callback.ExternalID).
+		First(&result.Invoice).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return result, ErrUnknownInvoice
+	}
+	if err != nil {
+		return result, err
+	}
+
+	if result.Invoice.Status != InvoiceStatusPending {
+		return result, nil
+	}
+
+	switch callback.Status {
+	case InvoiceStatusPaid:
+		paidAt := time.Now()
+		if callback.PaidAt != nil {
+			paidAt = *callback.PaidAt
+		}
+		result.Invoice.PaidAt = &paidAt
+		result.Invoice.PaidAmount = roundCents(callback.PaidAmount)
+	case InvoiceStatusExpired:
+	default:
+		return result, nil
+	}
+
+	result.Invoice.Status = SettledInvoiceStatus(callback.Status, result.Invoice.Amount, callback.PaidAmount)
+	if err := tx.Save(&result.Invoice).Error; err != nil {
+		return result, err
+	}
+	result.Applied = true
+
+	if result.Invoice.Status == InvoiceStatusUnderpaid {
+		return result, creditUnderpayment(tx, &result)
+	}
+
+	if result.Invoice.DepositTopUpID != nil {
+		return result, settleTopUp(tx, &result)
+	}
+
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&result.Rental, *result.Invoice.RentalID).Error; err != nil {
+		return result, err
+	}
+	result.From = result.Rental.Status
+
+	// Only the newest booking invoice of a rental drives its status. An older one was replaced with
+	// the new total, so it expiring must not expire the booking and paying it must not mark it paid.
+	if result.Invoice.Purpose == InvoicePurposeBooking {
+		var newer int64
+		if err := tx.Model(&models.Invoice{}).
+			Where("rental_id = ? AND purpose = ? AND invoice_id > ?", result.Rental.RentalID, InvoicePurposeBooking, result.Invoice.InvoiceID).
+			Count(&newer).Error; err != nil {
+			return result, err
+		}
+		if newer > 0 {
+			result.Superseded = true
+			return result, nil
+		}
+	}
+
+	to := rentalStatusForInvoice(result.Invoice.Purpose, result.Invoice.Status, result.Rental.Status)
+	if to == "" {
+		return result, nil
+	}
+
+	reason := fmt.Sprintf("Invoice %s %s", result.Invoice.ExternalID, result.Invoice.Status)
+	if err := TransitionRental(tx, &result.Rental, to, SystemActor(), reason); err != nil {
+		return result, err
+	}
+
+	if to == RentalStatusExpired {
+		_, err = ReleaseFleetUnit(tx, result.Rental, nil)
+	}
+
+	return result, err
+}
+
+// SettledInvoiceStatus is the status an invoice is stored with after a callback: a payment that does
+// not cover the invoice amount is UNDERPAID rather than PAID
+func SettledInvoiceStatus(status string, amount, paidAmount float64) string {
+	if status == InvoiceStatusPaid && roundCents(paidAmount) < roundCents(amount) {
+		return InvoiceStatusUnderpaid
+	}
+	return status
+}
+
+// creditUnderpayment puts the amount paid on an underpaid invoice on the customer's deposit.
+// The rental keeps waiting for payment and a top up is marked UNDERPAID.
+func creditUnderpayment(tx *gorm.DB, result *InvoiceCallbackResult) error {
+	var userID uint
+	if result.Invoice.DepositTopUpID != nil {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&result.TopUp, *result.Invoice.DepositTopUpID).Error; err != nil {
+			return err
+		}
+		result.TopUp.Status = InvoiceStatusUnderpaid
+		result.TopUp.PaidAt = result.Invoice.PaidAt
+		if err := tx.Save(&result.TopUp).Error; err != nil {
+			return err
+		}
+		userID = result.TopUp.UserID
+	} else {
+		if err := tx.First(&result.Rental, *result.Invoice.RentalID).Error; err != nil {
+			return err
+		}
+		result.From = result.Rental.Status
+		userID = result.Rental.UserID
+	}
+
+	if result.Invoice.PaidAmount <= 0 {
+		return nil
+	}
+
+	var err error
+	result.Credit, err = PostWalletTransaction(tx, WalletEntry{
+		UserID:      userID,
+		Type:        WalletTopUp,
+		Amount:      result.Invoice.PaidAmount,
+		RentalID:    result.Invoice.RentalID,
+		Description: fmt.Sprintf("Underpaid invoice %s", result.Invoice.ExternalID),
+	})
+	return err
+}
+
+// settleTopUp copies the invoice status to its top up and credits the deposit once it is paid
+func settleTopUp(tx *gorm.DB, result *InvoiceCallbackResult) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&result.TopUp, *result.Invoice.DepositTopUpID).Error; err != nil {
+		return err
+	}
+
+	result.TopUp.Status = result.Invoice.Status
+	result.TopUp.PaidAt = result.Invoice.PaidAt
+	if err := tx.Save(&result.TopUp).Error; err != nil {
+		return err
+	}
+
+	if result.TopUp.Status != InvoiceStatusPaid {
+		return nil
+	}
+
+	var err error
+	result.Credit, err = PostWalletTransaction(tx, WalletEntry{
+		UserID:      result.TopUp.UserID,
+		Type:        WalletTopUp,
+		Amount:      result.TopUp.Amount,
+		Description: fmt.Sprintf("Deposit top up #%d (%s)", result.TopUp.DepositTopUpID, result.Invoice.ExternalID),
+	})
+	return err
+}
+
+// UnpaidSupplements sums the supplementary invoices of a rental that were never paid in full.
+// Their amount is part of the rental's total cost but was not collected, so it is not refunded.
+func UnpaidSupplements(tx *gorm.DB, rentalID uint) (float64, error) {
+	var unpaid float64
+	err := tx.Model(&models.Invoice{}).
+		Where("rental_id = ? AND purpose = ? AND status <> ?", rentalID, InvoicePurposeSupplement, InvoiceStatusPaid).
+		Select("COALESCE(SUM(amount), 0)").
+		Scan(&unpaid).Error
+	return roundCents(unpaid), err
+}
+
+// rentalStatusForInvoice returns the status a rental moves to after its invoice settles, or "" to leave it alone.
+// Only the booking invoice of a rental that still waits for payment drives its status;
+// supplementary invoices and late callbacks for rentals that moved on are just recorded.
+func rentalStatusForInvoice(purpose, invoiceStatus, rentalStatus string) string {
+	if purpose != InvoicePurposeBooking || rentalStatus != RentalStatusBook {
+		return ""
+	}
+
+	switch invoiceStatus {
+	case InvoiceStatusPaid:
+		return RentalStatusPaid
+	case InvoiceStatusExpired:
+		return RentalStatusExpired
+	}
+
+	return ""
+}
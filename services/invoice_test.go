@@ -0,0 +1,51 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewInvoiceExternalID(t *testing.T) {
+	now := time.Date(2024, 10, 5, 10, 0, 0, 0, time.UTC)
+
+	first := NewInvoiceExternalID(InvoicePurposeBooking, 42, now)
+	second := NewInvoiceExternalID(InvoicePurposeBooking, 42, now.Add(time.Nanosecond))
+
+	assert.True(t, strings.HasPrefix(first, "JLRC-booking-42-"))
+	assert.NotEqual(t, first, second)
+	assert.LessOrEqual(t, len(first), 64)
+}
+
+func TestRentalStatusForInvoice(t *testing.T) {
+	tests := []struct {
+		name          string
+		purpose       string
+		invoiceStatus string
+		rentalStatus  string
+		expected      string
+	}{
+		{"booking paid", InvoicePurposeBooking, InvoiceStatusPaid, RentalStatusBook, RentalStatusPaid},
+		{"booking expired", InvoicePurposeBooking, InvoiceStatusExpired, RentalStatusBook, RentalStatusExpired},
+		{"already paid from deposit", InvoicePurposeBooking, InvoiceStatusPaid, RentalStatusPaid, ""},
+		{"paid after cancellation", InvoicePurposeBooking, InvoiceStatusPaid, RentalStatusCancel, ""},
+		{"supplement paid", InvoicePurposeSupplement, InvoiceStatusPaid, RentalStatusBook, ""},
+		{"supplement expired", InvoicePurposeSupplement, InvoiceStatusExpired, RentalStatusBook, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, rentalStatusForInvoice(tt.purpose, tt.invoiceStatus, tt.rentalStatus))
+		})
+	}
+}
+
+func TestSettledInvoiceStatus(t *testing.T) {
+	assert.Equal(t, InvoiceStatusPaid, SettledInvoiceStatus(InvoiceStatusPaid, 4500000, 4500000))
+	assert.Equal(t, InvoiceStatusPaid, SettledInvoiceStatus(InvoiceStatusPaid, 4500000, 4500000.004))
+	assert.Equal(t, InvoiceStatusPaid, SettledInvoiceStatus(InvoiceStatusPaid, 4500000, 5000000))
+	assert.Equal(t, InvoiceStatusUnderpaid, SettledInvoiceStatus(InvoiceStatusPaid, 4500000, 4000000))
+	assert.Equal(t, InvoiceStatusExpired, SettledInvoiceStatus(InvoiceStatusExpired, 4500000, 0))
+}
@@ -0,0 +1,37 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// lateReturnGracePeriod is how long after the booked return date a car can come back without a charge
+const lateReturnGracePeriod = time.Hour
+
+var ErrInvalidReturnTime = errors.New("returned_at must be between the rental date and now")
+
+// ValidateReturnTime checks a return time entered at check-in. It sets the late fee and the recorded
+// return, so it cannot be before the rental started or in the future.
+func ValidateReturnTime(rentalDate, returnedAt, now time.Time) error {
+	if returnedAt.Before(rentalDate) || returnedAt.After(now) {
+		return ErrInvalidReturnTime
+	}
+	return nil
+}
+
+// CalculateLateFee charges every started day past the booked return date at the car's daily rate.
+// Returns within the grace period, or rentals without a booked return date, are free.
+func CalculateLateFee(bookedReturn *time.Time, actualReturn time.Time, dailyRate float64) (int, float64) {
+	if bookedReturn == nil {
+		return 0, 0
+	}
+
+	late := actualReturn.Sub(*bookedReturn)
+	if late <= lateReturnGracePeriod {
+		return 0, 0
+	}
+
+	lateDays := int(math.Ceil(late.Hours() / 24))
+	return lateDays, float64(lateDays) * dailyRate
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculateLateFee(t *testing.T) {
+	booked := time.Date(2024, 10, 5, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		actual       time.Time
+		expectedDays int
+		expectedFee  float64
+	}{
+		{"early return", booked.Add(-3 * time.Hour), 0, 0},
+		{"within grace period", booked.Add(45 * time.Minute), 0, 0},
+		{"a few hours late", booked.Add(5 * time.Hour), 1, 150},
+		{"just over a day late", booked.Add(25 * time.Hour), 2, 300},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			days, fee := CalculateLateFee(&booked, tt.actual, 150)
+			assert.Equal(t, tt.expectedDays, days)
+			assert.Equal(t, tt.expectedFee, fee)
+		})
+	}
+}
+
+func TestCalculateLateFee_NoBookedReturnDate(t *testing.T) {
+	days, fee := CalculateLateFee(nil, time.Now(), 150)
+	assert.Equal(t, 0, days)
+	assert.Equal(t, 0.0, fee)
+}
+
+func TestValidateReturnTime(t *testing.T) {
+	rentalDate := time.Date(2024, 10, 1, 10, 0, 0, 0, time.UTC)
+	now := time.Date(2024, 10, 6, 12, 0, 0, 0, time.UTC)
+
+	assert.NoError(t, ValidateReturnTime(rentalDate, rentalDate.Add(48*time.Hour), now))
+	assert.NoError(t, ValidateReturnTime(rentalDate, now, now))
+	assert.ErrorIs(t, ValidateReturnTime(rentalDate, rentalDate.Add(-time.Minute), now), ErrInvalidReturnTime)
+	assert.ErrorIs(t, ValidateReturnTime(rentalDate, now.Add(time.Minute), now), ErrInvalidReturnTime)
+}
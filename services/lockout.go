@@ -0,0 +1,73 @@
+package services
+
+import (
+	"time"
+
+	"jakarta-luxury-rent-car/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	LockoutThreshold   = 5               // Failed logins in a row before the account is locked
+	lockoutBaseLength  = 5 * time.Minute // First lockout, doubled by every further lockout
+	lockoutMaxDuration = 24 * time.Hour
+)
+
+// LockoutDuration is how long an account is locked after its failures-th failed login in a row,
+// 0 when that failure does not lock it. Every LockoutThreshold failures lock it twice as long as before.
+func LockoutDuration(failures int) time.Duration {
+	if failures <= 0 || failures%LockoutThreshold != 0 {
+		return 0
+	}
+
+	duration := lockoutBaseLength
+	for i := LockoutThreshold; i < failures; i += LockoutThreshold {
+		duration *= 2
+		if duration >= lockoutMaxDuration {
+			return lockoutMaxDuration
+		}
+	}
+	return duration
+}
+
+// IsLocked reports whether user is locked out of logging in at now
+func IsLocked(user models.User, now time.Time) bool {
+	return user.LockedUntil != nil && now.Before(*user.LockedUntil)
+}
+
+// RecordLoginFailure counts a wrong password for user and locks the account when it reaches a threshold.
+// It returns when the account is locked until, or nil when this failure did not lock it.
+func RecordLoginFailure(db *gorm.DB, userID uint, now time.Time) (*time.Time, error) {
+	var lockedUntil *time.Time
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("user_id", "failed_login_attempts").
+			First(&user, userID).Error; err != nil {
+			return err
+		}
+
+		failures := user.FailedLoginAttempts + 1
+		updates := map[string]interface{}{"failed_login_attempts": failures}
+		if duration := LockoutDuration(failures); duration > 0 {
+			until := now.Add(duration)
+			lockedUntil = &until
+			updates["locked_until"] = until
+		}
+
+		return tx.Model(&user).Updates(updates).Error
+	})
+
+	return lockedUntil, err
+}
+
+// ResetLoginFailures clears the failed login count and any lockout of user
+func ResetLoginFailures(db *gorm.DB, userID uint) error {
+	return db.Model(&models.User{}).Where("user_id = ?", userID).Updates(map[string]interface{}{
+		"failed_login_attempts": 0,
+		"locked_until":          nil,
+	}).Error
+}
@@ -0,0 +1,31 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"jakarta-luxury-rent-car/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLockoutDuration(t *testing.T) {
+	for failures := 0; failures < LockoutThreshold; failures++ {
+		assert.Zero(t, LockoutDuration(failures), failures)
+	}
+
+	assert.Equal(t, 5*time.Minute, LockoutDuration(5))
+	assert.Zero(t, LockoutDuration(6))
+	assert.Equal(t, 10*time.Minute, LockoutDuration(10))
+	assert.Equal(t, 20*time.Minute, LockoutDuration(15))
+	assert.Equal(t, 24*time.Hour, LockoutDuration(100))
+}
+
+func TestIsLocked(t *testing.T) {
+	now := time.Date(2024, time.August, 17, 9, 0, 0, 0, time.UTC)
+	until := now.Add(5 * time.Minute)
+
+	assert.False(t, IsLocked(models.User{}, now))
+	assert.True(t, IsLocked(models.User{LockedUntil: &until}, now))
+	assert.False(t, IsLocked(models.User{LockedUntil: &until}, until))
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// LogNotifier writes messages to stdout or a file instead of sending them, for local development
+type LogNotifier struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+// NewLogNotifier writes to stdout when path is "stdout", otherwise appends to the file at path
+func NewLogNotifier(path string) (*LogNotifier, error) {
+	if path == "stdout" {
+		return &LogNotifier{out: os.Stdout}, nil
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open notification log: %v", err)
+	}
+	return &LogNotifier{out: file}, nil
+}
+
+// NewWriterNotifier writes messages to w
+func NewWriterNotifier(w io.Writer) *LogNotifier {
+	return &LogNotifier{out: w}
+}
+
+func (n *LogNotifier) Channel() string {
+	return ChannelLog
+}
+
+func (n *LogNotifier) Send(to Recipient, msg Message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	_, err := fmt.Fprintf(n.out, "--- %s to email=%q phone=%q subject=%q\n%s\n\n",
+		time.Now().Format(time.RFC3339), to.Email, to.PhoneNumber, msg.Subject, msg.Body)
+	return err
+}
@@ -0,0 +1,310 @@
+package services
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"text/template"
+	"time"
+
+	"jakarta-luxury-rent-car/models"
+
+	"gorm.io/gorm"
+)
+
+// Supported message locales, stored in users.preferred_language
+const (
+	LocaleID      = "id"
+	LocaleEN      = "en"
+	DefaultLocale = LocaleID
+)
+
+// Message events, each has a default template per locale in templates/<locale>/<event>.tmpl
+const (
+	EventBookingConfirmation    = "booking_confirmation"
+	EventBookingInvoice         = "booking_invoice"
+	EventBookingUpdated         = "booking_updated"
+	EventSupplementaryInvoice   = "supplementary_invoice"
+	EventBookingCancelled       = "booking_cancelled"
+	EventBookingApproved        = "booking_approved"
+	EventBookingApprovedOwner   = "booking_approved_owner"
+	EventBookingRejected        = "booking_rejected"
+	EventPaymentReceived        = "payment_received"
+	EventPaymentReceivedOwner   = "payment_received_owner"
+	EventPaymentAfterClose      = "payment_after_close"
+	EventPaymentReplacedInvoice = "payment_replaced_invoice"
+	EventPaymentUnderpaid       = "payment_underpaid"
+	EventInvoiceExpired         = "invoice_expired"
+	EventTopUpReceived          = "topup_received"
+	EventRefundCompleted        = "refund_completed"
+	EventRentalCompleted        = "rental_completed"
+	EventCallAssistanceOwner    = "call_assistance_owner"
+	EventReminderPickup         = "reminder_pickup"
+	EventReminderReturn         = "reminder_return"
+	EventOverdueOwner           = "overdue_owner"
+	EventInvoiceReminder        = "invoice_reminder"
+	EventStaffInvitation        = "staff_invitation"
+	EventOTPCode                = "otp_code"
+	EventAccountLocked          = "account_locked"
+)
+
+var ErrUnknownTemplate = errors.New("unknown message template")
+
+// MessageEvents lists every event in the order owners see them
+var MessageEvents = []string{
+	EventBookingConfirmation,
+	EventBookingInvoice,
+	EventBookingUpdated,
+	EventSupplementaryInvoice,
+	EventBookingCancelled,
+	EventBookingApproved,
+	EventBookingApprovedOwner,
+	EventBookingRejected,
+	EventPaymentReceived,
+	EventPaymentReceivedOwner,
+	EventPaymentAfterClose,
+	EventPaymentReplacedInvoice,
+	EventPaymentUnderpaid,
+	EventInvoiceExpired,
+	EventTopUpReceived,
+	EventRefundCompleted,
+	EventRentalCompleted,
+	EventCallAssistanceOwner,
+	EventReminderPickup,
+	EventReminderReturn,
+	EventOverdueOwner,
+	EventInvoiceReminder,
+	EventStaffInvitation,
+	EventOTPCode,
+	EventAccountLocked,
+}
+
+// MessageLocales lists the supported locales
+var MessageLocales = []string{LocaleID, LocaleEN}
+
+//go:embed templates
+var templateFS embed.FS
+
+// TemplateSource is the subject and body template text of one event and locale
+type TemplateSource struct {
+	Event      string `json:"event"`
+	Locale     string `json:"locale"`
+	Subject    string `json:"subject"`
+	Body       string `json:"body"`
+	Overridden bool   `json:"overridden"`
+}
+
+// DefaultTemplate returns the bundled template of an event and locale. A template file holds
+// the subject on its first line, a "---" line, then the body.
+func DefaultTemplate(event, locale string) (TemplateSource, error) {
+	content, err := templateFS.ReadFile("templates/" + locale + "/" + event + ".tmpl")
+	if err != nil {
+		return TemplateSource{}, fmt.Errorf("%w: %s/%s", ErrUnknownTemplate, locale, event)
+	}
+
+	subject, body, found := strings.Cut(string(content), "\n---\n")
+	if !found {
+		return TemplateSource{}, fmt.Errorf("template %s/%s: missing --- between subject and body", locale, event)
+	}
+
+	return TemplateSource{Event: event, Locale: locale, Subject: subject, Body: strings.TrimRight(body, "\n")}, nil
+}
+
+// LoadTemplate returns the owner's override of an event and locale, or the bundled default
+func LoadTemplate(db *gorm.DB, event, locale string) (TemplateSource, error) {
+	source, err := DefaultTemplate(event, locale)
+	if err != nil {
+		return source, err
+	}
+
+	var override models.MessageTemplate
+	err = db.Where("event = ? AND locale = ?", event, locale).First(&override).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return source, nil
+	}
+	if err != nil {
+		return source, err
+	}
+
+	source.Subject = override.Subject
+	source.Body = override.Body
+	source.Overridden = true
+	return source, nil
+}
+
+// RenderMessage renders the message of an event in the given locale, falling back to the default
+// locale for unknown locales. A broken owner override falls back to the bundled template.
+func RenderMessage(db *gorm.DB, event, locale string, data interface{}) (Message, error) {
+	if !IsMessageLocale(locale) {
+		locale = DefaultLocale
+	}
+
+	source, err := LoadTemplate(db, event, locale)
+	if err != nil {
+		return Message{}, err
+	}
+
+	msg, err := source.Render(data)
+	if err != nil && source.Overridden {
+		log.Printf("Template override %s/%s failed, using default: %v", locale, event, err)
+		source, err = DefaultTemplate(event, locale)
+		if err != nil {
+			return Message{}, err
+		}
+		return source.Render(data)
+	}
+	return msg, err
+}
+
+// Render executes the subject and body templates with data
+func (s TemplateSource) Render(data interface{}) (Message, error) {
+	subject, err := executeTemplate(s.Locale, s.Subject, data)
+	if err != nil {
+		return Message{}, fmt.Errorf("subject: %v", err)
+	}
+	body, err := executeTemplate(s.Locale, s.Body, data)
+	if err != nil {
+		return Message{}, fmt.Errorf("body: %v", err)
+	}
+	return Message{Subject: strings.TrimSpace(subject), Body: body}, nil
+}
+
+// ValidateTemplate checks that subject and body parse and render against sample data, so owners
+// cannot save a template that breaks at send time. It returns the rendered sample.
+func ValidateTemplate(locale, subject, body string) (Message, error) {
+	return TemplateSource{Locale: locale, Subject: subject, Body: body}.Render(SampleTemplateData())
+}
+
+// SampleTemplateData holds every field any event passes to its template, for validation and previews
+func SampleTemplateData() map[string]interface{} {
+	rentalDate := time.Date(2024, time.August, 17, 9, 0, 0, 0, time.UTC)
+	returnDate := rentalDate.AddDate(0, 0, 3)
+	user := models.User{UserID: 1, Email: "customer@example.com", PhoneNumber: "6281234567890", Role: "user", DepositAmount: 5000000}
+
+	return map[string]interface{}{
+		"User":     user,
+		"Customer": user,
+		"Rental": models.RentalHistory{
+			RentalID: 42, UserID: 1, CarID: 1, RentalDate: rentalDate, ReturnDate: &returnDate, ActualReturnDate: &returnDate,
+			PickupLocation: "Soekarno-Hatta Airport", DropoffLocation: "Grand Indonesia", TotalCost: 4500000, LateFee: 1500000,
+			Status: RentalStatusBook, AirportTransfer: true,
+		},
+		"Car":              models.Car{CarID: 1, Name: "Rolls-Royce Ghost", Category: "Sedan", Make: "Rolls-Royce", Model: "Ghost", Transmission: "Automatic", Year: 2023, FuelType: "Petrol", Class: "Luxury"},
+		"Driver":           models.Driver{Name: "Budi", PhoneNumber: "6281111111111"},
+		"Package":          models.EventPackage{PackageName: "Wedding", Description: "Decorated car for the wedding day"},
+		"FleetUnit":        &models.FleetUnit{LicensePlate: "B 1 JLR", Colour: "Black", VIN: "SCA664S50HUX00001"},
+		"Invoice":          models.Invoice{Amount: 4500000, PaidAmount: 4000000, InvoiceURL: "https://checkout.xendit.co/web/sample", ExpiresAt: &returnDate},
+		"TopUp":            models.DepositTopUp{Amount: 1000000},
+		"Refund":           models.Refund{RefundID: 7, RentalID: 42, Amount: 2250000},
+		"Assistance":       models.CallAssistance{CallAssistanceDate: rentalDate, Location: "Jl. Sudirman, Jakarta", Description: "Flat tyre"},
+		"MapsLink":         "https://www.google.com/maps/search/?api=1&query=Jl.+Sudirman%2C+Jakarta",
+		"Amount":           500000.0,
+		"Charged":          500000.0,
+		"Refunded":         0.0,
+		"RefundAmount":     2250000.0,
+		"RefundPercent":    50.0,
+		"LateDays":         1,
+		"From":             RentalStatusCancel,
+		"ToDeposit":        true,
+		"Invitation":       models.UserInvitation{Email: "staff@example.com", Role: RoleStaff, ExpiresAt: returnDate},
+		"InviteLink":       "http://localhost:8080/invitations/accept?token=sample",
+		"Code":             "123456",
+		"Purpose":          OTPPhoneVerification,
+		"ExpiresInMinutes": 10,
+		"LockedUntil":      returnDate,
+	}
+}
+
+func executeTemplate(locale, text string, data interface{}) (string, error) {
+	tmpl, err := parseTemplate(locale, text)
+	if err != nil {
+		return "", err
+	}
+
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+func parseTemplate(locale, text string) (*template.Template, error) {
+	return template.New("message").Option("missingkey=error").Funcs(templateFuncs(locale)).Parse(text)
+}
+
+// templateFuncs are the formatting helpers available in templates, in the template's locale
+func templateFuncs(locale string) template.FuncMap {
+	return template.FuncMap{
+		"money": func(amount float64) string { return FormatIDR(amount, locale) },
+		"date":  func(value interface{}) string { return FormatDate(value, locale) },
+		"percent": func(value float64) string {
+			return fmt.Sprintf("%.0f%%", value)
+		},
+	}
+}
+
+// IsMessageLocale reports whether locale has bundled templates
+func IsMessageLocale(locale string) bool {
+	for _, l := range MessageLocales {
+		if l == locale {
+			return true
+		}
+	}
+	return false
+}
+
+// FormatIDR formats an amount in rupiah: "Rp1.500.000" in Indonesian, "IDR 1,500,000" in English
+func FormatIDR(amount float64, locale string) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	separator, prefix := ",", "IDR "
+	if locale == LocaleID {
+		separator, prefix = ".", "Rp"
+	}
+
+	digits := fmt.Sprintf("%.0f", math.Round(amount))
+	var grouped strings.Builder
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			grouped.WriteString(separator)
+		}
+		grouped.WriteRune(digit)
+	}
+
+	return sign + prefix + grouped.String()
+}
+
+var indonesianMonths = [...]string{
+	"Januari", "Februari", "Maret", "April", "Mei", "Juni",
+	"Juli", "Agustus", "September", "Oktober", "November", "Desember",
+}
+
+// FormatDate formats a time.Time or *time.Time as "02 January 2006 15:04", with Indonesian
+// month names for LocaleID. Nil times are shown as "-".
+func FormatDate(value interface{}, locale string) string {
+	var t time.Time
+	switch v := value.(type) {
+	case time.Time:
+		t = v
+	case *time.Time:
+		if v == nil {
+			return "-"
+		}
+		t = *v
+	default:
+		return fmt.Sprint(value)
+	}
+
+	if locale == LocaleID {
+		return fmt.Sprintf("%02d %s %d %s", t.Day(), indonesianMonths[t.Month()-1], t.Year(), t.Format("15:04"))
+	}
+	return t.Format("02 January 2006 15:04")
+}
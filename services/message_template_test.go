@@ -0,0 +1,80 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultTemplatesRenderInEveryLocale(t *testing.T) {
+	for _, locale := range MessageLocales {
+		for _, event := range MessageEvents {
+			source, err := DefaultTemplate(event, locale)
+			if !assert.NoError(t, err, "%s/%s", locale, event) {
+				continue
+			}
+
+			msg, err := source.Render(SampleTemplateData())
+			assert.NoError(t, err, "%s/%s", locale, event)
+			assert.NotEmpty(t, msg.Subject, "%s/%s", locale, event)
+			assert.NotContains(t, msg.Body, "<no value>", "%s/%s", locale, event)
+		}
+	}
+}
+
+func TestDefaultTemplateFormatsPerLocale(t *testing.T) {
+	source, err := DefaultTemplate(EventBookingInvoice, LocaleID)
+	assert.NoError(t, err)
+	msg, err := source.Render(SampleTemplateData())
+	assert.NoError(t, err)
+	assert.Contains(t, msg.Body, "Rp4.500.000")
+
+	source, err = DefaultTemplate(EventBookingConfirmation, LocaleEN)
+	assert.NoError(t, err)
+	msg, err = source.Render(SampleTemplateData())
+	assert.NoError(t, err)
+	assert.Contains(t, msg.Body, "IDR 4,500,000")
+	assert.Contains(t, msg.Body, "17 August 2024 09:00")
+	assert.Equal(t, "Booking Confirmation - [Rental ID: 42]", msg.Subject)
+
+	_, err = DefaultTemplate("no_such_event", LocaleEN)
+	assert.ErrorIs(t, err, ErrUnknownTemplate)
+}
+
+func TestValidateTemplate(t *testing.T) {
+	msg, err := ValidateTemplate(LocaleID, "Sewa {{.Rental.RentalID}}", "Total {{money .Rental.TotalCost}}")
+	assert.NoError(t, err)
+	assert.Equal(t, "Sewa 42", msg.Subject)
+	assert.Equal(t, "Total Rp4.500.000", msg.Body)
+
+	_, err = ValidateTemplate(LocaleID, "Sewa {{.Rental.RentalID", "body")
+	assert.Error(t, err)
+
+	_, err = ValidateTemplate(LocaleID, "subject", "{{.Rental.NoSuchField}}")
+	assert.Error(t, err)
+
+	_, err = ValidateTemplate(LocaleID, "subject", "{{.NoSuchKey}}")
+	assert.Error(t, err)
+}
+
+func TestFormatIDR(t *testing.T) {
+	assert.Equal(t, "Rp0", FormatIDR(0, LocaleID))
+	assert.Equal(t, "Rp999", FormatIDR(999, LocaleID))
+	assert.Equal(t, "Rp1.500.000", FormatIDR(1500000, LocaleID))
+	assert.Equal(t, "Rp1.000", FormatIDR(999.5, LocaleID))
+	assert.Equal(t, "-Rp250.000", FormatIDR(-250000, LocaleID))
+	assert.Equal(t, "IDR 1,500,000", FormatIDR(1500000, LocaleEN))
+	assert.Equal(t, "IDR 12,345,678", FormatIDR(12345678, LocaleEN))
+}
+
+func TestFormatDate(t *testing.T) {
+	date := time.Date(2024, time.March, 5, 14, 30, 0, 0, time.UTC)
+
+	assert.Equal(t, "05 Maret 2024 14:30", FormatDate(date, LocaleID))
+	assert.Equal(t, "05 March 2024 14:30", FormatDate(date, LocaleEN))
+	assert.Equal(t, "05 Maret 2024 14:30", FormatDate(&date, LocaleID))
+
+	var missing *time.Time
+	assert.Equal(t, "-", FormatDate(missing, LocaleEN))
+}
@@ -0,0 +1,206 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// Notification channels a user can prefer, stored in users.preferred_channel
+const (
+	ChannelWhatsApp = "whatsapp"
+	ChannelEmail    = "email"
+	ChannelSMS      = "sms"
+	ChannelLog      = "log" // Local development, writes messages to stdout or a file
+)
+
+var (
+	ErrNoRecipientAddress = errors.New("recipient has no address for this channel")
+	ErrNoChannelAvailable = errors.New("no notification channel available for recipient")
+)
+
+// Recipient is who a notification is sent to, each channel picks the address it needs
+type Recipient struct {
+	Email       string
+	PhoneNumber string // Without the leading "+", as stored in users.phone_number
+}
+
+// Message is the content of a notification. Subject is only used by channels that have one.
+type Message struct {
+	Subject string
+	Body    string
+}
+
+// Notifier delivers messages over a single channel
+type Notifier interface {
+	Channel() string
+	Send(to Recipient, msg Message) error
+}
+
+// NotificationService sends a message over the recipient's preferred channel and falls back
+// to the other configured channels in order when it fails
+type NotificationService struct {
+	notifiers map[string]Notifier
+	fallbacks []string
+}
+
+// NewNotificationService creates a service over the given notifiers. fallbacks is the order in which
+// channels are tried after the preferred one; notifiers missing from it are tried last.
+func NewNotificationService(fallbacks []string, notifiers ...Notifier) *NotificationService {
+	service := &NotificationService{notifiers: map[string]Notifier{}}
+	for _, notifier := range notifiers {
+		service.notifiers[notifier.Channel()] = notifier
+	}
+
+	seen := map[string]bool{}
+	for _, channel := range fallbacks {
+		if _, ok := service.notifiers[channel]; ok && !seen[channel] {
+			service.fallbacks = append(service.fallbacks, channel)
+			seen[channel] = true
+		}
+	}
+	for _, notifier := range notifiers {
+		if !seen[notifier.Channel()] {
+			service.fallbacks = append(service.fallbacks, notifier.Channel())
+			seen[notifier.Channel()] = true
+		}
+	}
+
+	return service
+}
+
+// Channels returns the configured channels in fallback order
+func (s *NotificationService) Channels() []string {
+	return append([]string(nil), s.fallbacks...)
+}
+
+// Send delivers msg over the preferred channel, or the first fallback that succeeds.
+// It returns the channel used, or the errors of every channel tried.
+func (s *NotificationService) Send(to Recipient, preferred string, msg Message) (string, error) {
+	return s.sendOver(to, s.channelOrder(preferred), msg)
+}
+
+// SendOnly delivers msg over the first of channels that is configured and succeeds, without
+// falling back to any other channel. It is used for messages that must not leave those channels.
+func (s *NotificationService) SendOnly(to Recipient, channels []string, msg Message) (string, error) {
+	order := make([]string, 0, len(channels))
+	for _, channel := range channels {
+		if _, ok := s.notifiers[channel]; ok {
+			order = append(order, channel)
+		}
+	}
+	return s.sendOver(to, order, msg)
+}
+
+func (s *NotificationService) sendOver(to Recipient, channels []string, msg Message) (string, error) {
+	var errs []error
+	for _, channel := range channels {
+		err := s.notifiers[channel].Send(to, msg)
+		if err == nil {
+			return channel, nil
+		}
+		if !errors.Is(err, ErrNoRecipientAddress) {
+			errs = append(errs, fmt.Errorf("%s: %w", channel, err))
+		}
+	}
+
+	if len(errs) == 0 {
+		return "", ErrNoChannelAvailable
+	}
+	return "", errors.Join(errs...)
+}
+
+func (s *NotificationService) channelOrder(preferred string) []string {
+	order := make([]string, 0, len(s.fallbacks)+1)
+	if _, ok := s.notifiers[preferred]; ok {
+		order = append(order, preferred)
+	}
+	for _, channel := range s.fallbacks {
+		if channel != preferred {
+			order = append(order, channel)
+		}
+	}
+	return order
+}
+
+// IsNotificationChannel reports whether channel is a known notification channel. Users can prefer
+// every channel but log, which only serves as a development fallback in NOTIFICATION_FALLBACKS.
+func IsNotificationChannel(channel string) bool {
+	switch channel {
+	case ChannelWhatsApp, ChannelEmail, ChannelSMS, ChannelLog:
+		return true
+	}
+	return false
+}
+
+// NewNotificationServiceFromEnv configures every channel whose credentials are set:
+// TWILIO_ACCOUNT_SID/TWILIO_AUTH_TOKEN with TWILIO_WHATSAPP_FROM and/or TWILIO_SMS_FROM
+// (the older twilioAccountSID/twilioAuthToken/twilioPhoneNumber names are still read),
+// SMTP_HOST/SMTP_PORT/SMTP_USERNAME/SMTP_PASSWORD/SMTP_FROM, and NOTIFICATION_LOG_FILE
+// ("stdout" or a path). NOTIFICATION_FALLBACKS sets the fallback order, e.g. "whatsapp,sms,email".
+// Without any channel configured messages are logged to stdout.
+func NewNotificationServiceFromEnv() (*NotificationService, error) {
+	var notifiers []Notifier
+
+	accountSID := envOr("TWILIO_ACCOUNT_SID", "twilioAccountSID")
+	authToken := envOr("TWILIO_AUTH_TOKEN", "twilioAuthToken")
+	if accountSID != "" && authToken != "" {
+		if from := envOr("TWILIO_WHATSAPP_FROM", "twilioPhoneNumber"); from != "" {
+			notifiers = append(notifiers, NewTwilioNotifier(ChannelWhatsApp, accountSID, authToken, from))
+		}
+		if from := os.Getenv("TWILIO_SMS_FROM"); from != "" {
+			notifiers = append(notifiers, NewTwilioNotifier(ChannelSMS, accountSID, authToken, from))
+		}
+	}
+
+	if host := os.Getenv("SMTP_HOST"); host != "" {
+		port := os.Getenv("SMTP_PORT")
+		if port == "" {
+			port = "587"
+		}
+		from := os.Getenv("SMTP_FROM")
+		if from == "" {
+			return nil, errors.New("SMTP_FROM is required when SMTP_HOST is set")
+		}
+		notifiers = append(notifiers, NewSMTPNotifier(host, port, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), from))
+	}
+
+	logFile := os.Getenv("NOTIFICATION_LOG_FILE")
+	if logFile == "" && len(notifiers) == 0 {
+		log.Println("No notification channel configured, notifications are logged to stdout")
+		logFile = "stdout"
+	}
+	if logFile != "" {
+		notifier, err := NewLogNotifier(logFile)
+		if err != nil {
+			return nil, err
+		}
+		notifiers = append(notifiers, notifier)
+	}
+
+	fallbacks := []string{ChannelWhatsApp, ChannelSMS, ChannelEmail, ChannelLog}
+	if value := os.Getenv("NOTIFICATION_FALLBACKS"); value != "" {
+		fallbacks = nil
+		for _, channel := range strings.Split(value, ",") {
+			channel = strings.TrimSpace(channel)
+			if !IsNotificationChannel(channel) {
+				return nil, fmt.Errorf("unknown notification channel %q in NOTIFICATION_FALLBACKS", channel)
+			}
+			fallbacks = append(fallbacks, channel)
+		}
+	}
+
+	return NewNotificationService(fallbacks, notifiers...), nil
+}
+
+// envOr returns the first of the given environment variables that is set
+func envOr(keys ...string) string {
+	for _, key := range keys {
+		if value := os.Getenv(key); value != "" {
+			return value
+		}
+	}
+	return ""
+}
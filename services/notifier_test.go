@@ -0,0 +1,158 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type stubNotifier struct {
+	channel string
+	err     error
+	sent    []Message
+}
+
+func (n *stubNotifier) Channel() string { return n.channel }
+
+func (n *stubNotifier) Send(to Recipient, msg Message) error {
+	if n.err != nil {
+		return n.err
+	}
+	n.sent = append(n.sent, msg)
+	return nil
+}
+
+func TestNotificationServiceUsesPreferredChannel(t *testing.T) {
+	whatsapp := &stubNotifier{channel: ChannelWhatsApp}
+	email := &stubNotifier{channel: ChannelEmail}
+	service := NewNotificationService([]string{ChannelWhatsApp, ChannelEmail}, whatsapp, email)
+
+	channel, err := service.Send(Recipient{Email: "a@b.c"}, ChannelEmail, Message{Body: "hi"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, ChannelEmail, channel)
+	assert.Len(t, email.sent, 1)
+	assert.Empty(t, whatsapp.sent)
+}
+
+func TestNotificationServiceFallsBackInOrder(t *testing.T) {
+	whatsapp := &stubNotifier{channel: ChannelWhatsApp, err: errors.New("twilio down")}
+	sms := &stubNotifier{channel: ChannelSMS, err: ErrNoRecipientAddress}
+	email := &stubNotifier{channel: ChannelEmail}
+	service := NewNotificationService([]string{ChannelSMS, ChannelEmail}, email, whatsapp, sms)
+
+	channel, err := service.Send(Recipient{Email: "a@b.c"}, ChannelWhatsApp, Message{Body: "hi"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, ChannelEmail, channel)
+	assert.Equal(t, []string{ChannelSMS, ChannelEmail, ChannelWhatsApp}, service.Channels())
+}
+
+func TestNotificationServiceReportsEveryFailure(t *testing.T) {
+	whatsapp := &stubNotifier{channel: ChannelWhatsApp, err: errors.New("twilio down")}
+	email := &stubNotifier{channel: ChannelEmail, err: errors.New("smtp down")}
+	service := NewNotificationService(nil, whatsapp, email)
+
+	_, err := service.Send(Recipient{Email: "a@b.c", PhoneNumber: "62811"}, "", Message{Body: "hi"})
+
+	assert.ErrorContains(t, err, "whatsapp: twilio down")
+	assert.ErrorContains(t, err, "email: smtp down")
+
+	noAddress := NewNotificationService(nil, &stubNotifier{channel: ChannelSMS, err: ErrNoRecipientAddress})
+	_, err = noAddress.Send(Recipient{}, ChannelSMS, Message{Body: "hi"})
+	assert.ErrorIs(t, err, ErrNoChannelAvailable)
+}
+
+func TestNotificationServiceSendOnlyNeverFallsBackElsewhere(t *testing.T) {
+	whatsapp := &stubNotifier{channel: ChannelWhatsApp, err: errors.New("twilio down")}
+	sms := &stubNotifier{channel: ChannelSMS}
+	log := &stubNotifier{channel: ChannelLog}
+	service := NewNotificationService([]string{ChannelLog, ChannelWhatsApp, ChannelSMS}, log, whatsapp, sms)
+
+	channel, err := service.SendOnly(Recipient{PhoneNumber: "62811"}, []string{ChannelWhatsApp, ChannelSMS}, Message{Body: "123456"})
+	assert.NoError(t, err)
+	assert.Equal(t, ChannelSMS, channel)
+
+	sms.err = errors.New("sms down")
+	_, err = service.SendOnly(Recipient{PhoneNumber: "62811"}, []string{ChannelWhatsApp, ChannelSMS}, Message{Body: "123456"})
+	assert.ErrorContains(t, err, "sms: sms down")
+	assert.Len(t, sms.sent, 1)
+	assert.Empty(t, log.sent)
+
+	logOnly := NewNotificationService(nil, log)
+	_, err = logOnly.SendOnly(Recipient{PhoneNumber: "62811"}, []string{ChannelWhatsApp, ChannelSMS}, Message{Body: "123456"})
+	assert.ErrorIs(t, err, ErrNoChannelAvailable)
+	assert.Empty(t, log.sent)
+}
+
+func TestTwilioNotifierWhatsApp(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/2010-04-01/Accounts/AC123/Messages.json", r.URL.Path)
+		username, password, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "AC123", username)
+		assert.Equal(t, "token", password)
+
+		r.ParseForm()
+		assert.Equal(t, "whatsapp:+628111", r.PostForm.Get("To"))
+		assert.Equal(t, "whatsapp:+14155238886", r.PostForm.Get("From"))
+		assert.Equal(t, "hello", r.PostForm.Get("Body"))
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	notifier := NewTwilioNotifier(ChannelWhatsApp, "AC123", "token", "whatsapp:+14155238886")
+	notifier.BaseURL = server.URL
+
+	assert.NoError(t, notifier.Send(Recipient{PhoneNumber: "628111"}, Message{Body: "hello"}))
+	assert.ErrorIs(t, notifier.Send(Recipient{Email: "a@b.c"}, Message{Body: "hello"}), ErrNoRecipientAddress)
+}
+
+func TestTwilioNotifierSMSFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		assert.Equal(t, "+628111", r.PostForm.Get("To"))
+		assert.Equal(t, "+15005550006", r.PostForm.Get("From"))
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	notifier := NewTwilioNotifier(ChannelSMS, "AC123", "token", "+15005550006")
+	notifier.BaseURL = server.URL
+
+	assert.ErrorContains(t, notifier.Send(Recipient{PhoneNumber: "+628111"}, Message{Body: "hello"}), "status: 400")
+}
+
+func TestSMTPNotifierBuildsMessage(t *testing.T) {
+	notifier := NewSMTPNotifier("smtp.example.com", "587", "", "", "noreply@example.com")
+
+	var sentTo []string
+	var sent string
+	notifier.sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		assert.Equal(t, "smtp.example.com:587", addr)
+		assert.Equal(t, "noreply@example.com", from)
+		sentTo = to
+		sent = string(msg)
+		return nil
+	}
+
+	assert.NoError(t, notifier.Send(Recipient{Email: "customer@example.com"}, Message{Subject: "Booking", Body: "line 1\nline 2"}))
+	assert.Equal(t, []string{"customer@example.com"}, sentTo)
+	assert.Contains(t, sent, "Subject: Booking\r\n")
+	assert.Contains(t, sent, "line 1\r\nline 2")
+	assert.ErrorIs(t, notifier.Send(Recipient{PhoneNumber: "628111"}, Message{Body: "hi"}), ErrNoRecipientAddress)
+}
+
+func TestLogNotifierWritesMessage(t *testing.T) {
+	var out bytes.Buffer
+	notifier := NewWriterNotifier(&out)
+
+	assert.NoError(t, notifier.Send(Recipient{Email: "a@b.c"}, Message{Subject: "Hi", Body: "body"}))
+	assert.Contains(t, out.String(), `subject="Hi"`)
+	assert.Contains(t, out.String(), "body")
+}
@@ -0,0 +1,217 @@
+package services
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"jakarta-luxury-rent-car/models"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// One-time code purposes stored in one_time_codes.purpose
+const (
+	OTPPhoneVerification = "phone_verification"
+	OTPPasswordReset     = "password_reset"
+	OTPLogin             = "login" // Second factor of owner accounts
+)
+
+const (
+	OTPLength         = 6
+	OTPTTL            = 10 * time.Minute
+	OTPMaxAttempts    = 5
+	OTPResendInterval = time.Minute
+	OTPMaxPerHour     = 5  // Codes one account may be sent in an hour, over every purpose
+	OTPMaxPerDay      = 10 // Codes one account may be sent in a day, over every purpose
+)
+
+// OTPChannels are the only channels codes are sent over, WhatsApp first. Codes prove the phone
+// number belongs to the user, so they never go to email or to the log.
+var OTPChannels = []string{ChannelWhatsApp, ChannelSMS}
+
+var (
+	ErrOTPInvalid          = errors.New("one-time code is invalid")
+	ErrOTPExpired          = errors.New("one-time code has expired, request a new one")
+	ErrOTPTooManyAttempts  = errors.New("too many wrong codes, request a new one")
+	ErrOTPResendTooSoon    = errors.New("a code was sent less than a minute ago")
+	ErrOTPQuotaExceeded    = errors.New("too many codes were sent to this account, try again later")
+	ErrPhoneNotVerified    = errors.New("phone number is not verified")
+	ErrTwoFactorNotAllowed = errors.New("two-factor login is for owner and admin accounts")
+)
+
+// GenerateOTPCode returns a random numeric code of OTPLength digits
+func GenerateOTPCode() (string, error) {
+	max := big.NewInt(1)
+	for i := 0; i < OTPLength; i++ {
+		max.Mul(max, big.NewInt(10))
+	}
+
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", OTPLength, n), nil
+}
+
+// CheckOTPResend validates that a new code may be sent at now, given when the last one was sent
+func CheckOTPResend(last *models.OneTimeCode, now time.Time) error {
+	if last != nil && now.Sub(last.CreatedAt) < OTPResendInterval {
+		return ErrOTPResendTooSoon
+	}
+	return nil
+}
+
+// CheckOTPQuota validates that another code may be sent to an account that was sent
+// sentLastHour codes in the last hour and sentLastDay codes in the last day
+func CheckOTPQuota(sentLastHour, sentLastDay int64) error {
+	if sentLastHour >= OTPMaxPerHour || sentLastDay >= OTPMaxPerDay {
+		return ErrOTPQuotaExceeded
+	}
+	return nil
+}
+
+// CheckOTP validates that a stored code can still be tried at now
+func CheckOTP(otp models.OneTimeCode, now time.Time) error {
+	if otp.Attempts >= OTPMaxAttempts {
+		return ErrOTPTooManyAttempts
+	}
+	if !now.Before(otp.ExpiresAt) {
+		return ErrOTPExpired
+	}
+	return nil
+}
+
+// IssueOTP creates a new code for user and purpose and returns it in clear text to be sent.
+// Earlier unused codes for the same purpose stop working, and an account is sent at most
+// OTPMaxPerHour and OTPMaxPerDay codes. challengeHash identifies the code of a login that has
+// no access token yet and is empty for the other purposes.
+func IssueOTP(db *gorm.DB, userID uint, purpose, challengeHash string, now time.Time) (string, error) {
+	code, err := GenerateOTPCode()
+	if err != nil {
+		return "", err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(code), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var last models.OneTimeCode
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND purpose = ?", userID, purpose).
+			Order("one_time_code_id DESC").
+			First(&last).Error
+		switch {
+		case err == nil:
+			if err := CheckOTPResend(&last, now); err != nil {
+				return err
+			}
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		}
+
+		var sentLastHour, sentLastDay int64
+		if err := tx.Model(&models.OneTimeCode{}).
+			Where("user_id = ? AND created_at > ?", userID, now.Add(-time.Hour)).
+			Count(&sentLastHour).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.OneTimeCode{}).
+			Where("user_id = ? AND created_at > ?", userID, now.Add(-24*time.Hour)).
+			Count(&sentLastDay).Error; err != nil {
+			return err
+		}
+		if err := CheckOTPQuota(sentLastHour, sentLastDay); err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.OneTimeCode{}).
+			Where("user_id = ? AND purpose = ? AND consumed_at IS NULL", userID, purpose).
+			Update("consumed_at", now).Error; err != nil {
+			return err
+		}
+
+		otp := models.OneTimeCode{
+			UserID:    userID,
+			Purpose:   purpose,
+			CodeHash:  string(hash),
+			ExpiresAt: now.Add(OTPTTL),
+			CreatedAt: now,
+		}
+		if challengeHash != "" {
+			otp.ChallengeHash = &challengeHash
+		}
+		return tx.Create(&otp).Error
+	})
+	if err != nil {
+		return "", err
+	}
+	return code, nil
+}
+
+// VerifyOTP checks code against the current code of user for purpose and uses it up when it matches
+func VerifyOTP(db *gorm.DB, userID uint, purpose, code string, now time.Time) error {
+	_, err := verifyOTP(db, code, now, func(tx *gorm.DB) *gorm.DB {
+		return tx.Where("user_id = ? AND purpose = ?", userID, purpose)
+	})
+	return err
+}
+
+// VerifyLoginOTP checks code against the login code issued with challenge and returns the user it belongs to
+func VerifyLoginOTP(db *gorm.DB, challenge, code string, now time.Time) (uint, error) {
+	otp, err := verifyOTP(db, code, now, func(tx *gorm.DB) *gorm.DB {
+		return tx.Where("challenge_hash = ? AND purpose = ?", HashSecretToken(challenge), OTPLogin)
+	})
+	return otp.UserID, err
+}
+
+func verifyOTP(db *gorm.DB, code string, now time.Time, scope func(*gorm.DB) *gorm.DB) (models.OneTimeCode, error) {
+	var otp models.OneTimeCode
+	var result error
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := scope(tx).Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("consumed_at IS NULL").
+			Order("one_time_code_id DESC").
+			First(&otp).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				result = ErrOTPInvalid
+				return nil
+			}
+			return err
+		}
+
+		if result = CheckOTP(otp, now); result != nil {
+			return nil
+		}
+
+		// Wrong codes are counted and committed, so guessing stops after OTPMaxAttempts
+		if bcrypt.CompareHashAndPassword([]byte(otp.CodeHash), []byte(code)) != nil {
+			result = ErrOTPInvalid
+			return tx.Model(&otp).Update("attempts", gorm.Expr("attempts + 1")).Error
+		}
+
+		return tx.Model(&otp).Update("consumed_at", now).Error
+	})
+	if err != nil {
+		return otp, err
+	}
+	return otp, result
+}
+
+// CanUseTwoFactor validates that user may turn on the login second factor
+func CanUseTwoFactor(user models.User) error {
+	if user.Role != RoleOwner && user.Role != RoleAdmin {
+		return ErrTwoFactorNotAllowed
+	}
+	if user.PhoneVerifiedAt == nil {
+		return ErrPhoneNotVerified
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"jakarta-luxury-rent-car/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateOTPCode(t *testing.T) {
+	digits := regexp.MustCompile(`^[0-9]{6}$`)
+	for i := 0; i < 50; i++ {
+		code, err := GenerateOTPCode()
+		assert.NoError(t, err)
+		assert.Regexp(t, digits, code)
+	}
+}
+
+func TestCheckOTP(t *testing.T) {
+	now := time.Date(2024, time.August, 17, 9, 0, 0, 0, time.UTC)
+	otp := models.OneTimeCode{ExpiresAt: now.Add(OTPTTL)}
+	assert.NoError(t, CheckOTP(otp, now))
+
+	assert.ErrorIs(t, CheckOTP(otp, now.Add(OTPTTL)), ErrOTPExpired)
+
+	otp.Attempts = OTPMaxAttempts - 1
+	assert.NoError(t, CheckOTP(otp, now))
+
+	otp.Attempts = OTPMaxAttempts
+	assert.ErrorIs(t, CheckOTP(otp, now), ErrOTPTooManyAttempts)
+}
+
+func TestCheckOTPResend(t *testing.T) {
+	now := time.Date(2024, time.August, 17, 9, 0, 0, 0, time.UTC)
+	assert.NoError(t, CheckOTPResend(nil, now))
+
+	last := &models.OneTimeCode{CreatedAt: now.Add(-30 * time.Second)}
+	assert.ErrorIs(t, CheckOTPResend(last, now), ErrOTPResendTooSoon)
+
+	last.CreatedAt = now.Add(-OTPResendInterval)
+	assert.NoError(t, CheckOTPResend(last, now))
+}
+
+func TestCanUseTwoFactor(t *testing.T) {
+	verified := time.Date(2024, time.August, 17, 9, 0, 0, 0, time.UTC)
+
+	assert.NoError(t, CanUseTwoFactor(models.User{Role: RoleOwner, PhoneVerifiedAt: &verified}))
+	assert.NoError(t, CanUseTwoFactor(models.User{Role: RoleAdmin, PhoneVerifiedAt: &verified}))
+	assert.ErrorIs(t, CanUseTwoFactor(models.User{Role: RoleOwner}), ErrPhoneNotVerified)
+	assert.ErrorIs(t, CanUseTwoFactor(models.User{Role: RoleUser, PhoneVerifiedAt: &verified}), ErrTwoFactorNotAllowed)
+}
+
+func TestCheckOTPQuota(t *testing.T) {
+	assert.NoError(t, CheckOTPQuota(0, 0))
+	assert.NoError(t, CheckOTPQuota(OTPMaxPerHour-1, OTPMaxPerDay-1))
+	assert.ErrorIs(t, CheckOTPQuota(OTPMaxPerHour, OTPMaxPerHour), ErrOTPQuotaExceeded)
+	assert.ErrorIs(t, CheckOTPQuota(1, OTPMaxPerDay), ErrOTPQuotaExceeded)
+}
@@ -0,0 +1,179 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"jakarta-luxury-rent-car/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Outbox message statuses stored in outbox_messages.status
+const (
+	OutboxPending   = "pending"
+	OutboxDelivered = "delivered"
+	OutboxFailed    = "failed" // Gave up after the maximum number of attempts
+)
+
+// OutboxHandler delivers the payload of one outbox message. Returning an error schedules a retry,
+// so handlers must be safe to run again for a message that was partly delivered.
+type OutboxHandler func(payload []byte) error
+
+// OutboxDispatcher delivers outbox messages in the background with retries and exponential backoff
+type OutboxDispatcher struct {
+	db           *gorm.DB
+	handlers     map[string]OutboxHandler
+	PollInterval time.Duration
+	BatchSize    int
+	MaxAttempts  int
+	// Lease is how long a claimed message is hidden from other dispatchers while it is delivered
+	Lease time.Duration
+}
+
+// EnqueueOutbox stores a message in tx, so it is only delivered when the surrounding change commits
+func EnqueueOutbox(tx *gorm.DB, kind string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal outbox payload: %v", err)
+	}
+
+	now := time.Now()
+	message := models.OutboxMessage{
+		Kind:          kind,
+		Payload:       string(body),
+		Status:        OutboxPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}
+
+	return tx.Create(&message).Error
+}
+
+// NewOutboxDispatcher creates a dispatcher with one handler per message kind
+func NewOutboxDispatcher(db *gorm.DB, handlers map[string]OutboxHandler) *OutboxDispatcher {
+	return &OutboxDispatcher{
+		db:           db,
+		handlers:     handlers,
+		PollInterval: 5 * time.Second,
+		BatchSize:    20,
+		MaxAttempts:  10,
+		Lease:        2 * time.Minute,
+	}
+}
+
+// Run polls for due messages until ctx is cancelled
+func (d *OutboxDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := d.DispatchDue(); err != nil {
+			log.Println("Outbox dispatch failed: ", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchDue delivers one batch of due messages and returns how many were attempted
+func (d *OutboxDispatcher) DispatchDue() (int, error) {
+	messages, err := d.claim()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, message := range messages {
+		d.deliver(message)
+	}
+
+	return len(messages), nil
+}
+
+// claim picks due messages and pushes their next attempt past the lease, so concurrent
+// dispatchers (one per app instance) do not deliver the same message at the same time
+func (d *OutboxDispatcher) claim() ([]models.OutboxMessage, error) {
+	var messages []models.OutboxMessage
+
+	err := d.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", OutboxPending, now).
+			Order("next_attempt_at").
+			Limit(d.BatchSize).
+			Find(&messages).Error; err != nil {
+			return err
+		}
+
+		for i := range messages {
+			messages[i].Attempts++
+			messages[i].NextAttemptAt = now.Add(d.Lease)
+			if err := tx.Save(&messages[i]).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	return messages, err
+}
+
+func (d *OutboxDispatcher) deliver(message models.OutboxMessage) {
+	handler, ok := d.handlers[message.Kind]
+
+	var err error
+	if !ok {
+		err = fmt.Errorf("no handler for outbox kind %q", message.Kind)
+	} else {
+		err = handler([]byte(message.Payload))
+	}
+
+	now := time.Now()
+	if err == nil {
+		message.Status = OutboxDelivered
+		message.DeliveredAt = &now
+		message.LastError = ""
+	} else {
+		message.LastError = err.Error()
+		message.NextAttemptAt = now.Add(OutboxBackoff(message.Attempts))
+		if message.Attempts >= d.MaxAttempts {
+			message.Status = OutboxFailed
+		}
+	}
+
+	if err := d.db.Save(&message).Error; err != nil {
+		log.Println("Failed to update outbox message: ", err)
+	}
+}
+
+// OutboxBackoff is the wait before the next attempt after the given number of failed attempts:
+// 30 seconds doubling per attempt, at most one hour
+func OutboxBackoff(attempts int) time.Duration {
+	const (
+		base    = 30 * time.Second
+		maximum = time.Hour
+	)
+
+	if attempts < 1 {
+		return base
+	}
+
+	backoff := base
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= maximum {
+			return maximum
+		}
+	}
+
+	return backoff
+}
@@ -0,0 +1,222 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"jakarta-luxury-rent-car/models"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// setupOutboxTestDB connects to the Postgres database in TEST_DATABASE_DSN
+func setupOutboxTestDB(t *testing.T) *gorm.DB {
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("Failed to connect to database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.OutboxMessage{}); err != nil {
+		t.Fatalf("Failed to auto migrate: %v", err)
+	}
+
+	return db
+}
+
+// enqueueTestMessages queues count messages of a kind only this test handles
+func enqueueTestMessages(t *testing.T, db *gorm.DB, count int) string {
+	kind := fmt.Sprintf("test_%d", time.Now().UnixNano())
+	for i := 0; i < count; i++ {
+		assert.NoError(t, EnqueueOutbox(db, kind, map[string]int{"n": i}))
+	}
+	return kind
+}
+
+// testMessages loads the messages of kind in the order they were queued
+func testMessages(t *testing.T, db *gorm.DB, kind string) []models.OutboxMessage {
+	var messages []models.OutboxMessage
+	assert.NoError(t, db.Where("kind = ?", kind).Order("outbox_message_id").Find(&messages).Error)
+	return messages
+}
+
+// makeDue brings the next attempt of the messages of kind forward, as if their backoff had passed
+func makeDue(t *testing.T, db *gorm.DB, kind string) {
+	assert.NoError(t, db.Model(&models.OutboxMessage{}).Where("kind = ?", kind).Update("next_attempt_at", time.Now().Add(-time.Second)).Error)
+}
+
+func TestOutboxClaimLeasesMessagesFromOtherDispatchers(t *testing.T) {
+	db := setupOutboxTestDB(t)
+	kind := enqueueTestMessages(t, db, 1)
+
+	first := NewOutboxDispatcher(db, nil)
+	first.BatchSize = 1000
+	second := NewOutboxDispatcher(db, nil)
+	second.BatchSize = 1000
+
+	before := time.Now()
+	claimed, err := first.claim()
+	assert.NoError(t, err)
+	assert.True(t, containsKind(claimed, kind), "the due message is claimed")
+
+	// The lease pushes the next attempt out, so the other dispatcher does not see it
+	message := testMessages(t, db, kind)[0]
+	assert.Equal(t, OutboxPending, message.Status)
+	assert.Equal(t, 1, message.Attempts)
+	assert.WithinDuration(t, before.Add(first.Lease), message.NextAttemptAt, 5*time.Second)
+
+	claimed, err = second.claim()
+	assert.NoError(t, err)
+	assert.False(t, containsKind(claimed, kind), "a leased message is not claimed again")
+}
+
+func TestOutboxConcurrentDispatchersDeliverEachMessageOnce(t *testing.T) {
+	db := setupOutboxTestDB(t)
+	const count = 30
+	kind := enqueueTestMessages(t, db, count)
+
+	var (
+		mu         sync.Mutex
+		deliveries = map[int]int{}
+	)
+	handlers := map[string]OutboxHandler{
+		kind: func(payload []byte) error {
+			var body map[string]int
+			if err := json.Unmarshal(payload, &body); err != nil {
+				return err
+			}
+			mu.Lock()
+			deliveries[body["n"]]++
+			mu.Unlock()
+			return nil
+		},
+	}
+
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		dispatcher := NewOutboxDispatcher(db, handlers)
+		dispatcher.BatchSize = 5
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			for {
+				attempted, err := dispatcher.DispatchDue()
+				if err != nil || attempted == 0 {
+					return
+				}
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	assert.Len(t, deliveries, count)
+	for n, times := range deliveries {
+		assert.Equal(t, 1, times, "message %d", n)
+	}
+	for _, message := range testMessages(t, db, kind) {
+		assert.Equal(t, OutboxDelivered, message.Status)
+		assert.Equal(t, 1, message.Attempts)
+		assert.NotNil(t, message.DeliveredAt)
+	}
+}
+
+func TestOutboxRetriesWithBackoffUntilDelivered(t *testing.T) {
+	db := setupOutboxTestDB(t)
+	kind := enqueueTestMessages(t, db, 1)
+
+	calls := 0
+	dispatcher := NewOutboxDispatcher(db, map[string]OutboxHandler{
+		kind: func(payload []byte) error {
+			calls++
+			if calls < 3 {
+				return errors.New("twilio down")
+			}
+			return nil
+		},
+	})
+	dispatcher.BatchSize = 1000
+
+	for attempt := 1; attempt <= 2; attempt++ {
+		before := time.Now()
+		_, err := dispatcher.DispatchDue()
+		assert.NoError(t, err)
+
+		message := testMessages(t, db, kind)[0]
+		assert.Equal(t, OutboxPending, message.Status)
+		assert.Equal(t, attempt, message.Attempts)
+		assert.Equal(t, "twilio down", message.LastError)
+		assert.WithinDuration(t, before.Add(OutboxBackoff(attempt)), message.NextAttemptAt, 5*time.Second)
+
+		// Not due yet, so dispatching again right away leaves it alone
+		_, err = dispatcher.DispatchDue()
+		assert.NoError(t, err)
+		assert.Equal(t, attempt, testMessages(t, db, kind)[0].Attempts)
+
+		makeDue(t, db, kind)
+	}
+
+	_, err := dispatcher.DispatchDue()
+	assert.NoError(t, err)
+
+	message := testMessages(t, db, kind)[0]
+	assert.Equal(t, OutboxDelivered, message.Status)
+	assert.Equal(t, 3, message.Attempts)
+	assert.Empty(t, message.LastError)
+	assert.NotNil(t, message.DeliveredAt)
+	assert.Equal(t, 3, calls)
+}
+
+func TestOutboxFailsAfterMaxAttempts(t *testing.T) {
+	db := setupOutboxTestDB(t)
+	kind := enqueueTestMessages(t, db, 1)
+
+	calls := 0
+	dispatcher := NewOutboxDispatcher(db, map[string]OutboxHandler{
+		kind: func(payload []byte) error {
+			calls++
+			return errors.New("smtp down")
+		},
+	})
+	dispatcher.BatchSize = 1000
+	dispatcher.MaxAttempts = 3
+
+	for attempt := 1; attempt <= dispatcher.MaxAttempts; attempt++ {
+		_, err := dispatcher.DispatchDue()
+		assert.NoError(t, err)
+		makeDue(t, db, kind)
+	}
+
+	message := testMessages(t, db, kind)[0]
+	assert.Equal(t, OutboxFailed, message.Status)
+	assert.Equal(t, dispatcher.MaxAttempts, message.Attempts)
+	assert.Equal(t, "smtp down", message.LastError)
+
+	// Failed messages are never picked up again, even when due
+	_, err := dispatcher.DispatchDue()
+	assert.NoError(t, err)
+	assert.Equal(t, dispatcher.MaxAttempts, calls)
+	assert.Equal(t, OutboxFailed, testMessages(t, db, kind)[0].Status)
+}
+
+func containsKind(messages []models.OutboxMessage, kind string) bool {
+	for _, message := range messages {
+		if message.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,18 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutboxBackoff(t *testing.T) {
+	assert.Equal(t, 30*time.Second, OutboxBackoff(0))
+	assert.Equal(t, 30*time.Second, OutboxBackoff(1))
+	assert.Equal(t, time.Minute, OutboxBackoff(2))
+	assert.Equal(t, 4*time.Minute, OutboxBackoff(4))
+	assert.Equal(t, 32*time.Minute, OutboxBackoff(7))
+	assert.Equal(t, time.Hour, OutboxBackoff(8))
+	assert.Equal(t, time.Hour, OutboxBackoff(50))
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"bufio"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	defaultPasswordMinLength = 10
+	passwordMaxLength        = 72 // bcrypt ignores everything after 72 bytes
+)
+
+// ErrPasswordPolicy is wrapped by every reason a password is rejected
+var ErrPasswordPolicy = errors.New("password does not meet the password policy")
+
+var (
+	ErrPasswordTooShort = fmt.Errorf("%w: it is too short", ErrPasswordPolicy)
+	ErrPasswordTooLong  = fmt.Errorf("%w: it is longer than 72 bytes", ErrPasswordPolicy)
+	ErrPasswordCommon   = fmt.Errorf("%w: it is too common or appeared in a data breach", ErrPasswordPolicy)
+	ErrPasswordIsEmail  = fmt.Errorf("%w: it contains the email address", ErrPasswordPolicy)
+)
+
+//go:embed passwords/common.txt
+var bundledCommonPasswords string
+
+// PasswordPolicy decides which new passwords are accepted. Existing passwords keep working.
+type PasswordPolicy struct {
+	MinLength int
+	common    map[string]bool
+}
+
+// LoadPasswordPolicy reads PASSWORD_MIN_LENGTH (default 10) and PASSWORD_BLOCKLIST_FILE, a file of
+// extra rejected passwords one per line, on top of the bundled list of common and breached passwords
+func LoadPasswordPolicy() (PasswordPolicy, error) {
+	policy := NewPasswordPolicy(defaultPasswordMinLength, strings.NewReader(bundledCommonPasswords))
+
+	minLength, err := envValue("PASSWORD_MIN_LENGTH", defaultPasswordMinLength, parsePositiveInt)
+	if err != nil {
+		return policy, err
+	}
+	policy.MinLength = minLength
+
+	if path := os.Getenv("PASSWORD_BLOCKLIST_FILE"); path != "" {
+		file, err := os.Open(path)
+		if err != nil {
+			return policy, fmt.Errorf("invalid PASSWORD_BLOCKLIST_FILE: %w", err)
+		}
+		defer file.Close()
+		policy.addCommon(file)
+	}
+
+	return policy, nil
+}
+
+// NewPasswordPolicy creates a policy with a minimum length that rejects the passwords listed in common
+func NewPasswordPolicy(minLength int, common io.Reader) PasswordPolicy {
+	policy := PasswordPolicy{MinLength: minLength, common: map[string]bool{}}
+	policy.addCommon(common)
+	return policy
+}
+
+func (p PasswordPolicy) addCommon(list io.Reader) {
+	scanner := bufio.NewScanner(list)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			p.common[strings.ToLower(line)] = true
+		}
+	}
+}
+
+// Validate checks a new password of the account with the given email
+func (p PasswordPolicy) Validate(password, email string) error {
+	if len([]rune(password)) < p.MinLength {
+		return fmt.Errorf("%w: use at least %d characters", ErrPasswordTooShort, p.MinLength)
+	}
+	if len(password) > passwordMaxLength {
+		return ErrPasswordTooLong
+	}
+
+	lower := strings.ToLower(password)
+	if p.common[lower] {
+		return ErrPasswordCommon
+	}
+
+	if local, _, ok := strings.Cut(strings.ToLower(email), "@"); ok && len(local) >= 3 && strings.Contains(lower, local) {
+		return ErrPasswordIsEmail
+	}
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasswordPolicy_Validate(t *testing.T) {
+	policy := NewPasswordPolicy(10, strings.NewReader("# comment\nPassword123\nrahasia123\n"))
+
+	assert.NoError(t, policy.Validate("kuda-lumping-sore", "budi@example.com"))
+
+	assert.ErrorIs(t, policy.Validate("short", "budi@example.com"), ErrPasswordTooShort)
+	assert.ErrorIs(t, policy.Validate(strings.Repeat("x", 73), "budi@example.com"), ErrPasswordTooLong)
+
+	// The list is compared case-insensitively
+	assert.ErrorIs(t, policy.Validate("password123", "budi@example.com"), ErrPasswordCommon)
+	assert.ErrorIs(t, policy.Validate("RAHASIA123", "budi@example.com"), ErrPasswordCommon)
+	assert.NoError(t, policy.Validate("# comment!", "budi@example.com"))
+
+	assert.ErrorIs(t, policy.Validate("budisantoso2024", "BudiSantoso@example.com"), ErrPasswordIsEmail)
+}
+
+func TestLoadPasswordPolicy(t *testing.T) {
+	t.Setenv("PASSWORD_MIN_LENGTH", "")
+	t.Setenv("PASSWORD_BLOCKLIST_FILE", "")
+	policy, err := LoadPasswordPolicy()
+	assert.NoError(t, err)
+	assert.Equal(t, 10, policy.MinLength)
+	assert.ErrorIs(t, policy.Validate("qwertyuiop", "budi@example.com"), ErrPasswordCommon)
+
+	t.Setenv("PASSWORD_MIN_LENGTH", "14")
+	policy, err = LoadPasswordPolicy()
+	assert.NoError(t, err)
+	assert.Equal(t, 14, policy.MinLength)
+
+	t.Setenv("PASSWORD_MIN_LENGTH", "-1")
+	_, err = LoadPasswordPolicy()
+	assert.ErrorContains(t, err, "invalid PASSWORD_MIN_LENGTH")
+
+	t.Setenv("PASSWORD_MIN_LENGTH", "")
+	t.Setenv("PASSWORD_BLOCKLIST_FILE", "/nonexistent/blocklist.txt")
+	_, err = LoadPasswordPolicy()
+	assert.ErrorContains(t, err, "invalid PASSWORD_BLOCKLIST_FILE")
+}
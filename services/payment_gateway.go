@@ -0,0 +1,119 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Refund statuses reported by a payment gateway
+const (
+	GatewayRefundPending   = "PENDING"
+	GatewayRefundSucceeded = "SUCCEEDED"
+	GatewayRefundFailed    = "FAILED"
+)
+
+var (
+	ErrGatewayInvoiceNotFound  = errors.New("invoice not found at the payment gateway")
+	ErrGatewayInvoiceNotPaid   = errors.New("invoice has not been paid")
+	ErrGatewayInvoiceNotActive = errors.New("invoice is no longer pending")
+	ErrGatewayRefundTooLarge   = errors.New("refund exceeds the amount left on the invoice")
+	ErrGatewayRejected         = errors.New("request rejected by the payment gateway")
+)
+
+// GatewayRejected reports whether err is a definite refusal by the gateway. Anything else, such as a
+// timeout or a 5xx, leaves open whether the gateway acted on the request.
+func GatewayRejected(err error) bool {
+	return errors.Is(err, ErrGatewayRejected) ||
+		errors.Is(err, ErrGatewayInvoiceNotFound) ||
+		errors.Is(err, ErrGatewayInvoiceNotPaid) ||
+		errors.Is(err, ErrGatewayInvoiceNotActive) ||
+		errors.Is(err, ErrGatewayRefundTooLarge)
+}
+
+// InvoiceItem is one line shown on a gateway invoice
+type InvoiceItem struct {
+	Name     string
+	Quantity int
+	Price    float64
+	Category string
+}
+
+// InvoiceFee is an extra charge, or a discount when Value is negative
+type InvoiceFee struct {
+	Type  string
+	Value float64
+}
+
+// InvoiceRequest describes an invoice to create at the gateway
+type InvoiceRequest struct {
+	ExternalID    string
+	Amount        float64
+	Description   string
+	Duration      time.Duration
+	CustomerEmail string
+	CustomerPhone string
+	Items         []InvoiceItem
+	Fees          []InvoiceFee
+}
+
+// GatewayInvoice is an invoice as the gateway knows it. Status is one of the InvoiceStatus constants.
+type GatewayInvoice struct {
+	ID         string
+	ExternalID string
+	Status     string
+	Amount     float64
+	InvoiceURL string
+	ExpiresAt  *time.Time
+	PaidAt     *time.Time
+}
+
+// RefundRequest returns Amount of a paid invoice to the customer
+type RefundRequest struct {
+	InvoiceID   string
+	ReferenceID string // Our unique reference, so a retried refund is not paid out twice
+	Amount      float64
+	Reason      string
+}
+
+// GatewayRefund is a refund as the gateway knows it. Status is one of the GatewayRefund constants.
+type GatewayRefund struct {
+	ID          string
+	ReferenceID string
+	Status      string
+	Amount      float64
+}
+
+// PaymentGateway creates and settles invoices with a payment provider
+type PaymentGateway interface {
+	CreateInvoice(req InvoiceRequest) (GatewayInvoice, error)
+	GetInvoice(invoiceID string) (GatewayInvoice, error)
+	Refund(req RefundRequest) (GatewayRefund, error)
+	ExpireInvoice(invoiceID string) (GatewayInvoice, error)
+}
+
+// NormalizeInvoiceStatus maps SETTLED, which Xendit reports once a payment has been disbursed, to PAID
+func NormalizeInvoiceStatus(status string) string {
+	if status == "SETTLED" {
+		return InvoiceStatusPaid
+	}
+	return status
+}
+
+// NewPaymentGatewayFromEnv builds the gateway named by PAYMENT_GATEWAY: "xendit" (default) or "fake".
+// The fake keeps invoices in memory and is meant for local development and tests.
+func NewPaymentGatewayFromEnv() (PaymentGateway, error) {
+	switch name := os.Getenv("PAYMENT_GATEWAY"); name {
+	case "", "xendit":
+		apiKey := os.Getenv("API_KEY_XENDIT")
+		if apiKey == "" {
+			return nil, errors.New("API_KEY_XENDIT is required for the xendit payment gateway")
+		}
+		return NewXenditGateway(apiKey, os.Getenv("XENDIT_BASE_URL")), nil
+	case "fake":
+		return NewFakeGateway(), nil
+	default:
+		return nil, fmt.Errorf("unknown PAYMENT_GATEWAY %q", name)
+	}
+}
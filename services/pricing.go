@@ -0,0 +1,356 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"jakarta-luxury-rent-car/models"
+
+	"gorm.io/gorm"
+)
+
+// Add-on codes stored in addon_prices
+const (
+	AddonDriver          = "driver"
+	AddonPickupDropoff   = "pickup_dropoff"
+	AddonAirportTransfer = "airport_transfer"
+	AddonConcierge       = "concierge"
+)
+
+// Rounding rules for partial rental days
+const (
+	RoundingFullDay = "full_day" // A started day is charged as a full day
+	RoundingHourly  = "hourly"   // Partial days are charged pro rata
+)
+
+// defaultAddonPrices are used for add-ons that have no row in addon_prices
+var defaultAddonPrices = map[string]models.AddonPrice{
+	AddonDriver:          {Code: AddonDriver, Price: 100, PerDay: true},
+	AddonPickupDropoff:   {Code: AddonPickupDropoff, Price: 100},
+	AddonAirportTransfer: {Code: AddonAirportTransfer, Price: 50},
+	AddonConcierge:       {Code: AddonConcierge, Price: 100},
+}
+
+// membershipDiscounts is the discount percentage per membership level
+var membershipDiscounts = map[string]float64{
+	"Silver":   10,
+	"Gold":     20,
+	"Platinum": 30,
+}
+
+// PricingConfig is the owner-managed price list the engine calculates with
+type PricingConfig struct {
+	CarRates map[uint]models.CarRate
+	Addons   map[string]models.AddonPrice
+	Settings models.PricingSetting
+	Holidays map[string]string // Holiday name by date (YYYY-MM-DD)
+}
+
+// PriceInput is everything that decides what a booking costs
+type PriceInput struct {
+	Car               models.Car
+	RentalDate        time.Time
+	ReturnDate        time.Time
+	RentalDuration    string // daily, weekly or monthly rate plan
+	WithDriver        bool
+	Package           *models.EventPackage
+	PickupDropoff     bool // Both a pickup and a dropoff location were given
+	AirportTransfer   bool
+	ConciergeServices bool
+	DiscountLevel     string // Membership level, empty without a membership
+}
+
+// PriceBreakdown is the itemised cost of a booking
+type PriceBreakdown struct {
+	RentalDuration      string  `json:"rental_duration"`
+	RentalDays          float64 `json:"rental_days"`
+	CarDailyRate        float64 `json:"car_daily_rate"`
+	CarCost             float64 `json:"car_cost"`
+	WeekendDays         int     `json:"weekend_days"`
+	HolidayDays         int     `json:"holiday_days"`
+	SurchargeCost       float64 `json:"surcharge_cost"`
+	DriverDays          float64 `json:"driver_days"`
+	DriverDailyRate     float64 `json:"driver_daily_rate"`
+	DriverCost          float64 `json:"driver_cost"`
+	PackageName         string  `json:"package_name,omitempty"`
+	PackageCost         float64 `json:"package_cost"`
+	PickupDropoffCost   float64 `json:"pickup_dropoff_cost"`
+	AirportTransferCost float64 `json:"airport_transfer_cost"`
+	ConciergeCost       float64 `json:"concierge_cost"`
+	Subtotal            float64 `json:"subtotal"`
+	DiscountLevel       string  `json:"discount_level,omitempty"`
+	DiscountPercent     float64 `json:"discount_percent"`
+	DiscountAmount      float64 `json:"discount_amount"`
+	Total               float64 `json:"total"`
+}
+
+// PriceLine is one billable item of a breakdown
+type PriceLine struct {
+	Name      string
+	Quantity  float64
+	UnitPrice float64
+	Amount    float64
+}
+
+// LoadPricingConfig reads the current price list. Missing rows fall back to the car's rental cost
+// and the default add-on prices, so the engine works on an empty pricing schema.
+func LoadPricingConfig(db *gorm.DB) (PricingConfig, error) {
+	config := PricingConfig{
+		CarRates: make(map[uint]models.CarRate),
+		Addons:   make(map[string]models.AddonPrice),
+		Settings: models.PricingSetting{Rounding: RoundingFullDay},
+		Holidays: make(map[string]string),
+	}
+
+	var carRates []models.CarRate
+	if err := db.Find(&carRates).Error; err != nil {
+		return config, err
+	}
+	for _, rate := range carRates {
+		config.CarRates[rate.CarID] = rate
+	}
+
+	var addons []models.AddonPrice
+	if err := db.Find(&addons).Error; err != nil {
+		return config, err
+	}
+	for _, addon := range addons {
+		config.Addons[addon.Code] = addon
+	}
+
+	var settings []models.PricingSetting
+	if err := db.Order("pricing_setting_id").Limit(1).Find(&settings).Error; err != nil {
+		return config, err
+	}
+	if len(settings) > 0 {
+		config.Settings = settings[0]
+	}
+
+	var holidays []models.Holiday
+	if err := db.Find(&holidays).Error; err != nil {
+		return config, err
+	}
+	for _, holiday := range holidays {
+		config.Holidays[holiday.HolidayDate.Format("2006-01-02")] = holiday.Name
+	}
+
+	return config, nil
+}
+
+// CarRate returns the rates of a car, deriving weekly and monthly rates from the daily rate when they are not set
+func (c PricingConfig) CarRate(car models.Car) models.CarRate {
+	rate, ok := c.CarRates[car.CarID]
+	if !ok || rate.DailyRate == 0 {
+		rate = models.CarRate{CarID: car.CarID, DailyRate: car.RentalCosts}
+	}
+	if rate.WeeklyRate == 0 {
+		rate.WeeklyRate = rate.DailyRate * 7
+	}
+	if rate.MonthlyRate == 0 {
+		rate.MonthlyRate = rate.DailyRate * 30
+	}
+	return rate
+}
+
+// Addon returns the price of an add-on, falling back to its default price
+func (c PricingConfig) Addon(code string) models.AddonPrice {
+	if addon, ok := c.Addons[code]; ok {
+		return addon
+	}
+	return defaultAddonPrices[code]
+}
+
+// BillableDays converts a rental window into the number of days charged under the rounding rule
+func (c PricingConfig) BillableDays(rentalDate, returnDate time.Time) float64 {
+	days := returnDate.Sub(rentalDate).Hours() / 24
+	if days <= 0 {
+		return 0
+	}
+
+	if c.Settings.Rounding == RoundingHourly {
+		return days
+	}
+	return math.Ceil(days)
+}
+
+// CalculatePrice itemises the cost of a booking under the given price list and applies the membership discount
+func CalculatePrice(config PricingConfig, input PriceInput) PriceBreakdown {
+	rentalDays := config.BillableDays(input.RentalDate, input.ReturnDate)
+	rate := config.CarRate(input.Car)
+
+	duration := input.RentalDuration
+	if duration == "" {
+		duration = "daily"
+	}
+
+	breakdown := PriceBreakdown{
+		RentalDuration: duration,
+		RentalDays:     rentalDays,
+		CarDailyRate:   rate.DailyRate,
+		CarCost:        roundCents(carCost(rate, duration, rentalDays)),
+	}
+
+	// Weekend and holiday surcharges are a percentage of the daily rate for each day they cover
+	var surcharge float64
+	for day := 0; float64(day) < rentalDays; day++ {
+		weight := math.Min(1, rentalDays-float64(day))
+		date := input.RentalDate.AddDate(0, 0, day)
+
+		if _, ok := config.Holidays[date.Format("2006-01-02")]; ok {
+			breakdown.HolidayDays++
+			surcharge += weight * rate.DailyRate * config.Settings.HolidaySurchargePercent / 100
+		} else if date.Weekday() == time.Saturday || date.Weekday() == time.Sunday {
+			breakdown.WeekendDays++
+			surcharge += weight * rate.DailyRate * config.Settings.WeekendSurchargePercent / 100
+		}
+	}
+	breakdown.SurchargeCost = roundCents(surcharge)
+
+	if input.WithDriver {
+		driver := config.Addon(AddonDriver)
+		breakdown.DriverDays = rentalDays
+		breakdown.DriverDailyRate = driver.Price
+		breakdown.DriverCost = roundCents(addonCost(driver, rentalDays))
+	}
+
+	if input.Package != nil {
+		breakdown.PackageName = input.Package.PackageName
+		breakdown.PackageCost = input.Package.Cost
+	}
+
+	if input.PickupDropoff {
+		breakdown.PickupDropoffCost = roundCents(addonCost(config.Addon(AddonPickupDropoff), rentalDays))
+	}
+
+	if input.AirportTransfer {
+		breakdown.AirportTransferCost = roundCents(addonCost(config.Addon(AddonAirportTransfer), rentalDays))
+	}
+
+	if input.ConciergeServices {
+		breakdown.ConciergeCost = roundCents(addonCost(config.Addon(AddonConcierge), rentalDays))
+	}
+
+	breakdown.Subtotal = roundCents(breakdown.CarCost + breakdown.SurchargeCost + breakdown.DriverCost + breakdown.PackageCost +
+		breakdown.PickupDropoffCost + breakdown.AirportTransferCost + breakdown.ConciergeCost)
+
+	if percent, ok := membershipDiscounts[input.DiscountLevel]; ok {
+		breakdown.DiscountLevel = input.DiscountLevel
+		breakdown.DiscountPercent = percent
+		breakdown.DiscountAmount = roundCents(breakdown.Subtotal * percent / 100)
+	}
+
+	breakdown.Total = roundCents(breakdown.Subtotal - breakdown.DiscountAmount)
+
+	return breakdown
+}
+
+// carCost prices the car under a rate plan. Weekly bookings pay full weeks at the weekly rate,
+// monthly bookings pay 30-day months at the monthly rate; the remainder falls back to the shorter plan.
+func carCost(rate models.CarRate, duration string, days float64) float64 {
+	switch duration {
+	case "monthly":
+		months := math.Floor(days / 30)
+		return months*rate.MonthlyRate + carCost(rate, "weekly", days-months*30)
+	case "weekly":
+		weeks := math.Floor(days / 7)
+		return weeks*rate.WeeklyRate + (days-weeks*7)*rate.DailyRate
+	default:
+		return days * rate.DailyRate
+	}
+}
+
+func addonCost(addon models.AddonPrice, days float64) float64 {
+	if addon.PerDay {
+		return addon.Price * days
+	}
+	return addon.Price
+}
+
+// Lines lists the billable items of the breakdown, skipping the ones that were not booked
+func (b PriceBreakdown) Lines() []PriceLine {
+	lines := []PriceLine{
+		{Name: "Car Rental", Quantity: b.RentalDays, UnitPrice: roundCents(b.CarCost / math.Max(b.RentalDays, 1)), Amount: b.CarCost},
+	}
+
+	if b.SurchargeCost > 0 {
+		lines = append(lines, PriceLine{Name: "Weekend and Holiday Surcharge", Quantity: 1, UnitPrice: b.SurchargeCost, Amount: b.SurchargeCost})
+	}
+	if b.DriverCost > 0 {
+		lines = append(lines, PriceLine{Name: "Driver", Quantity: b.DriverDays, UnitPrice: b.DriverDailyRate, Amount: b.DriverCost})
+	}
+	if b.PackageCost > 0 {
+		lines = append(lines, PriceLine{Name: "Event Package", Quantity: 1, UnitPrice: b.PackageCost, Amount: b.PackageCost})
+	}
+	if b.PickupDropoffCost > 0 {
+		lines = append(lines, PriceLine{Name: "Pickup and Dropoff", Quantity: 1, UnitPrice: b.PickupDropoffCost, Amount: b.PickupDropoffCost})
+	}
+	if b.AirportTransferCost > 0 {
+		lines = append(lines, PriceLine{Name: "Transfer Airport", Quantity: 1, UnitPrice: b.AirportTransferCost, Amount: b.AirportTransferCost})
+	}
+	if b.ConciergeCost > 0 {
+		lines = append(lines, PriceLine{Name: "Concierge Service", Quantity: 1, UnitPrice: b.ConciergeCost, Amount: b.ConciergeCost})
+	}
+
+	return lines
+}
+
+// Summary renders the breakdown as a single line, e.g. for reports
+func (b PriceBreakdown) Summary() string {
+	parts := []string{fmt.Sprintf("Car for %.0f days (%s): %.2f", b.RentalDays, b.RentalDuration, b.CarCost)}
+	for _, line := range b.Lines()[1:] {
+		parts = append(parts, fmt.Sprintf("%s: %.2f", line.Name, line.Amount))
+	}
+
+	summary := strings.Join(parts, " + ")
+	if b.DiscountAmount > 0 {
+		summary += fmt.Sprintf(" - Discount %s (%.0f%%): %.2f", b.DiscountLevel, b.DiscountPercent, b.DiscountAmount)
+	}
+
+	return summary + fmt.Sprintf(" = Total: %.2f", b.Total)
+}
+
+// Encode stores the breakdown on the rental it was billed for
+func (b PriceBreakdown) Encode() *string {
+	body, err := json.Marshal(b)
+	if err != nil {
+		return nil
+	}
+	encoded := string(body)
+	return &encoded
+}
+
+// RentalCostDetails describes what a rental was billed: the breakdown stored at booking time
+// (only the total for older bookings), then the late fee and the grand total
+func RentalCostDetails(rental models.RentalHistory) string {
+	details := fmt.Sprintf("Total: %.2f", rental.TotalCost)
+	var breakdown PriceBreakdown
+	if rental.CostBreakdown != nil && json.Unmarshal([]byte(*rental.CostBreakdown), &breakdown) == nil {
+		details = breakdown.Summary()
+	}
+
+	if rental.LateFee > 0 {
+		details += fmt.Sprintf(" + Late Fee: %.2f = Grand Total: %.2f", rental.LateFee, RentalGrandTotal(rental))
+	}
+	return details
+}
+
+// RentalGrandTotal is the booking total plus the late fee charged at return
+func RentalGrandTotal(rental models.RentalHistory) float64 {
+	return roundCents(rental.TotalCost + rental.LateFee)
+}
+
+// GetMembershipDiscountLevel returns the membership level of a user, or an empty string without a membership
+func GetMembershipDiscountLevel(db *gorm.DB, userID uint) string {
+	var membership models.Membership
+	if err := db.Where("user_id = ?", userID).First(&membership).Error; err != nil {
+		return ""
+	}
+	return membership.DiscountLevel
+}
+
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
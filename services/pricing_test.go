@@ -0,0 +1,138 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"jakarta-luxury-rent-car/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func emptyPricingConfig() PricingConfig {
+	return PricingConfig{
+		CarRates: map[uint]models.CarRate{},
+		Addons:   map[string]models.AddonPrice{},
+		Settings: models.PricingSetting{Rounding: RoundingFullDay},
+		Holidays: map[string]string{},
+	}
+}
+
+func TestCalculatePrice_FullBooking(t *testing.T) {
+	// Tuesday to Friday, no weekend involved
+	rentalDate := time.Date(2024, 10, 1, 9, 0, 0, 0, time.UTC)
+
+	breakdown := CalculatePrice(emptyPricingConfig(), PriceInput{
+		Car:               models.Car{CarID: 5, RentalCosts: 200},
+		RentalDate:        rentalDate,
+		ReturnDate:        rentalDate.Add(3 * 24 * time.Hour),
+		WithDriver:        true,
+		Package:           &models.EventPackage{PackageName: "Wedding Package", Cost: 500},
+		PickupDropoff:     true,
+		AirportTransfer:   true,
+		ConciergeServices: true,
+		DiscountLevel:     "Gold",
+	})
+
+	assert.Equal(t, 3.0, breakdown.RentalDays)
+	assert.Equal(t, 600.0, breakdown.CarCost)
+	assert.Equal(t, 300.0, breakdown.DriverCost)
+	assert.Equal(t, 500.0, breakdown.PackageCost)
+	assert.Equal(t, 1650.0, breakdown.Subtotal)
+	assert.Equal(t, 20.0, breakdown.DiscountPercent)
+	assert.Equal(t, 330.0, breakdown.DiscountAmount)
+	assert.Equal(t, 1320.0, breakdown.Total)
+	assert.Len(t, breakdown.Lines(), 6)
+}
+
+func TestCalculatePrice_CarOnlyWithoutMembership(t *testing.T) {
+	rentalDate := time.Date(2024, 10, 1, 9, 0, 0, 0, time.UTC)
+
+	breakdown := CalculatePrice(emptyPricingConfig(), PriceInput{
+		Car:        models.Car{CarID: 5, RentalCosts: 150},
+		RentalDate: rentalDate,
+		ReturnDate: rentalDate.Add(2 * 24 * time.Hour),
+	})
+
+	assert.Equal(t, 300.0, breakdown.Total)
+	assert.Equal(t, 0.0, breakdown.DiscountAmount)
+	assert.Len(t, breakdown.Lines(), 1)
+	assert.Equal(t, "Car for 2 days (daily): 300.00 = Total: 300.00", breakdown.Summary())
+}
+
+func TestCalculatePrice_RoundingRules(t *testing.T) {
+	rentalDate := time.Date(2024, 10, 1, 9, 0, 0, 0, time.UTC)
+	input := PriceInput{
+		Car:        models.Car{CarID: 5, RentalCosts: 100},
+		RentalDate: rentalDate,
+		ReturnDate: rentalDate.Add(36 * time.Hour),
+	}
+
+	config := emptyPricingConfig()
+	assert.Equal(t, 200.0, CalculatePrice(config, input).Total)
+
+	config.Settings.Rounding = RoundingHourly
+	assert.Equal(t, 150.0, CalculatePrice(config, input).Total)
+}
+
+func TestCalculatePrice_WeeklyAndMonthlyRates(t *testing.T) {
+	rentalDate := time.Date(2024, 10, 1, 9, 0, 0, 0, time.UTC)
+
+	config := emptyPricingConfig()
+	config.CarRates[5] = models.CarRate{CarID: 5, DailyRate: 100, WeeklyRate: 600, MonthlyRate: 2000}
+
+	input := PriceInput{
+		Car:            models.Car{CarID: 5, RentalCosts: 999},
+		RentalDate:     rentalDate,
+		ReturnDate:     rentalDate.AddDate(0, 0, 9),
+		RentalDuration: "weekly",
+	}
+	// One week at the weekly rate and two days at the daily rate
+	assert.Equal(t, 800.0, CalculatePrice(config, input).CarCost)
+
+	input.RentalDuration = "monthly"
+	input.ReturnDate = rentalDate.AddDate(0, 0, 38)
+	// One month, one week and one day
+	assert.Equal(t, 2700.0, CalculatePrice(config, input).CarCost)
+}
+
+func TestCalculatePrice_WeekendAndHolidaySurcharges(t *testing.T) {
+	// Friday to Monday: Friday is a holiday, Saturday and Sunday are weekend days
+	rentalDate := time.Date(2024, 10, 4, 9, 0, 0, 0, time.UTC)
+
+	config := emptyPricingConfig()
+	config.Settings.WeekendSurchargePercent = 10
+	config.Settings.HolidaySurchargePercent = 50
+	config.Holidays["2024-10-04"] = "Company Holiday"
+	config.Addons[AddonAirportTransfer] = models.AddonPrice{Code: AddonAirportTransfer, Price: 75}
+
+	breakdown := CalculatePrice(config, PriceInput{
+		Car:             models.Car{CarID: 5, RentalCosts: 200},
+		RentalDate:      rentalDate,
+		ReturnDate:      rentalDate.AddDate(0, 0, 3),
+		AirportTransfer: true,
+	})
+
+	assert.Equal(t, 1, breakdown.HolidayDays)
+	assert.Equal(t, 2, breakdown.WeekendDays)
+	assert.Equal(t, 140.0, breakdown.SurchargeCost)
+	assert.Equal(t, 75.0, breakdown.AirportTransferCost)
+	assert.Equal(t, 815.0, breakdown.Total)
+}
+
+func TestRentalCostDetails_UsesTheStoredBreakdown(t *testing.T) {
+	breakdown := PriceBreakdown{RentalDuration: "daily", RentalDays: 2, CarCost: 2000000, Subtotal: 2000000, Total: 2000000}
+	rental := models.RentalHistory{TotalCost: 2000000, CostBreakdown: breakdown.Encode()}
+
+	assert.Equal(t, "Car for 2 days (daily): 2000000.00 = Total: 2000000.00", RentalCostDetails(rental))
+
+	rental.LateFee = 500000
+	assert.Equal(t, "Car for 2 days (daily): 2000000.00 = Total: 2000000.00 + Late Fee: 500000.00 = Grand Total: 2500000.00", RentalCostDetails(rental))
+	assert.Equal(t, 2500000.0, RentalGrandTotal(rental))
+}
+
+func TestRentalCostDetails_WithoutAStoredBreakdown(t *testing.T) {
+	rental := models.RentalHistory{TotalCost: 1500000.5, LateFee: 250000.25}
+
+	assert.Equal(t, "Total: 1500000.50 + Late Fee: 250000.25 = Grand Total: 1750000.75", RentalCostDetails(rental))
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimiter allows at most Limit hits per key within a sliding Window. State is kept in memory,
+// so every instance of the API limits on its own.
+type RateLimiter struct {
+	Limit  int
+	Window time.Duration
+
+	mu        sync.Mutex
+	hits      map[string][]time.Time
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewRateLimiter creates a limiter of limit hits per window
+func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
+	return &RateLimiter{Limit: limit, Window: window, hits: map[string][]time.Time{}, now: time.Now}
+}
+
+// Allow records a hit for key and reports whether it is within the limit.
+// When it is not, it also returns how long until the next hit would be allowed.
+func (l *RateLimiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	recent := l.recent(key, now)
+	if len(recent) >= l.Limit {
+		l.hits[key] = recent
+		return false, recent[0].Add(l.Window).Sub(now)
+	}
+
+	l.hits[key] = append(recent, now)
+	return true, 0
+}
+
+// recent returns the hits of key still inside the window
+func (l *RateLimiter) recent(key string, now time.Time) []time.Time {
+	hits := l.hits[key]
+	start := 0
+	for start < len(hits) && !hits[start].After(now.Add(-l.Window)) {
+		start++
+	}
+	return hits[start:]
+}
+
+// sweep forgets keys without recent hits, at most once per window
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.Window {
+		return
+	}
+	l.lastSweep = now
+
+	for key := range l.hits {
+		if len(l.recent(key, now)) == 0 {
+			delete(l.hits, key)
+		}
+	}
+}
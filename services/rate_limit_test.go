@@ -0,0 +1,46 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Date(2024, time.August, 17, 9, 0, 0, 0, time.UTC)
+	limiter := NewRateLimiter(3, time.Minute)
+	limiter.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		allowed, _ := limiter.Allow("ip:10.0.0.1")
+		assert.True(t, allowed)
+		now = now.Add(10 * time.Second)
+	}
+
+	// The fourth hit within a minute waits until the first one leaves the window
+	allowed, retryAfter := limiter.Allow("ip:10.0.0.1")
+	assert.False(t, allowed)
+	assert.Equal(t, 30*time.Second, retryAfter)
+
+	// Other keys are counted separately
+	allowed, _ = limiter.Allow("ip:10.0.0.2")
+	assert.True(t, allowed)
+
+	now = now.Add(30 * time.Second)
+	allowed, _ = limiter.Allow("ip:10.0.0.1")
+	assert.True(t, allowed)
+}
+
+func TestRateLimiter_ForgetsIdleKeys(t *testing.T) {
+	now := time.Date(2024, time.August, 17, 9, 0, 0, 0, time.UTC)
+	limiter := NewRateLimiter(1, time.Minute)
+	limiter.now = func() time.Time { return now }
+
+	limiter.Allow("email:a@example.com")
+	now = now.Add(2 * time.Minute)
+	limiter.Allow("email:b@example.com")
+
+	assert.NotContains(t, limiter.hits, "email:a@example.com")
+	assert.Contains(t, limiter.hits, "email:b@example.com")
+}
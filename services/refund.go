@@ -0,0 +1,226 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"jakarta-luxury-rent-car/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Refund methods and statuses stored in refunds
+const (
+	RefundMethodWallet  = "wallet"
+	RefundMethodGateway = "gateway"
+
+	RefundRequested  = "requested"
+	RefundProcessing = "processing"
+	RefundCompleted  = "completed"
+	RefundFailed     = "failed"
+)
+
+var ErrRefundNotRetryable = errors.New("only gateway refunds that were not sent, failed, or got no answer from the gateway can be retried")
+
+// RequestRefund records a refund of amount for a rental inside tx. Rentals paid through a gateway
+// invoice that still has amount left to refund are refunded through the gateway and stay "requested"
+// until ProcessGatewayRefund runs after the transaction commits. Everything else is credited to the
+// deposit right away and completes in the same transaction.
+func RequestRefund(tx *gorm.DB, rental models.RentalHistory, amount float64, reason string) (models.Refund, error) {
+	paidInvoice, err := refundableInvoice(tx, rental.RentalID, amount)
+	if err != nil {
+		return models.Refund{}, err
+	}
+
+	refund := models.Refund{
+		RentalID:    rental.RentalID,
+		UserID:      rental.UserID,
+		Amount:      roundCents(amount),
+		Method:      refundMethodFor(paidInvoice),
+		Status:      RefundRequested,
+		Reason:      reason,
+		ReferenceID: NewInvoiceExternalID("refund", rental.RentalID, time.Now()),
+	}
+	if refund.Method == RefundMethodGateway {
+		refund.InvoiceID = &paidInvoice.InvoiceID
+	}
+
+	if err := tx.Create(&refund).Error; err != nil {
+		return refund, err
+	}
+
+	if refund.Method == RefundMethodGateway {
+		return refund, nil
+	}
+
+	transaction, err := PostWalletTransaction(tx, WalletEntry{
+		UserID:      rental.UserID,
+		Type:        WalletRefund,
+		Amount:      refund.Amount,
+		RentalID:    &rental.RentalID,
+		Description: reason,
+	})
+	if err != nil {
+		return refund, err
+	}
+
+	now := time.Now()
+	refund.WalletTransactionID = &transaction.WalletTransactionID
+	refund.Status = RefundCompleted
+	refund.CompletedAt = &now
+
+	return refund, tx.Save(&refund).Error
+}
+
+// RefundRetryable reports whether a gateway refund may be sent to the gateway: it was never sent, the
+// gateway refused it, or the request got no answer and the refund is still waiting in processing.
+func RefundRetryable(refund models.Refund) bool {
+	if refund.Method != RefundMethodGateway {
+		return false
+	}
+
+	switch refund.Status {
+	case RefundRequested, RefundFailed:
+		return true
+	case RefundProcessing:
+		return refund.FailureReason != ""
+	}
+	return false
+}
+
+// ProcessGatewayRefund sends a retryable gateway refund to the payment gateway and stores the outcome.
+// The gateway is called outside any transaction; the refund's reference ID keeps a retry from paying out twice.
+// Only a refusal by the gateway marks the refund failed. When the request fails in transit the gateway may
+// still have accepted it, so the refund stays processing with the error kept until a callback settles it.
+func ProcessGatewayRefund(db *gorm.DB, gateway PaymentGateway, refund models.Refund) (models.Refund, error) {
+	if !RefundRetryable(refund) {
+		return refund, ErrRefundNotRetryable
+	}
+
+	var invoice models.Invoice
+	if err := db.First(&invoice, *refund.InvoiceID).Error; err != nil {
+		return refund, err
+	}
+
+	refund.Status = RefundProcessing
+	refund.FailureReason = ""
+	if err := db.Save(&refund).Error; err != nil {
+		return refund, err
+	}
+
+	gatewayRefund, err := gateway.Refund(RefundRequest{
+		InvoiceID:   invoice.XenditInvoiceID,
+		ReferenceID: refund.ReferenceID,
+		Amount:      refund.Amount,
+		Reason:      refund.Reason,
+	})
+	if err != nil {
+		refund.FailureReason = err.Error()
+		if GatewayRejected(err) {
+			refund.Status = RefundFailed
+		}
+		if saveErr := db.Save(&refund).Error; saveErr != nil {
+			return refund, saveErr
+		}
+		return refund, err
+	}
+
+	refund.GatewayRefundID = gatewayRefund.ID
+	applyGatewayRefundStatus(&refund, gatewayRefund.Status, "")
+
+	return refund, db.Save(&refund).Error
+}
+
+// ApplyGatewayRefundUpdate stores a refund status the gateway reports later, e.g. through a callback.
+// It returns whether the refund changed. Updates for completed refunds are ignored, and a failed refund
+// only moves on when the gateway reports it succeeded after all.
+func ApplyGatewayRefundUpdate(tx *gorm.DB, referenceID, gatewayStatus, failureReason string) (models.Refund, bool, error) {
+	var refund models.Refund
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("reference_id = ?", referenceID).First(&refund).Error; err != nil {
+		return refund, false, err
+	}
+
+	if !acceptsGatewayRefundUpdate(refund.Status, gatewayStatus) {
+		return refund, false, nil
+	}
+
+	applyGatewayRefundStatus(&refund, gatewayStatus, failureReason)
+	if refund.Status == RefundProcessing {
+		return refund, false, nil
+	}
+
+	return refund, true, tx.Save(&refund).Error
+}
+
+// acceptsGatewayRefundUpdate reports whether a gateway status may still change a refund. A refund marked
+// failed may have been paid out anyway, so its money leaving must be recorded.
+func acceptsGatewayRefundUpdate(refundStatus, gatewayStatus string) bool {
+	switch refundStatus {
+	case RefundProcessing:
+		return true
+	case RefundFailed:
+		return gatewayStatus == GatewayRefundSucceeded
+	}
+	return false
+}
+
+// refundMethodFor refunds through the gateway when the rental was paid by an invoice that covers the amount
+func refundMethodFor(paidInvoice *models.Invoice) string {
+	if paidInvoice != nil {
+		return RefundMethodGateway
+	}
+	return RefundMethodWallet
+}
+
+// refundableInvoice returns the newest paid booking or supplementary invoice of a rental that can still refund amount
+// through the gateway, or nil when none can and the refund goes to the deposit
+func refundableInvoice(tx *gorm.DB, rentalID uint, amount float64) (*models.Invoice, error) {
+	var invoices []models.Invoice
+	if err := tx.Where("rental_id = ? AND purpose IN ? AND status = ?", rentalID, []string{InvoicePurposeBooking, InvoicePurposeSupplement}, InvoiceStatusPaid).
+		Order("paid_at DESC").
+		Find(&invoices).Error; err != nil {
+		return nil, err
+	}
+
+	for i := range invoices {
+		// Failed gateway refunds count too, since they can still be retried
+		var refunded float64
+		if err := tx.Model(&models.Refund{}).
+			Where("invoice_id = ? AND method = ?", invoices[i].InvoiceID, RefundMethodGateway).
+			Select("COALESCE(SUM(amount), 0)").
+			Scan(&refunded).Error; err != nil {
+			return nil, err
+		}
+
+		if CanRefundFromInvoice(invoices[i], refunded, amount) {
+			return &invoices[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// CanRefundFromInvoice reports whether amount fits in what is left of a paid invoice after the
+// gateway refunds already made or pending against it
+func CanRefundFromInvoice(invoice models.Invoice, refunded, amount float64) bool {
+	return roundCents(amount) <= roundCents(invoice.Amount-refunded)
+}
+
+// applyGatewayRefundStatus maps a gateway refund status onto the refund
+func applyGatewayRefundStatus(refund *models.Refund, gatewayStatus, failureReason string) {
+	switch gatewayStatus {
+	case GatewayRefundSucceeded:
+		now := time.Now()
+		refund.Status = RefundCompleted
+		refund.CompletedAt = &now
+		refund.FailureReason = ""
+	case GatewayRefundFailed:
+		refund.Status = RefundFailed
+		refund.FailureReason = failureReason
+		if refund.FailureReason == "" {
+			refund.FailureReason = "refund failed at the payment gateway"
+		}
+	default:
+		refund.Status = RefundProcessing
+	}
+}
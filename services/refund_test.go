@@ -0,0 +1,79 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"jakarta-luxury-rent-car/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefundMethodFor(t *testing.T) {
+	assert.Equal(t, RefundMethodWallet, refundMethodFor(nil))
+	assert.Equal(t, RefundMethodGateway, refundMethodFor(&models.Invoice{Amount: 300}))
+}
+
+func TestCanRefundFromInvoice(t *testing.T) {
+	invoice := models.Invoice{Amount: 300}
+
+	assert.True(t, CanRefundFromInvoice(invoice, 0, 150))
+	assert.True(t, CanRefundFromInvoice(invoice, 0, 300))
+	assert.False(t, CanRefundFromInvoice(invoice, 0, 350))
+
+	// The callback already refunded the invoice in full, a later cancellation goes to the deposit
+	assert.False(t, CanRefundFromInvoice(invoice, 300, 300))
+	assert.True(t, CanRefundFromInvoice(invoice, 100, 200))
+	assert.False(t, CanRefundFromInvoice(invoice, 100.01, 200))
+}
+
+func TestApplyGatewayRefundStatus(t *testing.T) {
+	succeeded := models.Refund{Status: RefundProcessing}
+	applyGatewayRefundStatus(&succeeded, GatewayRefundSucceeded, "")
+	assert.Equal(t, RefundCompleted, succeeded.Status)
+	assert.NotNil(t, succeeded.CompletedAt)
+
+	failed := models.Refund{Status: RefundProcessing}
+	applyGatewayRefundStatus(&failed, GatewayRefundFailed, "")
+	assert.Equal(t, RefundFailed, failed.Status)
+	assert.NotEmpty(t, failed.FailureReason)
+
+	pending := models.Refund{Status: RefundRequested}
+	applyGatewayRefundStatus(&pending, GatewayRefundPending, "")
+	assert.Equal(t, RefundProcessing, pending.Status)
+	assert.Nil(t, pending.CompletedAt)
+}
+
+func TestRefundRetryable(t *testing.T) {
+	assert.True(t, RefundRetryable(models.Refund{Method: RefundMethodGateway, Status: RefundRequested}))
+	assert.True(t, RefundRetryable(models.Refund{Method: RefundMethodGateway, Status: RefundFailed}))
+	assert.False(t, RefundRetryable(models.Refund{Method: RefundMethodGateway, Status: RefundCompleted}))
+	assert.False(t, RefundRetryable(models.Refund{Method: RefundMethodWallet, Status: RefundFailed}))
+
+	// Waiting on the gateway is only retryable when the request never got an answer
+	assert.False(t, RefundRetryable(models.Refund{Method: RefundMethodGateway, Status: RefundProcessing}))
+	assert.True(t, RefundRetryable(models.Refund{Method: RefundMethodGateway, Status: RefundProcessing, FailureReason: "timeout"}))
+}
+
+func TestGatewayRejected(t *testing.T) {
+	assert.True(t, GatewayRejected(ErrGatewayRefundTooLarge))
+	assert.True(t, GatewayRejected(fmt.Errorf("%w: 400 Bad Request", ErrGatewayRejected)))
+
+	// No answer, so the gateway may have accepted the refund
+	assert.False(t, GatewayRejected(errors.New("failed to send HTTP request: timeout")))
+	assert.False(t, GatewayRejected(errors.New("received non-2xx response: 503 Service Unavailable")))
+}
+
+func TestAcceptsGatewayRefundUpdate(t *testing.T) {
+	assert.True(t, acceptsGatewayRefundUpdate(RefundProcessing, GatewayRefundSucceeded))
+	assert.True(t, acceptsGatewayRefundUpdate(RefundProcessing, GatewayRefundFailed))
+
+	// A refund failed on our side that the gateway paid out after all
+	assert.True(t, acceptsGatewayRefundUpdate(RefundFailed, GatewayRefundSucceeded))
+	assert.False(t, acceptsGatewayRefundUpdate(RefundFailed, GatewayRefundFailed))
+	assert.False(t, acceptsGatewayRefundUpdate(RefundFailed, GatewayRefundPending))
+
+	assert.False(t, acceptsGatewayRefundUpdate(RefundCompleted, GatewayRefundFailed))
+	assert.False(t, acceptsGatewayRefundUpdate(RefundRequested, GatewayRefundSucceeded))
+}
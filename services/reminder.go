@@ -0,0 +1,199 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"jakarta-luxury-rent-car/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Reminder kinds, each is sent at most once per rental (unique in reminder_logs)
+const (
+	ReminderPickup        = "pickup"
+	ReminderReturn        = "return"
+	ReminderOverdue       = "overdue"
+	ReminderUnpaidInvoice = "unpaid_invoice"
+)
+
+// OutboxReminder is the outbox kind a due reminder is queued under
+const OutboxReminder = "rental_reminder"
+
+// ReminderPayload is the outbox payload of a reminder
+type ReminderPayload struct {
+	RentalID  uint   `json:"rental_id"`
+	Kind      string `json:"kind"`
+	InvoiceID uint   `json:"invoice_id,omitempty"` // Set for unpaid invoice reminders
+}
+
+// ReminderConfig holds when each reminder is due
+type ReminderConfig struct {
+	PickupLead  time.Duration  // Before RentalDate
+	ReturnHour  int            // Local hour on the day of ReturnDate
+	InvoiceLead time.Duration  // Before the invoice expires
+	Location    *time.Location // Local time of the rental company
+}
+
+// DefaultReminderConfig sends pickup reminders a day ahead, return reminders at 08:00 WIB
+// and unpaid invoice reminders 3 hours before the invoice expires
+func DefaultReminderConfig() ReminderConfig {
+	return ReminderConfig{
+		PickupLead:  24 * time.Hour,
+		ReturnHour:  8,
+		InvoiceLead: 3 * time.Hour,
+		Location:    time.FixedZone("WIB", 7*60*60),
+	}
+}
+
+// PickupReminderDue reports whether the pickup is within the lead time and still ahead
+func PickupReminderDue(rentalDate, now time.Time, config ReminderConfig) bool {
+	return now.Before(rentalDate) && !now.Before(rentalDate.Add(-config.PickupLead))
+}
+
+// ReturnReminderTime is the morning of the return day in local time. A return before that hour
+// is reminded two hours ahead instead.
+func ReturnReminderTime(returnDate time.Time, config ReminderConfig) time.Time {
+	local := returnDate.In(config.Location)
+	morning := time.Date(local.Year(), local.Month(), local.Day(), config.ReturnHour, 0, 0, 0, config.Location)
+	if !morning.Before(returnDate) {
+		return returnDate.Add(-2 * time.Hour)
+	}
+	return morning
+}
+
+// ReturnReminderDue reports whether the return reminder time has passed but the car is not due back yet
+func ReturnReminderDue(returnDate, now time.Time, config ReminderConfig) bool {
+	return now.Before(returnDate) && !now.Before(ReturnReminderTime(returnDate, config))
+}
+
+// OverdueAlertDue reports whether a rental is past its return date and the late fee grace period
+func OverdueAlertDue(returnDate, now time.Time) bool {
+	return now.Sub(returnDate) > lateReturnGracePeriod
+}
+
+// InvoiceReminderDue reports whether an unpaid invoice expires within the lead time
+func InvoiceReminderDue(expiresAt, now time.Time, config ReminderConfig) bool {
+	return now.Before(expiresAt) && !now.Before(expiresAt.Add(-config.InvoiceLead))
+}
+
+// ReminderScheduler periodically queues due reminders into the outbox
+type ReminderScheduler struct {
+	db       *gorm.DB
+	config   ReminderConfig
+	Interval time.Duration
+}
+
+// NewReminderScheduler creates a scheduler that scans every minute
+func NewReminderScheduler(db *gorm.DB, config ReminderConfig) *ReminderScheduler {
+	return &ReminderScheduler{db: db, config: config, Interval: time.Minute}
+}
+
+// Run scans for due reminders until ctx is cancelled
+func (s *ReminderScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.QueueDue(time.Now()); err != nil {
+			log.Println("Reminder scan failed: ", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// QueueDue queues every reminder due at now that has not been sent yet and returns how many were queued
+func (s *ReminderScheduler) QueueDue(now time.Time) (int, error) {
+	queued := 0
+
+	// Paid or approved bookings picked up within the lead time
+	var pickups []models.RentalHistory
+	if err := s.unsent(ReminderPickup).
+		Where("status IN ? AND rental_date > ? AND rental_date <= ?", []string{RentalStatusPaid, RentalStatusRent}, now, now.Add(s.config.PickupLead)).
+		Find(&pickups).Error; err != nil {
+		return queued, err
+	}
+	for _, rental := range pickups {
+		if !PickupReminderDue(rental.RentalDate, now, s.config) {
+			continue
+		}
+		if err := s.queue(rental.RentalID, ReminderPayload{RentalID: rental.RentalID, Kind: ReminderPickup}, now, &queued); err != nil {
+			return queued, err
+		}
+	}
+
+	// Rentals out with a customer, due back today or already overdue. Return reminders that were
+	// already sent are skipped by the claim in queue.
+	var rented []models.RentalHistory
+	if err := s.unsent(ReminderOverdue).Where("status = ? AND return_date IS NOT NULL AND return_date <= ?", RentalStatusRent, now.Add(24*time.Hour)).
+		Find(&rented).Error; err != nil {
+		return queued, err
+	}
+	for _, rental := range rented {
+		kind := ""
+		switch {
+		case ReturnReminderDue(*rental.ReturnDate, now, s.config):
+			kind = ReminderReturn
+		case OverdueAlertDue(*rental.ReturnDate, now):
+			kind = ReminderOverdue
+		default:
+			continue
+		}
+		if err := s.queue(rental.RentalID, ReminderPayload{RentalID: rental.RentalID, Kind: kind}, now, &queued); err != nil {
+			return queued, err
+		}
+	}
+
+	// Booking and supplementary invoices about to expire unpaid
+	var invoices []models.Invoice
+	if err := s.db.Where("status = ? AND rental_id IS NOT NULL AND expires_at > ? AND expires_at <= ?", InvoiceStatusPending, now, now.Add(s.config.InvoiceLead)).
+		Where("rental_id NOT IN (SELECT rental_id FROM reminder_logs WHERE kind = ?)", ReminderUnpaidInvoice).
+		Find(&invoices).Error; err != nil {
+		return queued, err
+	}
+	for _, invoice := range invoices {
+		if !InvoiceReminderDue(*invoice.ExpiresAt, now, s.config) {
+			continue
+		}
+		payload := ReminderPayload{RentalID: *invoice.RentalID, Kind: ReminderUnpaidInvoice, InvoiceID: invoice.InvoiceID}
+		if err := s.queue(*invoice.RentalID, payload, now, &queued); err != nil {
+			return queued, err
+		}
+	}
+
+	return queued, nil
+}
+
+// unsent selects rentals that have not had the given reminder yet
+func (s *ReminderScheduler) unsent(kind string) *gorm.DB {
+	return s.db.Where("rental_id NOT IN (SELECT rental_id FROM reminder_logs WHERE kind = ?)", kind)
+}
+
+// queue claims the reminder in reminder_logs and queues its delivery in one transaction.
+// The unique (rental_id, kind) claim lets several instances scan at the same time: only the
+// instance that inserts the log row queues the reminder.
+func (s *ReminderScheduler) queue(rentalID uint, payload ReminderPayload, now time.Time, queued *int) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		entry := models.ReminderLog{RentalID: rentalID, Kind: payload.Kind, SentAt: now}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&entry)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		if err := EnqueueOutbox(tx, OutboxReminder, payload); err != nil {
+			return err
+		}
+		*queued++
+		return nil
+	})
+}
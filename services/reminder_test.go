@@ -0,0 +1,59 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPickupReminderDue(t *testing.T) {
+	config := DefaultReminderConfig()
+	pickup := time.Date(2024, time.May, 10, 10, 0, 0, 0, config.Location)
+
+	assert.False(t, PickupReminderDue(pickup, pickup.Add(-25*time.Hour), config))
+	assert.True(t, PickupReminderDue(pickup, pickup.Add(-24*time.Hour), config))
+	assert.True(t, PickupReminderDue(pickup, pickup.Add(-time.Minute), config))
+	assert.False(t, PickupReminderDue(pickup, pickup, config))
+}
+
+func TestReturnReminderTime(t *testing.T) {
+	config := DefaultReminderConfig()
+
+	afternoon := time.Date(2024, time.May, 12, 15, 0, 0, 0, config.Location)
+	assert.Equal(t, time.Date(2024, time.May, 12, 8, 0, 0, 0, config.Location), ReturnReminderTime(afternoon, config))
+
+	// Stored in UTC, still the morning of the local return day
+	utc := time.Date(2024, time.May, 12, 3, 0, 0, 0, time.UTC) // 10:00 WIB
+	assert.True(t, ReturnReminderTime(utc, config).Equal(time.Date(2024, time.May, 12, 8, 0, 0, 0, config.Location)))
+
+	// Returns before the morning reminder are reminded two hours ahead
+	early := time.Date(2024, time.May, 12, 7, 0, 0, 0, config.Location)
+	assert.Equal(t, early.Add(-2*time.Hour), ReturnReminderTime(early, config))
+}
+
+func TestReturnReminderDue(t *testing.T) {
+	config := DefaultReminderConfig()
+	returnDate := time.Date(2024, time.May, 12, 15, 0, 0, 0, config.Location)
+
+	assert.False(t, ReturnReminderDue(returnDate, time.Date(2024, time.May, 12, 7, 59, 0, 0, config.Location), config))
+	assert.True(t, ReturnReminderDue(returnDate, time.Date(2024, time.May, 12, 8, 0, 0, 0, config.Location), config))
+	assert.False(t, ReturnReminderDue(returnDate, returnDate, config))
+}
+
+func TestOverdueAlertDue(t *testing.T) {
+	returnDate := time.Date(2024, time.May, 12, 15, 0, 0, 0, time.UTC)
+
+	assert.False(t, OverdueAlertDue(returnDate, returnDate.Add(30*time.Minute)))
+	assert.False(t, OverdueAlertDue(returnDate, returnDate.Add(time.Hour)))
+	assert.True(t, OverdueAlertDue(returnDate, returnDate.Add(time.Hour+time.Minute)))
+}
+
+func TestInvoiceReminderDue(t *testing.T) {
+	config := DefaultReminderConfig()
+	expiresAt := time.Date(2024, time.May, 12, 15, 0, 0, 0, time.UTC)
+
+	assert.False(t, InvoiceReminderDue(expiresAt, expiresAt.Add(-4*time.Hour), config))
+	assert.True(t, InvoiceReminderDue(expiresAt, expiresAt.Add(-3*time.Hour), config))
+	assert.False(t, InvoiceReminderDue(expiresAt, expiresAt, config))
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"jakarta-luxury-rent-car/models"
+
+	"gorm.io/gorm"
+)
+
+// Rental statuses stored in rental_histories.status
+const (
+	RentalStatusBook      = "Book"
+	RentalStatusPaid      = "Paid"
+	RentalStatusRent      = "Rent"
+	RentalStatusCompleted = "Completed"
+	RentalStatusCancel    = "Cancel"
+	RentalStatusExpired   = "Expired" // Never paid within the payment hold window
+)
+
+// Actor roles that can trigger a rental status transition.
+// "customer" is the user the rental belongs to, whatever their account role is.
+const (
+	ActorCustomer = "customer"
+	ActorOwner    = "owner"
+	ActorStaff    = "staff"
+	ActorAdmin    = "admin"
+	ActorSystem   = "system"
+)
+
+var (
+	ErrIllegalTransition   = errors.New("illegal rental status transition")
+	ErrTransitionForbidden = errors.New("actor is not allowed to perform this rental status transition")
+)
+
+// rentalTransitions lists, per current status, the statuses a rental may move to and who may move it there
+var rentalTransitions = map[string]map[string][]string{
+	"": {
+		RentalStatusBook: {ActorCustomer},
+	},
+	RentalStatusBook: {
+		RentalStatusPaid:    {ActorCustomer, ActorSystem},
+		RentalStatusCancel:  {ActorCustomer, ActorOwner, ActorAdmin, ActorSystem},
+		RentalStatusExpired: {ActorSystem},
+	},
+	RentalStatusPaid: {
+		RentalStatusRent:   {ActorOwner, ActorAdmin, ActorStaff},
+		RentalStatusCancel: {ActorCustomer, ActorOwner, ActorAdmin},
+	},
+	RentalStatusRent: {
+		RentalStatusCompleted: {ActorOwner, ActorAdmin, ActorStaff},
+	},
+}
+
+// RentalActor identifies who triggers a transition
+type RentalActor struct {
+	UserID *uint
+	Role   string
+}
+
+// CustomerActor is the customer acting on their own rental
+func CustomerActor(userID uint) RentalActor {
+	return RentalActor{UserID: &userID, Role: ActorCustomer}
+}
+
+// StaffActor is an owner, admin or staff member acting on somebody else's rental
+func StaffActor(user models.User) RentalActor {
+	return RentalActor{UserID: &user.UserID, Role: user.Role}
+}
+
+// SystemActor is the service itself, e.g. a payment callback or a background job
+func SystemActor() RentalActor {
+	return RentalActor{Role: ActorSystem}
+}
+
+// CheckRentalTransition validates that actor may move rental from its current status to the given one
+func CheckRentalTransition(rental models.RentalHistory, to string, actor RentalActor) error {
+	allowedRoles, ok := rentalTransitions[rental.Status][to]
+	if !ok {
+		return fmt.Errorf("%w: %q to %q", ErrIllegalTransition, rental.Status, to)
+	}
+
+	if actor.Role == ActorCustomer && (actor.UserID == nil || *actor.UserID != rental.UserID) {
+		return fmt.Errorf("%w: rental belongs to another customer", ErrTransitionForbidden)
+	}
+
+	for _, role := range allowedRoles {
+		if role == actor.Role {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %s cannot move a rental from %q to %q", ErrTransitionForbidden, actor.Role, rental.Status, to)
+}
+
+// TransitionRental moves rental to a new status inside tx and records the transition.
+// The update only applies while the stored status is still the one the rental was loaded with,
+// so two concurrent transitions cannot both win.
+func TransitionRental(tx *gorm.DB, rental *models.RentalHistory, to string, actor RentalActor, reason string) error {
+	if err := CheckRentalTransition(*rental, to, actor); err != nil {
+		return err
+	}
+
+	from := rental.Status
+	result := tx.Model(&models.RentalHistory{}).
+		Where("rental_id = ? AND status = ?", rental.RentalID, from).
+		Update("status", to)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: rental %d is no longer %q", ErrIllegalTransition, rental.RentalID, from)
+	}
+
+	rental.Status = to
+	return recordRentalStatusEvent(tx, rental.RentalID, from, to, actor, reason)
+}
+
+// RecordRentalCreated validates and records the initial transition of a newly created rental
+func RecordRentalCreated(tx *gorm.DB, rental models.RentalHistory, actor RentalActor, reason string) error {
+	if err := CheckRentalTransition(models.RentalHistory{UserID: rental.UserID}, rental.Status, actor); err != nil {
+		return err
+	}
+
+	return recordRentalStatusEvent(tx, rental.RentalID, "", rental.Status, actor, reason)
+}
+
+func recordRentalStatusEvent(tx *gorm.DB, rentalID uint, from, to string, actor RentalActor, reason string) error {
+	event := models.RentalStatusEvent{
+		RentalID:    rentalID,
+		FromStatus:  from,
+		ToStatus:    to,
+		ActorUserID: actor.UserID,
+		ActorRole:   actor.Role,
+		Reason:      reason,
+		CreatedAt:   time.Now(),
+	}
+
+	return tx.Create(&event).Error
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"testing"
+
+	"jakarta-luxury-rent-car/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckRentalTransition_AllowedTransitions(t *testing.T) {
+	owner := StaffActor(models.User{UserID: 1, Role: "owner"})
+	customer := CustomerActor(7)
+
+	assert.NoError(t, CheckRentalTransition(models.RentalHistory{UserID: 7, Status: RentalStatusBook}, RentalStatusPaid, customer))
+	assert.NoError(t, CheckRentalTransition(models.RentalHistory{UserID: 7, Status: RentalStatusBook}, RentalStatusPaid, SystemActor()))
+	assert.NoError(t, CheckRentalTransition(models.RentalHistory{UserID: 7, Status: RentalStatusPaid}, RentalStatusRent, owner))
+	assert.NoError(t, CheckRentalTransition(models.RentalHistory{UserID: 7, Status: RentalStatusRent}, RentalStatusCompleted, owner))
+	assert.NoError(t, CheckRentalTransition(models.RentalHistory{UserID: 7, Status: RentalStatusPaid}, RentalStatusCancel, customer))
+	assert.NoError(t, CheckRentalTransition(models.RentalHistory{UserID: 7, Status: RentalStatusBook}, RentalStatusExpired, SystemActor()))
+
+	admin := StaffActor(models.User{UserID: 3, Role: "admin"})
+	assert.NoError(t, CheckRentalTransition(models.RentalHistory{UserID: 7, Status: RentalStatusPaid}, RentalStatusRent, admin))
+	assert.NoError(t, CheckRentalTransition(models.RentalHistory{UserID: 7, Status: RentalStatusPaid}, RentalStatusCancel, admin))
+}
+
+func TestCheckRentalTransition_RejectsIllegalTransitions(t *testing.T) {
+	owner := StaffActor(models.User{UserID: 1, Role: "owner"})
+
+	// Approving an unpaid booking
+	err := CheckRentalTransition(models.RentalHistory{UserID: 7, Status: RentalStatusBook}, RentalStatusRent, owner)
+	assert.ErrorIs(t, err, ErrIllegalTransition)
+
+	// Rejecting a rental that is already with the customer
+	err = CheckRentalTransition(models.RentalHistory{UserID: 7, Status: RentalStatusRent}, RentalStatusCancel, owner)
+	assert.ErrorIs(t, err, ErrIllegalTransition)
+
+	// Nothing leaves a final state
+	err = CheckRentalTransition(models.RentalHistory{UserID: 7, Status: RentalStatusCompleted}, RentalStatusRent, owner)
+	assert.ErrorIs(t, err, ErrIllegalTransition)
+}
+
+func TestCheckRentalTransition_RejectsWrongActor(t *testing.T) {
+	staff := StaffActor(models.User{UserID: 2, Role: "staff"})
+
+	// Staff can hand over cars but not reject bookings
+	err := CheckRentalTransition(models.RentalHistory{UserID: 7, Status: RentalStatusPaid}, RentalStatusCancel, staff)
+	assert.ErrorIs(t, err, ErrTransitionForbidden)
+
+	// Customers cannot approve their own booking
+	err = CheckRentalTransition(models.RentalHistory{UserID: 7, Status: RentalStatusPaid}, RentalStatusRent, CustomerActor(7))
+	assert.ErrorIs(t, err, ErrTransitionForbidden)
+
+	// Only the expiry job expires bookings
+	err = CheckRentalTransition(models.RentalHistory{UserID: 7, Status: RentalStatusBook}, RentalStatusExpired, CustomerActor(7))
+	assert.ErrorIs(t, err, ErrTransitionForbidden)
+
+	// Customers cannot touch another customer's rental
+	err = CheckRentalTransition(models.RentalHistory{UserID: 7, Status: RentalStatusBook}, RentalStatusPaid, CustomerActor(8))
+	assert.ErrorIs(t, err, ErrTransitionForbidden)
+}
@@ -0,0 +1,225 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"jakarta-luxury-rent-car/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultAccessTokenTTL  = 15 * time.Minute
+	defaultRefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+var (
+	ErrRefreshTokenInvalid = errors.New("refresh token is invalid, expired or revoked")
+	ErrRefreshTokenReused  = errors.New("refresh token was already used, the session has been revoked")
+)
+
+// TokenConfig is how long access and refresh tokens are valid
+type TokenConfig struct {
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+}
+
+// DefaultTokenConfig is used when ACCESS_TOKEN_TTL and REFRESH_TOKEN_TTL are unset
+func DefaultTokenConfig() TokenConfig {
+	return TokenConfig{AccessTTL: defaultAccessTokenTTL, RefreshTTL: defaultRefreshTokenTTL}
+}
+
+// LoadTokenConfig reads ACCESS_TOKEN_TTL and REFRESH_TOKEN_TTL as Go durations such as "15m" or "720h"
+func LoadTokenConfig() (TokenConfig, error) {
+	accessTTL, err := envValue("ACCESS_TOKEN_TTL", defaultAccessTokenTTL, parsePositiveDuration)
+	if err != nil {
+		return DefaultTokenConfig(), err
+	}
+	refreshTTL, err := envValue("REFRESH_TOKEN_TTL", defaultRefreshTokenTTL, parsePositiveDuration)
+	if err != nil {
+		return DefaultTokenConfig(), err
+	}
+	return TokenConfig{AccessTTL: accessTTL, RefreshTTL: refreshTTL}, nil
+}
+
+// Session is the token pair issued at login or refresh, the access token itself is signed by the caller
+type Session struct {
+	UserID           uint
+	FamilyID         string
+	AccessJTI        string
+	AccessExpiresAt  time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+}
+
+// NewSecretToken returns a random token to give out once and the hash stored in its place,
+// so a leaked database does not leak usable links or refresh tokens
+func NewSecretToken() (token, hash string, err error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(raw)
+	return token, HashSecretToken(token), nil
+}
+
+// HashSecretToken hashes a token from NewSecretToken
+func HashSecretToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewTokenID returns a random ID for the jti claim and refresh token families
+func NewTokenID() (string, error) {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(raw), nil
+}
+
+// StartSession stores a new refresh token for user inside tx. An empty familyID starts a new family (a login),
+// otherwise the token continues the family of the token it was rotated from.
+func StartSession(tx *gorm.DB, userID uint, familyID string, now time.Time, config TokenConfig) (Session, error) {
+	var err error
+	if familyID == "" {
+		if familyID, err = NewTokenID(); err != nil {
+			return Session{}, err
+		}
+	}
+
+	accessJTI, err := NewTokenID()
+	if err != nil {
+		return Session{}, err
+	}
+
+	token, hash, err := NewSecretToken()
+	if err != nil {
+		return Session{}, err
+	}
+
+	session := Session{
+		UserID:           userID,
+		FamilyID:         familyID,
+		AccessJTI:        accessJTI,
+		AccessExpiresAt:  now.Add(config.AccessTTL),
+		RefreshToken:     token,
+		RefreshExpiresAt: now.Add(config.RefreshTTL),
+	}
+
+	err = tx.Create(&models.RefreshToken{
+		UserID:          userID,
+		FamilyID:        familyID,
+		TokenHash:       hash,
+		AccessJTI:       accessJTI,
+		AccessExpiresAt: session.AccessExpiresAt,
+		ExpiresAt:       session.RefreshExpiresAt,
+	}).Error
+	return session, err
+}
+
+// CheckRefreshToken validates that a stored refresh token can be exchanged at now
+func CheckRefreshToken(token models.RefreshToken, now time.Time) error {
+	if token.RevokedAt != nil || !now.Before(token.ExpiresAt) {
+		return ErrRefreshTokenInvalid
+	}
+	if token.RotatedAt != nil {
+		return ErrRefreshTokenReused
+	}
+	return nil
+}
+
+// RotateRefreshToken exchanges a refresh token for a new session in the same family.
+// Presenting a token that was already rotated revokes the whole family, since either the client
+// or a thief is using a stolen copy.
+func RotateRefreshToken(db *gorm.DB, refreshToken string, now time.Time, config TokenConfig) (Session, error) {
+	var session Session
+	reused := false
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var stored models.RefreshToken
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ?", HashSecretToken(refreshToken)).
+			First(&stored).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrRefreshTokenInvalid
+			}
+			return err
+		}
+
+		switch err := CheckRefreshToken(stored, now); {
+		case errors.Is(err, ErrRefreshTokenReused):
+			reused = true
+			return RevokeSessionFamily(tx, stored.FamilyID, now)
+		case err != nil:
+			return err
+		}
+
+		if err := tx.Model(&stored).Update("rotated_at", now).Error; err != nil {
+			return err
+		}
+
+		var err error
+		session, err = StartSession(tx, stored.UserID, stored.FamilyID, now, config)
+		return err
+	})
+	if err == nil && reused {
+		return Session{}, ErrRefreshTokenReused
+	}
+	return session, err
+}
+
+// RevokeSessionFamily revokes every refresh token of a login and the access tokens issued with them
+func RevokeSessionFamily(tx *gorm.DB, familyID string, now time.Time) error {
+	return revokeSessions(tx, now, "family_id = ?", familyID)
+}
+
+// RevokeUserSessions revokes every refresh and access token of a user, logging them out on all devices
+func RevokeUserSessions(tx *gorm.DB, userID uint, now time.Time) error {
+	return revokeSessions(tx, now, "user_id = ?", userID)
+}
+
+// RevokeOtherSessions revokes every session of a user except the login with familyID
+func RevokeOtherSessions(tx *gorm.DB, userID uint, familyID string, now time.Time) error {
+	return revokeSessions(tx, now, "user_id = ? AND family_id <> ?", userID, familyID)
+}
+
+func revokeSessions(tx *gorm.DB, now time.Time, query string, args ...interface{}) error {
+	// Access tokens that have not expired yet are rejected by their jti
+	var live []models.RefreshToken
+	if err := tx.Where(query, args...).Where("access_expires_at > ?", now).Find(&live).Error; err != nil {
+		return err
+	}
+	if len(live) > 0 {
+		revoked := make([]models.RevokedToken, 0, len(live))
+		for _, token := range live {
+			revoked = append(revoked, models.RevokedToken{
+				JTI:       token.AccessJTI,
+				UserID:    token.UserID,
+				ExpiresAt: token.AccessExpiresAt,
+				RevokedAt: now,
+			})
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&revoked).Error; err != nil {
+			return err
+		}
+	}
+
+	return tx.Model(&models.RefreshToken{}).
+		Where(query, args...).
+		Where("revoked_at IS NULL").
+		Update("revoked_at", now).Error
+}
+
+// IsTokenRevoked reports whether the access token with this jti has been revoked
+func IsTokenRevoked(db *gorm.DB, jti string) (bool, error) {
+	var count int64
+	err := db.Model(&models.RevokedToken{}).Where("jti = ?", jti).Count(&count).Error
+	return count > 0, err
+}
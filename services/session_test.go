@@ -0,0 +1,68 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"jakarta-luxury-rent-car/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadTokenConfig(t *testing.T) {
+	t.Setenv("ACCESS_TOKEN_TTL", "")
+	t.Setenv("REFRESH_TOKEN_TTL", "")
+	config, err := LoadTokenConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, TokenConfig{AccessTTL: 15 * time.Minute, RefreshTTL: 720 * time.Hour}, config)
+
+	t.Setenv("ACCESS_TOKEN_TTL", "5m")
+	t.Setenv("REFRESH_TOKEN_TTL", "168h")
+	config, err = LoadTokenConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, TokenConfig{AccessTTL: 5 * time.Minute, RefreshTTL: 168 * time.Hour}, config)
+
+	t.Setenv("ACCESS_TOKEN_TTL", "-5m")
+	_, err = LoadTokenConfig()
+	assert.ErrorContains(t, err, "invalid ACCESS_TOKEN_TTL")
+
+	t.Setenv("ACCESS_TOKEN_TTL", "")
+	t.Setenv("REFRESH_TOKEN_TTL", "a week")
+	_, err = LoadTokenConfig()
+	assert.ErrorContains(t, err, "invalid REFRESH_TOKEN_TTL")
+}
+
+func TestNewSecretToken(t *testing.T) {
+	token, hash, err := NewSecretToken()
+	assert.NoError(t, err)
+	assert.Len(t, token, 43)
+	assert.Len(t, hash, 64)
+	assert.Equal(t, hash, HashSecretToken(token))
+
+	other, _, err := NewSecretToken()
+	assert.NoError(t, err)
+	assert.NotEqual(t, token, other)
+}
+
+func TestNewTokenID(t *testing.T) {
+	id, err := NewTokenID()
+	assert.NoError(t, err)
+	assert.Len(t, id, 32)
+}
+
+func TestCheckRefreshToken(t *testing.T) {
+	now := time.Date(2024, time.August, 17, 9, 0, 0, 0, time.UTC)
+	token := models.RefreshToken{ExpiresAt: now.Add(time.Hour)}
+	assert.NoError(t, CheckRefreshToken(token, now))
+
+	assert.ErrorIs(t, CheckRefreshToken(token, now.Add(time.Hour)), ErrRefreshTokenInvalid)
+
+	// A rotated token presented again means it was copied
+	rotated := token
+	rotated.RotatedAt = &now
+	assert.ErrorIs(t, CheckRefreshToken(rotated, now), ErrRefreshTokenReused)
+
+	// Revoked tokens are simply invalid, the family is already revoked
+	rotated.RevokedAt = &now
+	assert.ErrorIs(t, CheckRefreshToken(rotated, now), ErrRefreshTokenInvalid)
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+)
+
+// SMTPNotifier sends plain text emails through an SMTP server
+type SMTPNotifier struct {
+	addr     string
+	auth     smtp.Auth
+	from     string
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewSMTPNotifier creates an email notifier, username may be empty for servers without authentication
+func NewSMTPNotifier(host, port, username, password, from string) *SMTPNotifier {
+	notifier := &SMTPNotifier{
+		addr:     net.JoinHostPort(host, port),
+		from:     from,
+		sendMail: smtp.SendMail,
+	}
+	if username != "" {
+		notifier.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return notifier
+}
+
+func (n *SMTPNotifier) Channel() string {
+	return ChannelEmail
+}
+
+func (n *SMTPNotifier) Send(to Recipient, msg Message) error {
+	if to.Email == "" {
+		return ErrNoRecipientAddress
+	}
+
+	subject := msg.Subject
+	if subject == "" {
+		subject = "Jakarta Luxury Rent Car"
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "From: %s\r\n", n.from)
+	fmt.Fprintf(&body, "To: %s\r\n", to.Email)
+	fmt.Fprintf(&body, "Subject: %s\r\n", subject)
+	body.WriteString("MIME-Version: 1.0\r\n")
+	body.WriteString("Content-Type: text/plain; charset=\"UTF-8\"\r\n\r\n")
+	body.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+
+	if err := n.sendMail(n.addr, n.auth, n.from, []string{to.Email}, []byte(body.String())); err != nil {
+		return fmt.Errorf("failed to send email: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TwilioNotifier sends WhatsApp or SMS messages through the Twilio Messages API
+type TwilioNotifier struct {
+	channel    string
+	accountSID string
+	authToken  string
+	from       string
+	BaseURL    string
+	Client     *http.Client
+}
+
+// NewTwilioNotifier creates a notifier for ChannelWhatsApp or ChannelSMS sending from the given number,
+// e.g. "+14155238886"
+func NewTwilioNotifier(channel, accountSID, authToken, from string) *TwilioNotifier {
+	return &TwilioNotifier{
+		channel:    channel,
+		accountSID: accountSID,
+		authToken:  authToken,
+		from:       strings.TrimPrefix(from, "whatsapp:"),
+		BaseURL:    "https://api.twilio.com",
+		Client:     &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+func (n *TwilioNotifier) Channel() string {
+	return n.channel
+}
+
+func (n *TwilioNotifier) Send(to Recipient, msg Message) error {
+	if to.PhoneNumber == "" {
+		return ErrNoRecipientAddress
+	}
+
+	toNumber := "+" + strings.TrimPrefix(to.PhoneNumber, "+")
+	fromNumber := n.from
+	if n.channel == ChannelWhatsApp {
+		toNumber = "whatsapp:" + toNumber
+		fromNumber = "whatsapp:" + fromNumber
+	}
+
+	formData := url.Values{}
+	formData.Set("To", toNumber)
+	formData.Set("From", fromNumber)
+	formData.Set("Body", msg.Body)
+
+	twilioURL := fmt.Sprintf("%s/2010-04-01/Accounts/%s/Messages.json", n.BaseURL, n.accountSID)
+	req, err := http.NewRequest(http.MethodPost, twilioURL, strings.NewReader(formData.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create Twilio request: %v", err)
+	}
+	req.SetBasicAuth(n.accountSID, n.authToken)
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := n.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send Twilio request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("failed to send %s notification via Twilio, status: %d", n.channel, resp.StatusCode)
+	}
+
+	return nil
+}
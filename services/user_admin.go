@@ -0,0 +1,52 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"jakarta-luxury-rent-car/models"
+)
+
+// InvitationTTL is how long an invitation link can be used
+const InvitationTTL = 72 * time.Hour
+
+var (
+	ErrUnknownRole        = errors.New("unknown role")
+	ErrSelfManagement     = errors.New("admins cannot change their own role or suspend themselves")
+	ErrInvitationRole     = errors.New("invitations are for staff, driver, owner or admin accounts")
+	ErrInvitationNotFound = errors.New("invitation not found")
+	ErrInvitationUsed     = errors.New("invitation has already been used")
+	ErrInvitationExpired  = errors.New("invitation has expired")
+	ErrUserAlreadyExists  = errors.New("user already exists with this email")
+)
+
+// CheckUserManagement validates that actor may change the role of or suspend target.
+// Admins cannot act on themselves so the last admin cannot lock everybody out.
+func CheckUserManagement(actorID, targetID uint) error {
+	if actorID == targetID {
+		return ErrSelfManagement
+	}
+	return nil
+}
+
+// CheckInvitationRole validates the role an invitation grants. Customers register themselves.
+func CheckInvitationRole(role string) error {
+	if !IsRole(role) {
+		return ErrUnknownRole
+	}
+	if role == RoleUser {
+		return ErrInvitationRole
+	}
+	return nil
+}
+
+// CheckInvitation validates that an invitation can still be accepted at now
+func CheckInvitation(invitation models.UserInvitation, now time.Time) error {
+	if invitation.AcceptedAt != nil {
+		return ErrInvitationUsed
+	}
+	if !now.Before(invitation.ExpiresAt) {
+		return ErrInvitationExpired
+	}
+	return nil
+}
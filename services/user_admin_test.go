@@ -0,0 +1,34 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"jakarta-luxury-rent-car/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckUserManagement(t *testing.T) {
+	assert.NoError(t, CheckUserManagement(1, 2))
+	assert.ErrorIs(t, CheckUserManagement(1, 1), ErrSelfManagement)
+}
+
+func TestCheckInvitationRole(t *testing.T) {
+	assert.NoError(t, CheckInvitationRole(RoleStaff))
+	assert.NoError(t, CheckInvitationRole(RoleDriver))
+	assert.NoError(t, CheckInvitationRole(RoleOwner))
+	assert.ErrorIs(t, CheckInvitationRole(RoleUser), ErrInvitationRole)
+	assert.ErrorIs(t, CheckInvitationRole("root"), ErrUnknownRole)
+}
+
+func TestCheckInvitation(t *testing.T) {
+	now := time.Date(2024, time.August, 17, 9, 0, 0, 0, time.UTC)
+	invitation := models.UserInvitation{ExpiresAt: now.Add(time.Hour)}
+	assert.NoError(t, CheckInvitation(invitation, now))
+
+	assert.ErrorIs(t, CheckInvitation(invitation, now.Add(time.Hour)), ErrInvitationExpired)
+
+	invitation.AcceptedAt = &now
+	assert.ErrorIs(t, CheckInvitation(invitation, now), ErrInvitationUsed)
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"jakarta-luxury-rent-car/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Wallet transaction types stored in wallet_transactions.type
+const (
+	WalletOpeningBalance = "opening_balance" // Only written by sql/wallet_opening_balance.sql, never posted by the app
+	WalletTopUp          = "topup"
+	WalletRentalPayment  = "rental_payment"
+	WalletRefund         = "refund"
+	WalletPenalty        = "penalty"
+	WalletAdjustment     = "adjustment"
+)
+
+var (
+	ErrInvalidWalletEntry = errors.New("invalid wallet entry")
+	ErrInsufficientFunds  = errors.New("insufficient deposit balance")
+)
+
+// WalletEntry is a change to a customer's deposit. Amount is positive for every type except
+// adjustments, which carry their own sign; debit types are stored as negative amounts.
+//
+// The ledger is single-entry on purpose: the customer deposit is the only account the app owns.
+// The other side of every entry already has its own record (the gateway invoice of a top up, the
+// rental it paid for, the refund row), which the entry points to through RentalID and Description,
+// so a contra row would duplicate them without adding a check. balance_after, the append-only
+// trigger and ReconcileWallets keep the ledger honest instead.
+type WalletEntry struct {
+	UserID      uint
+	Type        string
+	Amount      float64
+	RentalID    *uint
+	Description string
+}
+
+// WalletReconciliation compares a user's cached deposit balance with the sum of their ledger
+type WalletReconciliation struct {
+	UserID           uint    `json:"user_id"`
+	Email            string  `json:"email"`
+	DepositAmount    float64 `json:"deposit_amount"`
+	LedgerBalance    float64 `json:"ledger_balance"`
+	Difference       float64 `json:"difference"`
+	TransactionCount int64   `json:"transaction_count"`
+}
+
+// PostWalletTransaction appends entry to the ledger and moves the user's deposit balance with it.
+// The user row is locked so concurrent entries see each other's balance. Rental payments fail with
+// ErrInsufficientFunds when the deposit does not cover them; penalties and adjustments may overdraw it.
+func PostWalletTransaction(tx *gorm.DB, entry WalletEntry) (models.WalletTransaction, error) {
+	amount, err := signedWalletAmount(entry.Type, entry.Amount)
+	if err != nil {
+		return models.WalletTransaction{}, err
+	}
+
+	var user models.User
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, entry.UserID).Error; err != nil {
+		return models.WalletTransaction{}, err
+	}
+
+	balance := roundCents(user.DepositAmount + amount)
+	if balance < 0 && !mayOverdraw(entry.Type) {
+		return models.WalletTransaction{}, fmt.Errorf("%w: balance %.2f, required %.2f", ErrInsufficientFunds, user.DepositAmount, -amount)
+	}
+	if err := tx.Model(&user).Update("deposit_amount", balance).Error; err != nil {
+		return models.WalletTransaction{}, err
+	}
+
+	transaction := models.WalletTransaction{
+		UserID:       entry.UserID,
+		Type:         entry.Type,
+		Amount:       amount,
+		BalanceAfter: balance,
+		RentalID:     entry.RentalID,
+		Description:  entry.Description,
+		CreatedAt:    time.Now(),
+	}
+
+	return transaction, tx.Create(&transaction).Error
+}
+
+// BalanceBefore is the deposit balance right before transaction was posted
+func BalanceBefore(transaction models.WalletTransaction) float64 {
+	return roundCents(transaction.BalanceAfter - transaction.Amount)
+}
+
+// ReconcileWallets sums the ledger of every user and reports it next to the cached deposit balance
+func ReconcileWallets(db *gorm.DB) ([]WalletReconciliation, error) {
+	var rows []WalletReconciliation
+	err := db.Table("users").
+		Select("users.user_id, users.email, users.deposit_amount, " +
+			"COALESCE(SUM(wallet_transactions.amount), 0) AS ledger_balance, " +
+			"COUNT(wallet_transactions.wallet_transaction_id) AS transaction_count").
+		Joins("LEFT JOIN wallet_transactions ON wallet_transactions.user_id = users.user_id").
+		Group("users.user_id, users.email, users.deposit_amount").
+		Order("users.user_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range rows {
+		rows[i].Difference = roundCents(rows[i].DepositAmount - rows[i].LedgerBalance)
+	}
+
+	return rows, nil
+}
+
+// signedWalletAmount applies the direction of a transaction type to a positive amount
+func signedWalletAmount(transactionType string, amount float64) (float64, error) {
+	switch transactionType {
+	case WalletTopUp, WalletRefund:
+		if amount <= 0 {
+			return 0, fmt.Errorf("%w: %s amount must be positive", ErrInvalidWalletEntry, transactionType)
+		}
+		return roundCents(amount), nil
+	case WalletRentalPayment, WalletPenalty:
+		if amount <= 0 {
+			return 0, fmt.Errorf("%w: %s amount must be positive", ErrInvalidWalletEntry, transactionType)
+		}
+		return -roundCents(amount), nil
+	case WalletAdjustment:
+		if amount == 0 {
+			return 0, fmt.Errorf("%w: adjustment amount must not be zero", ErrInvalidWalletEntry)
+		}
+		return roundCents(amount), nil
+	default:
+		return 0, fmt.Errorf("%w: unknown type %q", ErrInvalidWalletEntry, transactionType)
+	}
+}
+
+// mayOverdraw reports whether a transaction type may take the balance below zero.
+// Penalties are owed whatever the balance is, and adjustments correct the ledger.
+func mayOverdraw(transactionType string) bool {
+	return transactionType == WalletPenalty || transactionType == WalletAdjustment
+}
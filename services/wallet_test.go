@@ -0,0 +1,55 @@
+package services
+
+import (
+	"testing"
+
+	"jakarta-luxury-rent-car/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignedWalletAmount(t *testing.T) {
+	tests := []struct {
+		name            string
+		transactionType string
+		amount          float64
+		expected        float64
+		expectError     bool
+	}{
+		{"top up credits", WalletTopUp, 100, 100, false},
+		{"refund credits", WalletRefund, 49.999, 50, false},
+		{"rental payment debits", WalletRentalPayment, 300, -300, false},
+		{"penalty debits", WalletPenalty, 150, -150, false},
+		{"negative adjustment", WalletAdjustment, -20, -20, false},
+		{"positive adjustment", WalletAdjustment, 20, 20, false},
+		{"negative top up", WalletTopUp, -100, 0, true},
+		{"zero payment", WalletRentalPayment, 0, 0, true},
+		{"zero adjustment", WalletAdjustment, 0, 0, true},
+		{"unknown type", "bonus", 10, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			amount, err := signedWalletAmount(tt.transactionType, tt.amount)
+			if tt.expectError {
+				assert.ErrorIs(t, err, ErrInvalidWalletEntry)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, amount)
+		})
+	}
+}
+
+func TestMayOverdraw(t *testing.T) {
+	assert.False(t, mayOverdraw(WalletRentalPayment))
+	assert.False(t, mayOverdraw(WalletTopUp))
+	assert.False(t, mayOverdraw(WalletRefund))
+	assert.True(t, mayOverdraw(WalletPenalty))
+	assert.True(t, mayOverdraw(WalletAdjustment))
+}
+
+func TestBalanceBefore(t *testing.T) {
+	assert.Equal(t, 400.1, BalanceBefore(models.WalletTransaction{Amount: -300.2, BalanceAfter: 99.9}))
+	assert.Equal(t, 0.0, BalanceBefore(models.WalletTransaction{Amount: 150, BalanceAfter: 150}))
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const defaultXenditBaseURL = "https://api.xendit.co"
+
+// XenditGateway talks to the Xendit REST API
+type XenditGateway struct {
+	apiKey  string
+	baseURL string
+	client  *http.Client
+}
+
+// xenditInvoice is the part of a Xendit invoice the app uses
+type xenditInvoice struct {
+	ID         string     `json:"id"`
+	ExternalID string     `json:"external_id"`
+	Status     string     `json:"status"`
+	Amount     float64    `json:"amount"`
+	InvoiceURL string     `json:"invoice_url"`
+	ExpiryDate *time.Time `json:"expiry_date"`
+	PaidAt     *time.Time `json:"paid_at"`
+}
+
+// xenditRefund is the part of a Xendit refund the app uses
+type xenditRefund struct {
+	ID          string  `json:"id"`
+	ReferenceID string  `json:"reference_id"`
+	Status      string  `json:"status"`
+	Amount      float64 `json:"amount"`
+}
+
+// NewXenditGateway creates a Xendit client. An empty baseURL uses the production API.
+func NewXenditGateway(apiKey, baseURL string) *XenditGateway {
+	if baseURL == "" {
+		baseURL = defaultXenditBaseURL
+	}
+
+	return &XenditGateway{
+		apiKey:  apiKey,
+		baseURL: baseURL,
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// CreateInvoice creates a Xendit invoice in IDR
+func (g *XenditGateway) CreateInvoice(req InvoiceRequest) (GatewayInvoice, error) {
+	items := []map[string]interface{}{}
+	for _, item := range req.Items {
+		entry := map[string]interface{}{
+			"name":     item.Name,
+			"quantity": item.Quantity,
+			"price":    item.Price,
+		}
+		if item.Category != "" {
+			entry["category"] = item.Category
+		}
+		items = append(items, entry)
+	}
+
+	requestBody := map[string]interface{}{
+		"external_id":      req.ExternalID,
+		"amount":           req.Amount,
+		"description":      req.Description,
+		"invoice_duration": int(req.Duration.Seconds()),
+		"currency":         "IDR",
+		"customer": map[string]interface{}{
+			"email":         req.CustomerEmail,
+			"mobile_number": req.CustomerPhone,
+		},
+		"items": items,
+	}
+
+	if len(req.Fees) > 0 {
+		fees := []map[string]interface{}{}
+		for _, fee := range req.Fees {
+			fees = append(fees, map[string]interface{}{
+				"type":  fee.Type,
+				"value": fee.Value,
+			})
+		}
+		requestBody["fees"] = fees
+	}
+
+	var invoice xenditInvoice
+	if err := g.do(http.MethodPost, "/v2/invoices", requestBody, &invoice, nil); err != nil {
+		return GatewayInvoice{}, err
+	}
+
+	// The invoice URL is sent to the customer over WhatsApp
+	if invoice.InvoiceURL == "" {
+		return GatewayInvoice{}, fmt.Errorf("invoice URL not found in response")
+	}
+
+	return invoice.toGatewayInvoice(), nil
+}
+
+// GetInvoice fetches the current state of a Xendit invoice
+func (g *XenditGateway) GetInvoice(invoiceID string) (GatewayInvoice, error) {
+	var invoice xenditInvoice
+	if err := g.do(http.MethodGet, "/v2/invoices/"+invoiceID, nil, &invoice, ErrGatewayInvoiceNotFound); err != nil {
+		return GatewayInvoice{}, err
+	}
+
+	return invoice.toGatewayInvoice(), nil
+}
+
+// ExpireInvoice closes a pending Xendit invoice so it can no longer be paid
+func (g *XenditGateway) ExpireInvoice(invoiceID string) (GatewayInvoice, error) {
+	var invoice xenditInvoice
+	if err := g.do(http.MethodPost, "/invoices/"+invoiceID+"/expire!", nil, &invoice, ErrGatewayInvoiceNotFound); err != nil {
+		return GatewayInvoice{}, err
+	}
+
+	return invoice.toGatewayInvoice(), nil
+}
+
+// Refund returns part or all of a paid Xendit invoice
+func (g *XenditGateway) Refund(req RefundRequest) (GatewayRefund, error) {
+	requestBody := map[string]interface{}{
+		"invoice_id":   req.InvoiceID,
+		"reference_id": req.ReferenceID,
+		"amount":       req.Amount,
+		"reason":       "CANCELLATION",
+		"metadata": map[string]interface{}{
+			"note": req.Reason,
+		},
+	}
+
+	var refund xenditRefund
+	if err := g.do(http.MethodPost, "/refunds", requestBody, &refund, nil); err != nil {
+		return GatewayRefund{}, err
+	}
+
+	return GatewayRefund{
+		ID:          refund.ID,
+		ReferenceID: refund.ReferenceID,
+		Status:      refund.Status,
+		Amount:      refund.Amount,
+	}, nil
+}
+
+// do sends a request to Xendit with basic authentication and decodes the JSON response into out.
+// A 404 is returned as notFound when it is set, so only requests about one invoice report a missing invoice.
+func (g *XenditGateway) do(method, path string, requestBody interface{}, out interface{}, notFound error) error {
+	var body io.Reader
+	if requestBody != nil {
+		jsonBody, err := json.Marshal(requestBody)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request body: %v", err)
+		}
+		body = bytes.NewBuffer(jsonBody)
+	}
+
+	req, err := http.NewRequest(method, g.baseURL+path, body)
+	if err != nil {
+		return fmt.Errorf("failed to create HTTP request: %v", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.SetBasicAuth(g.apiKey, "")
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send HTTP request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound && notFound != nil {
+		return notFound
+	}
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%w: %s - %s", ErrGatewayRejected, resp.Status, string(bodyBytes))
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("received non-2xx response: %s - %s", resp.Status, string(bodyBytes))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response body: %v", err)
+	}
+
+	return nil
+}
+
+func (i xenditInvoice) toGatewayInvoice() GatewayInvoice {
+	return GatewayInvoice{
+		ID:         i.ID,
+		ExternalID: i.ExternalID,
+		Status:     NormalizeInvoiceStatus(i.Status),
+		Amount:     i.Amount,
+		InvoiceURL: i.InvoiceURL,
+		ExpiresAt:  i.ExpiryDate,
+		PaidAt:     i.PaidAt,
+	}
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestXenditGatewayCreateInvoice(t *testing.T) {
+	var received map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v2/invoices", r.URL.Path)
+		username, _, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "secret-key", username)
+
+		json.NewDecoder(r.Body).Decode(&received)
+		w.Write([]byte(`{"id":"inv-1","external_id":"JLRC-booking-7-1","status":"PENDING","amount":300,"invoice_url":"https://checkout.xendit.co/inv-1"}`))
+	}))
+	defer server.Close()
+
+	gateway := NewXenditGateway("secret-key", server.URL)
+	invoice, err := gateway.CreateInvoice(InvoiceRequest{
+		ExternalID: "JLRC-booking-7-1",
+		Amount:     300,
+		Duration:   24 * time.Hour,
+		Items:      []InvoiceItem{{Name: "Car", Quantity: 1, Price: 300}},
+		Fees:       []InvoiceFee{{Type: "Discount", Value: -30}},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "inv-1", invoice.ID)
+	assert.Equal(t, "https://checkout.xendit.co/inv-1", invoice.InvoiceURL)
+	assert.Equal(t, "JLRC-booking-7-1", received["external_id"])
+	assert.Equal(t, float64(86400), received["invoice_duration"])
+	assert.Len(t, received["fees"], 1)
+}
+
+func TestXenditGatewayGetInvoice(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/invoices/inv-1" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"id":"inv-1","status":"SETTLED","amount":300}`))
+	}))
+	defer server.Close()
+
+	gateway := NewXenditGateway("secret-key", server.URL)
+
+	invoice, err := gateway.GetInvoice("inv-1")
+	assert.NoError(t, err)
+	assert.Equal(t, InvoiceStatusPaid, invoice.Status)
+
+	_, err = gateway.GetInvoice("inv-2")
+	assert.ErrorIs(t, err, ErrGatewayInvoiceNotFound)
+}
+
+func TestXenditGatewayRefundNotFoundIsNotAMissingInvoice(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error_code":"DATA_NOT_FOUND"}`))
+	}))
+	defer server.Close()
+
+	gateway := NewXenditGateway("secret-key", server.URL)
+
+	_, err := gateway.Refund(RefundRequest{InvoiceID: "inv-1", ReferenceID: "refund-1", Amount: 100})
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrGatewayInvoiceNotFound)
+	assert.ErrorContains(t, err, "DATA_NOT_FOUND")
+}
+
+func TestXenditGatewayRefundRejectedOnlyOnClientErrors(t *testing.T) {
+	status := http.StatusBadRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	gateway := NewXenditGateway("secret-key", server.URL)
+
+	_, err := gateway.Refund(RefundRequest{InvoiceID: "inv-1", ReferenceID: "refund-1", Amount: 100})
+	assert.ErrorIs(t, err, ErrGatewayRejected)
+
+	status = http.StatusBadGateway
+	_, err = gateway.Refund(RefundRequest{InvoiceID: "inv-1", ReferenceID: "refund-1", Amount: 100})
+	assert.Error(t, err)
+	assert.False(t, GatewayRejected(err))
+}
+
+func TestNewPaymentGatewayFromEnv(t *testing.T) {
+	t.Setenv("PAYMENT_GATEWAY", "")
+	t.Setenv("API_KEY_XENDIT", "")
+	_, err := NewPaymentGatewayFromEnv()
+	assert.ErrorContains(t, err, "API_KEY_XENDIT")
+
+	t.Setenv("API_KEY_XENDIT", "secret-key")
+	gateway, err := NewPaymentGatewayFromEnv()
+	assert.NoError(t, err)
+	assert.IsType(t, &XenditGateway{}, gateway)
+
+	t.Setenv("PAYMENT_GATEWAY", "stripe")
+	_, err = NewPaymentGatewayFromEnv()
+	assert.Error(t, err)
+}
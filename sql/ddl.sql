@@ -5,7 +5,14 @@ CREATE TABLE Users (
     phone_number VARCHAR(15) NOT NULL,
     address TEXT NOT NULL,
     deposit_amount NUMERIC(10,2) DEFAULT 0,
-    role VARCHAR(20) NOT NULL DEFAULT 'user'
+    role VARCHAR(20) NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'owner', 'staff', 'driver', 'admin')),
+    preferred_channel VARCHAR(10) NOT NULL DEFAULT 'whatsapp' CHECK (preferred_channel IN ('whatsapp', 'email', 'sms')),
+    preferred_language VARCHAR(5) NOT NULL DEFAULT 'id' CHECK (preferred_language IN ('id', 'en')),
+    suspended_at TIMESTAMP,
+    phone_verified_at TIMESTAMP,
+    two_factor_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    failed_login_attempts INT NOT NULL DEFAULT 0,
+    locked_until TIMESTAMP
 );
 
 CREATE TABLE Cars (
@@ -45,26 +52,183 @@ CREATE TABLE Memberships (
     FOREIGN KEY (user_id) REFERENCES Users(user_id)
 );
 
+CREATE TABLE fleet_units (
+    fleet_unit_id SERIAL PRIMARY KEY,
+    car_id INT NOT NULL,
+    license_plate VARCHAR(15) UNIQUE NOT NULL,
+    vin VARCHAR(17) UNIQUE NOT NULL,
+    colour VARCHAR(255) NOT NULL,
+    odometer INT NOT NULL DEFAULT 0 CHECK (odometer >= 0),
+    status VARCHAR(20) NOT NULL DEFAULT 'available' CHECK (status IN ('available', 'rented', 'maintenance', 'retired')),
+    FOREIGN KEY (car_id) REFERENCES Cars(car_id)
+);
+
 CREATE TABLE RentalHistory (
     rental_id SERIAL PRIMARY KEY,
     user_id INT NOT NULL,
     car_id INT NOT NULL,
+    fleet_unit_id INT,
     driver_id INT,
     rental_date TIMESTAMP NOT NULL,
     return_date TIMESTAMP,
     total_cost NUMERIC(10,2) NOT NULL,
-    status VARCHAR(10) NOT NULL CHECK (status IN ('Book', 'Paid', 'Rent', 'Cancel')),
+    status VARCHAR(10) NOT NULL CHECK (status IN ('Book', 'Paid', 'Rent', 'Completed', 'Cancel', 'Expired')),
+    rental_duration VARCHAR(10) NOT NULL DEFAULT 'daily' CHECK (rental_duration IN ('daily', 'weekly', 'monthly')),
     package_id INT,
     airport_transfer BOOLEAN DEFAULT FALSE,
     pickup_location TEXT,
     dropoff_location TEXT,
     concierge_services BOOLEAN DEFAULT FALSE,
+    actual_return_date TIMESTAMP,
+    return_odometer INT,
+    return_fuel_level INT CHECK (return_fuel_level BETWEEN 0 AND 100),
+    late_fee NUMERIC(10,2) DEFAULT 0,
+    payment_due_at TIMESTAMP,
+    cost_breakdown JSONB,
     FOREIGN KEY (user_id) REFERENCES Users(user_id),
     FOREIGN KEY (car_id) REFERENCES Cars(car_id),
+    FOREIGN KEY (fleet_unit_id) REFERENCES fleet_units(fleet_unit_id),
     FOREIGN KEY (driver_id) REFERENCES Drivers(driver_id),
     FOREIGN KEY (package_id) REFERENCES EventPackages(package_id)
 );
 
+-- Availability checks look up overlapping rentals per car
+CREATE INDEX idx_rental_history_car_window ON RentalHistory (car_id, rental_date, return_date);
+
+-- The expiry job looks up unpaid bookings past their payment hold window
+CREATE INDEX idx_rental_history_payment_due ON RentalHistory (payment_due_at) WHERE status = 'Book';
+
+CREATE TABLE rental_status_events (
+    event_id SERIAL PRIMARY KEY,
+    rental_id INT NOT NULL,
+    from_status VARCHAR(10),
+    to_status VARCHAR(10) NOT NULL,
+    actor_user_id INT,
+    actor_role VARCHAR(20) NOT NULL,
+    reason TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (rental_id) REFERENCES RentalHistory(rental_id),
+    FOREIGN KEY (actor_user_id) REFERENCES Users(user_id)
+);
+
+CREATE INDEX idx_rental_status_events_rental ON rental_status_events (rental_id);
+
+CREATE TABLE wallet_transactions (
+    wallet_transaction_id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL,
+    type VARCHAR(20) NOT NULL CHECK (type IN ('opening_balance', 'topup', 'rental_payment', 'refund', 'penalty', 'adjustment')),
+    amount NUMERIC(12,2) NOT NULL,
+    balance_after NUMERIC(12,2) NOT NULL,
+    rental_id INT,
+    description TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (user_id) REFERENCES Users(user_id),
+    FOREIGN KEY (rental_id) REFERENCES RentalHistory(rental_id)
+);
+
+CREATE INDEX idx_wallet_transactions_user ON wallet_transactions (user_id, wallet_transaction_id);
+
+-- The ledger is append-only, corrections are posted as adjustment entries
+CREATE FUNCTION wallet_transactions_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'wallet_transactions is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER wallet_transactions_no_update_delete
+    BEFORE UPDATE OR DELETE ON wallet_transactions
+    FOR EACH ROW EXECUTE FUNCTION wallet_transactions_append_only();
+
+CREATE TABLE deposit_top_ups (
+    deposit_top_up_id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL,
+    amount NUMERIC(12,2) NOT NULL CHECK (amount > 0),
+    status VARCHAR(10) NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'PAID', 'EXPIRED', 'UNDERPAID')),
+    invoice_url TEXT,
+    expires_at TIMESTAMP,
+    paid_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (user_id) REFERENCES Users(user_id)
+);
+
+CREATE INDEX idx_deposit_top_ups_user ON deposit_top_ups (user_id);
+
+CREATE TABLE invoices (
+    invoice_id SERIAL PRIMARY KEY,
+    external_id VARCHAR(64) UNIQUE NOT NULL,
+    xendit_invoice_id VARCHAR(64),
+    rental_id INT,
+    deposit_top_up_id INT,
+    purpose VARCHAR(20) NOT NULL CHECK (purpose IN ('booking', 'supplement', 'topup')),
+    amount NUMERIC(10,2) NOT NULL,
+    status VARCHAR(10) NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'PAID', 'EXPIRED', 'UNDERPAID')),
+    invoice_url TEXT,
+    expires_at TIMESTAMP,
+    paid_at TIMESTAMP,
+    paid_amount NUMERIC(10,2) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (rental_id) REFERENCES RentalHistory(rental_id),
+    FOREIGN KEY (deposit_top_up_id) REFERENCES deposit_top_ups(deposit_top_up_id),
+    -- An invoice bills either a rental or a top up
+    CHECK ((rental_id IS NULL) <> (deposit_top_up_id IS NULL))
+);
+
+CREATE INDEX idx_invoices_rental ON invoices (rental_id);
+CREATE INDEX idx_invoices_deposit_top_up ON invoices (deposit_top_up_id);
+
+CREATE TABLE refunds (
+    refund_id SERIAL PRIMARY KEY,
+    rental_id INT NOT NULL,
+    user_id INT NOT NULL,
+    amount NUMERIC(12,2) NOT NULL CHECK (amount > 0),
+    method VARCHAR(10) NOT NULL CHECK (method IN ('wallet', 'gateway')),
+    status VARCHAR(12) NOT NULL DEFAULT 'requested' CHECK (status IN ('requested', 'processing', 'completed', 'failed')),
+    reason TEXT,
+    invoice_id INT,
+    reference_id VARCHAR(64) UNIQUE NOT NULL,
+    gateway_refund_id VARCHAR(64),
+    wallet_transaction_id INT,
+    failure_reason TEXT,
+    completed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (rental_id) REFERENCES RentalHistory(rental_id),
+    FOREIGN KEY (user_id) REFERENCES Users(user_id),
+    FOREIGN KEY (invoice_id) REFERENCES invoices(invoice_id),
+    FOREIGN KEY (wallet_transaction_id) REFERENCES wallet_transactions(wallet_transaction_id)
+);
+
+CREATE INDEX idx_refunds_rental ON refunds (rental_id);
+CREATE INDEX idx_refunds_user ON refunds (user_id);
+
+CREATE TABLE car_rates (
+    car_id INT PRIMARY KEY,
+    daily_rate NUMERIC(10,2) NOT NULL CHECK (daily_rate >= 0),
+    weekly_rate NUMERIC(10,2) NOT NULL DEFAULT 0 CHECK (weekly_rate >= 0),
+    monthly_rate NUMERIC(10,2) NOT NULL DEFAULT 0 CHECK (monthly_rate >= 0),
+    FOREIGN KEY (car_id) REFERENCES Cars(car_id)
+);
+
+CREATE TABLE addon_prices (
+    code VARCHAR(30) PRIMARY KEY,
+    price NUMERIC(10,2) NOT NULL CHECK (price >= 0),
+    per_day BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+CREATE TABLE pricing_settings (
+    pricing_setting_id SERIAL PRIMARY KEY,
+    weekend_surcharge_percent NUMERIC(5,2) NOT NULL DEFAULT 0,
+    holiday_surcharge_percent NUMERIC(5,2) NOT NULL DEFAULT 0,
+    rounding VARCHAR(10) NOT NULL DEFAULT 'full_day' CHECK (rounding IN ('full_day', 'hourly'))
+);
+
+CREATE TABLE holidays (
+    holiday_date DATE PRIMARY KEY,
+    name TEXT NOT NULL
+);
+
 CREATE TABLE CallAssistance (
     assistance_id SERIAL PRIMARY KEY,
     rental_id INT NOT NULL,
@@ -74,4 +238,131 @@ CREATE TABLE CallAssistance (
     location TEXT NOT NULL,
     FOREIGN KEY (rental_id) REFERENCES RentalHistory(rental_id),
     FOREIGN KEY (user_id) REFERENCES Users(user_id)
-);
\ No newline at end of file
+);
+
+CREATE TABLE outbox_messages (
+    outbox_message_id SERIAL PRIMARY KEY,
+    kind VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(10) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'delivered', 'failed')),
+    attempts INT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    delivered_at TIMESTAMP
+);
+
+CREATE INDEX idx_outbox_messages_due ON outbox_messages (next_attempt_at) WHERE status = 'pending';
+
+CREATE TABLE message_templates (
+    message_template_id SERIAL PRIMARY KEY,
+    event VARCHAR(50) NOT NULL,
+    locale VARCHAR(5) NOT NULL CHECK (locale IN ('id', 'en')),
+    subject TEXT NOT NULL,
+    body TEXT NOT NULL,
+    updated_by INT NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (updated_by) REFERENCES Users(user_id),
+    UNIQUE (event, locale)
+);
+
+CREATE TABLE reminder_logs (
+    reminder_log_id SERIAL PRIMARY KEY,
+    rental_id INT NOT NULL,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('pickup', 'return', 'overdue', 'unpaid_invoice')),
+    sent_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (rental_id) REFERENCES RentalHistory(rental_id),
+    UNIQUE (rental_id, kind)
+);
+
+CREATE TABLE user_invitations (
+    user_invitation_id SERIAL PRIMARY KEY,
+    email VARCHAR(255) NOT NULL,
+    role VARCHAR(20) NOT NULL CHECK (role IN ('owner', 'staff', 'driver', 'admin')),
+    token_hash CHAR(64) UNIQUE NOT NULL,
+    invited_by INT NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    accepted_at TIMESTAMP,
+    user_id INT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (invited_by) REFERENCES Users(user_id),
+    FOREIGN KEY (user_id) REFERENCES Users(user_id)
+);
+
+CREATE INDEX idx_user_invitations_email ON user_invitations (email);
+
+CREATE TABLE refresh_tokens (
+    refresh_token_id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL,
+    family_id VARCHAR(32) NOT NULL,
+    token_hash CHAR(64) UNIQUE NOT NULL,
+    access_jti VARCHAR(32) NOT NULL,
+    access_expires_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    rotated_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (user_id) REFERENCES Users(user_id)
+);
+
+CREATE INDEX idx_refresh_tokens_user_id ON refresh_tokens (user_id);
+CREATE INDEX idx_refresh_tokens_family_id ON refresh_tokens (family_id);
+
+CREATE TABLE revoked_tokens (
+    jti VARCHAR(32) PRIMARY KEY,
+    user_id INT NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES Users(user_id)
+);
+
+CREATE INDEX idx_revoked_tokens_expires_at ON revoked_tokens (expires_at);
+
+CREATE TABLE one_time_codes (
+    one_time_code_id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL,
+    purpose VARCHAR(20) NOT NULL CHECK (purpose IN ('phone_verification', 'password_reset', 'login')),
+    code_hash TEXT NOT NULL,
+    challenge_hash CHAR(64) UNIQUE,
+    attempts INT NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP NOT NULL,
+    consumed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (user_id) REFERENCES Users(user_id)
+);
+
+CREATE INDEX idx_one_time_codes_user_purpose ON one_time_codes (user_id, purpose);
+
+CREATE TABLE audit_logs (
+    audit_log_id SERIAL PRIMARY KEY,
+    actor_user_id INT,
+    actor_role VARCHAR(20) NOT NULL,
+    action VARCHAR(50) NOT NULL,
+    entity_type VARCHAR(30) NOT NULL,
+    entity_id VARCHAR(100) NOT NULL,
+    before JSONB,
+    after JSONB,
+    ip_address VARCHAR(45),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (actor_user_id) REFERENCES Users(user_id)
+);
+
+CREATE INDEX idx_audit_logs_actor_user_id ON audit_logs (actor_user_id);
+CREATE INDEX idx_audit_logs_action ON audit_logs (action);
+CREATE INDEX idx_audit_logs_entity ON audit_logs (entity_type, entity_id);
+CREATE INDEX idx_audit_logs_created_at ON audit_logs (created_at);
+
+-- The audit log is append-only: rows can be inserted but never changed or removed
+CREATE FUNCTION audit_logs_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_logs is append-only, % is not allowed', TG_OP;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_logs_no_update_or_delete
+    BEFORE UPDATE OR DELETE ON audit_logs
+    FOR EACH ROW EXECUTE FUNCTION audit_logs_append_only();
+
+CREATE TRIGGER audit_logs_no_truncate
+    BEFORE TRUNCATE ON audit_logs
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_logs_append_only();
@@ -36,10 +36,16 @@ INSERT INTO Users (email, password, phone_number, address, deposit_amount, role)
 ('user1@example.com', 'user1', '+6281211115030', 'Jl. Kebon Jeruk No. 12, Jakarta', 100.00, 'user'),
 ('user2@example.com', 'user2', '+6285894999562', 'Jl. Kebon Jeruk No. 12, Jakarta', 50.00, 'admin');
 
+-- Opening balances, so every deposit is backed by the wallet ledger
+INSERT INTO wallet_transactions (user_id, type, amount, balance_after, description)
+SELECT user_id, 'adjustment', deposit_amount, deposit_amount, 'Opening balance'
+FROM Users
+WHERE deposit_amount <> 0;
+
 -- Insert into Rental History
-INSERT INTO rental_histories (user_id, car_id, driver_id, rental_date, return_date, total_cost, status, package_id, airport_transfer, pickup_location, dropoff_location, concierge_services) VALUES
-(1, 1, 1, '2024-08-01', '2024-08-05', 500.00, 'Completed', 1, TRUE, 'Jakarta Airport', 'Hotel Indonesia Kempinski', FALSE),
-(2, 2, 2, '2024-08-10', NULL, 150.00, 'Ongoing', NULL, FALSE, 'Grand Hyatt Jakarta', 'Plaza Indonesia', TRUE);
+INSERT INTO rental_histories (user_id, car_id, driver_id, rental_date, return_date, total_cost, status, package_id, airport_transfer, pickup_location, dropoff_location, concierge_services, actual_return_date, return_odometer, return_fuel_level) VALUES
+(1, 1, 1, '2024-08-01', '2024-08-05', 500.00, 'Completed', 1, TRUE, 'Jakarta Airport', 'Hotel Indonesia Kempinski', FALSE, '2024-08-05 10:00:00', 45210, 80),
+(2, 2, 2, '2024-08-10', NULL, 150.00, 'Rent', NULL, FALSE, 'Grand Hyatt Jakarta', 'Plaza Indonesia', TRUE, NULL, NULL, NULL);
 
 -- Insert into Roadside Assistance
 INSERT INTO CallAssistance (rental_id, user_id, callassistance_date, description, location) VALUES
@@ -51,3 +57,13 @@ INSERT INTO Memberships (user_id, discount_level) VALUES
 (1, 'Silver'),
 (2, 'Gold');
 
+
+-- Insert into Pricing (add-on prices match the previous fixed prices)
+INSERT INTO addon_prices (code, price, per_day) VALUES
+('driver', 100.00, TRUE),
+('pickup_dropoff', 100.00, FALSE),
+('airport_transfer', 50.00, FALSE),
+('concierge', 100.00, FALSE);
+
+INSERT INTO pricing_settings (weekend_surcharge_percent, holiday_surcharge_percent, rounding) VALUES
+(0, 0, 'full_day');
@@ -0,0 +1,26 @@
+-- One-off migration for databases that held deposits before the wallet ledger existed.
+-- Every user whose deposit_amount differs from the sum of their ledger gets one opening_balance
+-- entry for the difference, dated just before their first ledger entry, so the reconciliation
+-- report starts at zero. Running it again inserts nothing.
+BEGIN;
+
+ALTER TABLE wallet_transactions DROP CONSTRAINT IF EXISTS wallet_transactions_type_check;
+ALTER TABLE wallet_transactions ADD CONSTRAINT wallet_transactions_type_check
+    CHECK (type IN ('opening_balance', 'topup', 'rental_payment', 'refund', 'penalty', 'adjustment'));
+
+INSERT INTO wallet_transactions (user_id, type, amount, balance_after, description, created_at)
+SELECT users.user_id,
+       'opening_balance',
+       users.deposit_amount - COALESCE(ledger.total, 0),
+       users.deposit_amount - COALESCE(ledger.total, 0),
+       'Deposit balance before the wallet ledger',
+       COALESCE(ledger.first_created_at - INTERVAL '1 second', NOW())
+FROM users
+LEFT JOIN (
+    SELECT user_id, SUM(amount) AS total, MIN(created_at) AS first_created_at
+    FROM wallet_transactions
+    GROUP BY user_id
+) ledger ON ledger.user_id = users.user_id
+WHERE users.deposit_amount <> COALESCE(ledger.total, 0);
+
+COMMIT;